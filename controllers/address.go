@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// addressOutput is an address along with its rendering in the local layout of its country
+type addressOutput struct {
+	models.Address
+	Formatted string `json:"formatted"`
+}
+
+type addressController struct {
+	userStore    models.Users
+	countryStore models.Countries
+	addressStore models.Addresses
+}
+
+func NewAddressController(us models.Users, cs models.Countries, as models.Addresses) *addressController {
+	return &addressController{
+		userStore:    us,
+		countryStore: cs,
+		addressStore: as,
+	}
+}
+
+// pathAddressID function takes a gin context and
+// returns the address ID in the path parameter or writes back the error to the API response
+func pathAddressID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("addressID"))
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPathParam.Error()})
+		return 0, false
+	}
+
+	return id, true
+}
+
+// userExists method takes a gin context and a user ID
+// checks that the user exists and writes back the error to the API response otherwise
+func (a *addressController) userExists(ctx *gin.Context, userID int) bool {
+	if _, err := a.userStore.GetByID(userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// checkAddress method takes a gin context and an Address object
+// fetches its country, validates the address against the rules of the country
+// and writes back the error to the API response if it is invalid
+func (a *addressController) checkAddress(ctx *gin.Context, address *models.Address) (*models.Country, bool) {
+	if address.CountryID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errAddressCountry.Error()})
+		return nil, false
+	}
+
+	country, err := a.countryStore.GetByID(address.CountryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errAddressCountry.Error()})
+		return nil, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := validateAddress(address, country); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return country, true
+}
+
+// output method takes an Address object and the countries already fetched by ID
+// renders the address in the layout of its country and returns it along with an error if any
+func (a *addressController) output(address models.Address, countries map[int]*models.Country) (addressOutput, error) {
+	country, ok := countries[address.CountryID]
+	if !ok {
+		var err error
+		country, err = a.countryStore.GetByID(address.CountryID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			country = &models.Country{}
+		} else if err != nil {
+			return addressOutput{}, err
+		}
+
+		countries[address.CountryID] = country
+	}
+
+	return addressOutput{Address: address, Formatted: formatAddress(&address, country)}, nil
+}
+
+// List method takes a gin context, validates the path parameter
+// authorizes the user based on JWT headers, interacts with the model
+// and writes back every address of the user to the API response
+func (a *addressController) List(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if !a.userExists(ctx, id) {
+		return
+	}
+
+	addresses, err := a.addressStore.ListByUser(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	countries := make(map[int]*models.Country)
+	outputs := make([]addressOutput, 0, len(addresses))
+	for _, address := range addresses {
+		output, err := a.output(address, countries)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		outputs = append(outputs, output)
+	}
+
+	ctx.JSON(http.StatusOK, outputs)
+}
+
+// Create method takes a gin context, validates the path parameter and request body
+// authorizes the user based on JWT headers, validates the address against the rules of its country
+// creates it using model and writes back to the API response
+func (a *addressController) Create(ctx *gin.Context) {
+	var address models.Address
+
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if err := ctx.ShouldBindBodyWithJSON(&address); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	if !a.userExists(ctx, id) {
+		return
+	}
+
+	address.ID = 0
+	address.UserID = id
+
+	country, ok := a.checkAddress(ctx, &address)
+	if !ok {
+		return
+	}
+
+	if err := a.addressStore.Create(&address); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Location", "/users/"+strconv.Itoa(id)+"/addresses/"+strconv.Itoa(address.ID))
+	ctx.JSON(http.StatusCreated, addressOutput{Address: address, Formatted: formatAddress(&address, country)})
+}
+
+// Get method takes a gin context, validates the path parameters
+// authorizes the user based on JWT headers, interacts with the model
+// and writes back the address to the API response
+func (a *addressController) Get(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	addressID, ok := pathAddressID(ctx)
+	if !ok {
+		return
+	}
+
+	address, err := a.addressStore.Get(id, addressID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errAddressNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	output, err := a.output(*address, make(map[int]*models.Country))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, output)
+}
+
+// Update method takes a gin context, validates the path parameters and request body
+// authorizes the user based on JWT headers, validates the address against the rules of its country
+// replaces it using model and writes back to the API response
+func (a *addressController) Update(ctx *gin.Context) {
+	var address models.Address
+
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	addressID, ok := pathAddressID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.ShouldBindBodyWithJSON(&address); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	existing, err := a.addressStore.Get(id, addressID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errAddressNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	address.ID = existing.ID
+	address.UserID = existing.UserID
+	address.CreatedAt = existing.CreatedAt
+
+	country, ok := a.checkAddress(ctx, &address)
+	if !ok {
+		return
+	}
+
+	if err := a.addressStore.Update(&address); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, addressOutput{Address: address, Formatted: formatAddress(&address, country)})
+}
+
+// Delete method takes a gin context, validates the path parameters
+// authorizes the user based on JWT headers, deletes the address using model
+// and writes back to the API response
+func (a *addressController) Delete(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	addressID, ok := pathAddressID(ctx)
+	if !ok {
+		return
+	}
+
+	err := a.addressStore.Delete(id, addressID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errAddressNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
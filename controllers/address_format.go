@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+// addressFormat holds the postal rules of a country and the local layout its addresses are written in
+type addressFormat struct {
+	// layout has a line per address line where %N is the recipient, %A the street lines,
+	// %C the city, %S the subdivision and %Z the postal code
+	layout string
+	// postalCode is the pattern of the country's postal codes, the postal code is optional without it
+	postalCode        *regexp.Regexp
+	postalCodeExample string
+	// subdivisionName is how the country calls its subdivisions, such as state or province
+	subdivisionName     string
+	subdivisionRequired bool
+	// subdivisions are the accepted subdivision codes, any subdivision is accepted when it is empty
+	subdivisions []string
+}
+
+// defaultAddressFormat is used for the countries without rules of their own
+var defaultAddressFormat = addressFormat{
+	layout:          "%N\n%A\n%C %S %Z",
+	subdivisionName: "subdivision",
+}
+
+// addressFormats holds the postal rules and layout of the countries with known rules keyed by their country code
+var addressFormats = map[string]addressFormat{
+	"US": {
+		layout:              "%N\n%A\n%C, %S %Z",
+		postalCode:          regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		postalCodeExample:   "94043",
+		subdivisionName:     "state",
+		subdivisionRequired: true,
+		subdivisions: []string{
+			"AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "FL", "GA", "HI", "ID", "IL", "IN", "IA", "KS", "KY",
+			"LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE", "NV", "NH", "NJ", "NM", "NY", "NC", "ND",
+			"OH", "OK", "OR", "PA", "RI", "SC", "SD", "TN", "TX", "UT", "VT", "VA", "WA", "WV", "WI", "WY", "DC",
+			"AS", "GU", "MP", "PR", "VI", "AA", "AE", "AP",
+		},
+	},
+	"CA": {
+		layout:              "%N\n%A\n%C %S %Z",
+		postalCode:          regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] ?\d[ABCEGHJ-NPRSTV-Z]\d$`),
+		postalCodeExample:   "K1A 0B1",
+		subdivisionName:     "province",
+		subdivisionRequired: true,
+		subdivisions:        []string{"AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"},
+	},
+	"GB": {
+		layout:            "%N\n%A\n%C\n%Z",
+		postalCode:        regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+		postalCodeExample: "SW1A 1AA",
+		subdivisionName:   "county",
+	},
+	"DE": {
+		layout:            "%N\n%A\n%Z %C",
+		postalCode:        regexp.MustCompile(`^\d{5}$`),
+		postalCodeExample: "10115",
+		subdivisionName:   "state",
+	},
+	"FR": {
+		layout:            "%N\n%A\n%Z %C",
+		postalCode:        regexp.MustCompile(`^\d{2} ?\d{3}$`),
+		postalCodeExample: "75008",
+		subdivisionName:   "region",
+	},
+	"IN": {
+		layout:              "%N\n%A\n%C %Z\n%S",
+		postalCode:          regexp.MustCompile(`^[1-9]\d{2} ?\d{3}$`),
+		postalCodeExample:   "110001",
+		subdivisionName:     "state",
+		subdivisionRequired: true,
+	},
+	"AU": {
+		layout:              "%N\n%A\n%C %S %Z",
+		postalCode:          regexp.MustCompile(`^\d{4}$`),
+		postalCodeExample:   "2000",
+		subdivisionName:     "state",
+		subdivisionRequired: true,
+		subdivisions:        []string{"ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA"},
+	},
+	"JP": {
+		layout:              "〒%Z\n%S %C\n%A\n%N",
+		postalCode:          regexp.MustCompile(`^\d{3}-?\d{4}$`),
+		postalCodeExample:   "100-0001",
+		subdivisionName:     "prefecture",
+		subdivisionRequired: true,
+	},
+	"BR": {
+		layout:              "%N\n%A\n%C-%S\n%Z",
+		postalCode:          regexp.MustCompile(`^\d{5}-?\d{3}$`),
+		postalCodeExample:   "01310-100",
+		subdivisionName:     "state",
+		subdivisionRequired: true,
+		subdivisions: []string{
+			"AC", "AL", "AP", "AM", "BA", "CE", "DF", "ES", "GO", "MA", "MT", "MS", "MG", "PA",
+			"PB", "PR", "PE", "PI", "RJ", "RN", "RS", "RO", "RR", "SC", "SP", "SE", "TO",
+		},
+	},
+}
+
+// addressFormatOf function takes a country and
+// returns the postal rules and layout of its addresses
+func addressFormatOf(country *models.Country) addressFormat {
+	if format, ok := addressFormats[strings.ToUpper(country.CountryCode)]; ok {
+		return format
+	}
+
+	return defaultAddressFormat
+}
+
+// validateAddress function takes an Address object and its country
+// normalizes the postal code and subdivision, validates the address against the rules of the country and
+// returns an error for any missing or invalid values
+func validateAddress(address *models.Address, country *models.Country) error {
+	if !models.ValidAddressType(address.Type) {
+		return errors.New("address type must be one of home, billing or shipping")
+	}
+
+	if strings.TrimSpace(address.Line1) == "" || strings.TrimSpace(address.City) == "" {
+		return errors.New("address line1 and city cannot be empty")
+	}
+
+	format := addressFormatOf(country)
+
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	if format.postalCode != nil && !format.postalCode.MatchString(address.PostalCode) {
+		return fmt.Errorf("address postal code must be a postal code of %s such as %s", country.CommonName, format.postalCodeExample)
+	}
+
+	address.Subdivision = strings.TrimSpace(address.Subdivision)
+	if format.subdivisionRequired && address.Subdivision == "" {
+		return fmt.Errorf("address %s is required in %s", format.subdivisionName, country.CommonName)
+	}
+
+	if len(format.subdivisions) > 0 {
+		address.Subdivision = strings.ToUpper(address.Subdivision)
+		if !slices.Contains(format.subdivisions, address.Subdivision) {
+			return fmt.Errorf("address %s must be one of the codes %s", format.subdivisionName, strings.Join(format.subdivisions, ", "))
+		}
+	}
+
+	return nil
+}
+
+// formatAddress function takes an Address object and its country and
+// returns the address written in the local layout of the country followed by the country name
+func formatAddress(address *models.Address, country *models.Country) string {
+	street := address.Line1
+	if address.Line2 != "" {
+		street += "\n" + address.Line2
+	}
+
+	replacer := strings.NewReplacer(
+		"%N", address.Recipient,
+		"%A", street,
+		"%C", address.City,
+		"%S", address.Subdivision,
+		"%Z", address.PostalCode,
+	)
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(replacer.Replace(addressFormatOf(country).layout), "\n") {
+		// Optional parts left empty would otherwise leave extra spaces behind
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	// International mail carries the destination country on the last line in capitals
+	if country.CommonName != "" {
+		lines = append(lines, strings.ToUpper(country.CommonName))
+	}
+
+	return strings.Join(lines, "\n")
+}
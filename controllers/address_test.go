@@ -0,0 +1,445 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_validateAddress runs unit tests on the function validateAddress
+func Test_validateAddress(t *testing.T) {
+	us := &models.Country{ID: 1, CommonName: "United States", CountryCode: "US"}
+	gb := &models.Country{ID: 2, CommonName: "United Kingdom", CountryCode: "GB"}
+	nz := &models.Country{ID: 3, CommonName: "New Zealand", CountryCode: "NZ"}
+
+	tests := []struct {
+		name            string
+		address         models.Address
+		country         *models.Country
+		wantPostalCode  string
+		wantSubdivision string
+		wantErr         bool
+	}{
+		{
+			name:            "Valid address normalizing the subdivision",
+			address:         models.Address{Type: models.AddressHome, Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", Subdivision: "ca", PostalCode: "94043"},
+			country:         us,
+			wantPostalCode:  "94043",
+			wantSubdivision: "CA",
+		},
+		{
+			name:           "Valid address normalizing the postal code",
+			address:        models.Address{Type: models.AddressBilling, Line1: "10 Downing Street", City: "London", PostalCode: " sw1a 2aa "},
+			country:        gb,
+			wantPostalCode: "SW1A 2AA",
+		},
+		{
+			name:    "Valid address of a country without rules",
+			address: models.Address{Type: models.AddressShipping, Line1: "1 Queen Street", City: "Auckland"},
+			country: nz,
+		},
+		{
+			name:    "Invalid type",
+			address: models.Address{Type: "work", Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", Subdivision: "CA", PostalCode: "94043"},
+			country: us,
+			wantErr: true,
+		},
+		{
+			name:    "Missing city",
+			address: models.Address{Type: models.AddressHome, Line1: "1600 Amphitheatre Pkwy", Subdivision: "CA", PostalCode: "94043"},
+			country: us,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid postal code",
+			address: models.Address{Type: models.AddressHome, Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", Subdivision: "CA", PostalCode: "9404"},
+			country: us,
+			wantErr: true,
+		},
+		{
+			name:    "Missing subdivision",
+			address: models.Address{Type: models.AddressHome, Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", PostalCode: "94043"},
+			country: us,
+			wantErr: true,
+		},
+		{
+			name:    "Unknown subdivision",
+			address: models.Address{Type: models.AddressHome, Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", Subdivision: "XX", PostalCode: "94043"},
+			country: us,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAddress(&tt.address, tt.country)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && (tt.address.PostalCode != tt.wantPostalCode || tt.address.Subdivision != tt.wantSubdivision) {
+				t.Errorf("validateAddress() = %q, %q, want %q, %q", tt.address.PostalCode, tt.address.Subdivision, tt.wantPostalCode, tt.wantSubdivision)
+			}
+		})
+	}
+}
+
+// Test_formatAddress runs unit tests on the function formatAddress
+func Test_formatAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address models.Address
+		country *models.Country
+		want    string
+	}{
+		{
+			name:    "United States layout",
+			address: models.Address{Recipient: "Jane Doe", Line1: "1600 Amphitheatre Pkwy", City: "Mountain View", Subdivision: "CA", PostalCode: "94043"},
+			country: &models.Country{CommonName: "United States", CountryCode: "US"},
+			want:    "Jane Doe\n1600 Amphitheatre Pkwy\nMountain View, CA 94043\nUNITED STATES",
+		},
+		{
+			name:    "Postal code before the city",
+			address: models.Address{Recipient: "Max Mustermann", Line1: "Unter den Linden 1", Line2: "Hinterhaus", City: "Berlin", PostalCode: "10117"},
+			country: &models.Country{CommonName: "Germany", CountryCode: "DE"},
+			want:    "Max Mustermann\nUnter den Linden 1\nHinterhaus\n10117 Berlin\nGERMANY",
+		},
+		{
+			name:    "Default layout without recipient",
+			address: models.Address{Line1: "1 Queen Street", City: "Auckland", PostalCode: "1010"},
+			country: &models.Country{CommonName: "New Zealand", CountryCode: "NZ"},
+			want:    "1 Queen Street\nAuckland 1010\nNEW ZEALAND",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAddress(&tt.address, tt.country); got != tt.want {
+				t.Errorf("formatAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_addressController_Create runs unit tests on the method Create
+func Test_addressController_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+
+	us := &models.Country{ID: 1, CommonName: "United States", CountryCode: "US"}
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to payload",
+			reqBody:  `{"line1": 1}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to user not found",
+			reqBody: `{"type": "home", "line1": "1600 Amphitheatre Pkwy", "city": "Mountain View", "subdivision": "CA", "postalCode": "94043", "countryID": 1}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "Failure case due to unknown country",
+			reqBody: `{"type": "home", "line1": "1600 Amphitheatre Pkwy", "city": "Mountain View", "subdivision": "CA", "postalCode": "94043", "countryID": 9}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				countryModel.EXPECT().GetByID(9).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to invalid postal code",
+			reqBody: `{"type": "home", "line1": "1600 Amphitheatre Pkwy", "city": "Mountain View", "subdivision": "CA", "postalCode": "ABC", "countryID": 1}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				countryModel.EXPECT().GetByID(1).Return(us, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to model",
+			reqBody: `{"type": "home", "line1": "1600 Amphitheatre Pkwy", "city": "Mountain View", "subdivision": "CA", "postalCode": "94043", "countryID": 1}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				countryModel.EXPECT().GetByID(1).Return(us, nil)
+				addressModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "Success case",
+			reqBody: `{"id": 7, "userID": 2, "type": "home", "line1": "1600 Amphitheatre Pkwy", "city": "Mountain View", "subdivision": "ca", "postalCode": "94043", "countryID": 1}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				countryModel.EXPECT().GetByID(1).Return(us, nil)
+				addressModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(address *models.Address) error {
+					if address.ID != 0 || address.UserID != 1 || address.Subdivision != "CA" {
+						t.Errorf("unexpected created address: %v", address)
+					}
+					address.ID = 3
+					return nil
+				})
+			},
+			wantCode: http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+			ctx.Request.Header.Set("Content-Type", "application/json")
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			aH := NewAddressController(userModel, countryModel, addressModel)
+
+			aH.Create(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("addressController.Create() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode == http.StatusCreated && w.Header().Get("Location") != "/users/1/addresses/3" {
+				t.Errorf("addressController.Create() Location = %v", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+// Test_addressController_Get runs unit tests on the method Get
+func Test_addressController_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+
+	tests := []struct {
+		name      string
+		addressID string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Failure case due to invalid address ID",
+			addressID: "abc",
+			expMock:   func() {},
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Failure case due to address not found",
+			addressID: "2",
+			expMock: func() {
+				addressModel.EXPECT().Get(1, 2).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:      "Success case",
+			addressID: "2",
+			expMock: func() {
+				addressModel.EXPECT().Get(1, 2).Return(&models.Address{ID: 2, UserID: 1, Line1: "1 Queen Street", City: "Auckland", CountryID: 3}, nil)
+				countryModel.EXPECT().GetByID(3).Return(&models.Country{ID: 3, CommonName: "New Zealand", CountryCode: "NZ"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "GET"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}, {Key: "addressID", Value: tt.addressID}}
+
+			aH := NewAddressController(userModel, countryModel, addressModel)
+
+			aH.Get(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("addressController.Get() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_addressController_Update runs unit tests on the method Update
+func Test_addressController_Update(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+
+	existing := &models.Address{ID: 2, UserID: 1, Type: models.AddressHome, Line1: "1 Queen Street", City: "Auckland", CountryID: 3}
+	reqBody := `{"type": "billing", "line1": "10 Downing Street", "city": "London", "postalCode": "sw1a 2aa", "countryID": 2}`
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to payload",
+			reqBody:  `{"city": 1}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to address not found",
+			reqBody: reqBody,
+			expMock: func() {
+				addressModel.EXPECT().Get(1, 2).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "Failure case due to missing country",
+			reqBody: `{"type": "billing", "line1": "10 Downing Street", "city": "London"}`,
+			expMock: func() {
+				addressModel.EXPECT().Get(1, 2).Return(existing, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Success case",
+			reqBody: reqBody,
+			expMock: func() {
+				addressModel.EXPECT().Get(1, 2).Return(existing, nil)
+				countryModel.EXPECT().GetByID(2).Return(&models.Country{ID: 2, CommonName: "United Kingdom", CountryCode: "GB"}, nil)
+				addressModel.EXPECT().Update(gomock.Any()).DoAndReturn(func(address *models.Address) error {
+					if address.ID != 2 || address.UserID != 1 || address.PostalCode != "SW1A 2AA" {
+						t.Errorf("unexpected updated address: %v", address)
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "PUT"
+			ctx.Request.Header.Set("Content-Type", "application/json")
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}, {Key: "addressID", Value: "2"}}
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			aH := NewAddressController(userModel, countryModel, addressModel)
+
+			aH.Update(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("addressController.Update() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_addressController_Delete runs unit tests on the method Delete
+func Test_addressController_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name: "Failure case due to address not found",
+			expMock: func() {
+				addressModel.EXPECT().Delete(1, 2).Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				addressModel.EXPECT().Delete(1, 2).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				addressModel.EXPECT().Delete(1, 2).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "DELETE"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}, {Key: "addressID", Value: "2"}}
+
+			aH := NewAddressController(userModel, countryModel, addressModel)
+
+			aH.Delete(ctx)
+			ctx.Writer.WriteHeaderNow()
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("addressController.Delete() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+// recordAudit function takes the audit event model, a user ID, the event type, its detail and the client IP
+// and records the event in the audit trail of the user
+// store failures are only logged so that a broken store never fails the action being recorded
+func recordAudit(ae models.AuditEvents, userID int, eventType, detail, ip string) {
+	event := &models.AuditEvent{UserID: userID, Type: eventType, Detail: detail, IP: ip}
+	if err := ae.Create(event); err != nil {
+		log.Printf("failed to record %s event of user %d: %v", eventType, userID, err)
+	}
+}
+
+// recordLock function takes the audit event model, a user ID, the block applied by a failed login,
+// the client IP and the current time and records the lock when it is the account rather than the IP being locked
+func recordLock(ae models.AuditEvents, userID int, block *loginBlock, ip string, now time.Time) {
+	if block.status != http.StatusLocked {
+		return
+	}
+
+	recordAudit(ae, userID, models.AuditAccountLocked, "until "+now.Add(block.retryAfter).UTC().Format(time.RFC3339), ip)
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/storage"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultAvatarMaxSize is the largest image in bytes accepted as an avatar
+	defaultAvatarMaxSize = 5 << 20
+	// maxAvatarDimension bounds the width and height of an uploaded image before it is decoded
+	maxAvatarDimension = 4096
+	// defaultAvatarCacheMaxAge is how long clients may cache an avatar without revalidating it
+	defaultAvatarCacheMaxAge = time.Hour
+)
+
+// avatarSizes are the widths in pixels of the square thumbnails kept for every avatar, largest first
+var avatarSizes = []int{256, 128, 64}
+
+// avatarTypes are the image types, as sniffed from their content, accepted as avatars
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type avatarController struct {
+	userStore models.Users
+	blobStore storage.BlobStore
+}
+
+func NewAvatarController(us models.Users, bs storage.BlobStore) *avatarController {
+	return &avatarController{
+		userStore: us,
+		blobStore: bs,
+	}
+}
+
+// avatarKey function takes a user ID and a thumbnail size and
+// returns the key the thumbnail is stored under
+func avatarKey(userID int, size int) string {
+	return fmt.Sprintf("avatars/%d/%d", userID, size)
+}
+
+// DeleteAvatar function takes a context, a BlobStore and a user ID
+// removes every thumbnail of the user's avatar and returns an error if any
+func DeleteAvatar(ctx context.Context, bs storage.BlobStore, userID int) error {
+	for _, size := range avatarSizes {
+		if err := bs.Delete(ctx, avatarKey(userID, size)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// thumbnail function takes an image and a size
+// crops the centered square of the image and
+// returns it scaled to size by size pixels
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+
+	return dst
+}
+
+// avatarThumbnails function takes an uploaded image and its content type and
+// returns its thumbnails in the order of avatarSizes and their content type along with an error if any
+// Photos stay JPEG while every other type becomes PNG to keep its transparency
+func avatarThumbnails(data []byte, contentType string) ([][]byte, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errAvatarInvalid
+	}
+
+	if config.Width > maxAvatarDimension || config.Height > maxAvatarDimension {
+		return nil, "", errAvatarDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errAvatarInvalid
+	}
+
+	outputType := "image/png"
+	if contentType == "image/jpeg" {
+		outputType = contentType
+	}
+
+	thumbnails := make([][]byte, 0, len(avatarSizes))
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		if outputType == "image/jpeg" {
+			err = jpeg.Encode(&buf, thumbnail(img, size), &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, thumbnail(img, size))
+		}
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		thumbnails = append(thumbnails, buf.Bytes())
+	}
+
+	return thumbnails, outputType, nil
+}
+
+// readAvatar function takes a gin context and the largest accepted size
+// reads the image of the multipart field avatar and
+// writes back the error to the API response if it is missing or too large
+func readAvatar(ctx *gin.Context, maxSize int64) ([]byte, bool) {
+	header, err := ctx.FormFile("avatar")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errAvatarTooLarge.Error()})
+		return nil, false
+	} else if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errAvatarMissing.Error()})
+		return nil, false
+	}
+
+	if header.Size > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errAvatarTooLarge.Error()})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	} else if int64(len(data)) > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errAvatarTooLarge.Error()})
+		return nil, false
+	}
+
+	return data, true
+}
+
+// Upload method takes a gin context, validates the path parameter
+// authorizes the user based on JWT headers, validates the type and size of the multipart image
+// stores its resized thumbnails and writes back to the API response
+func (a *avatarController) Upload(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if _, err := a.userStore.GetByID(id); errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxSize := int64(intFromEnv("AVATAR_MAX_SIZE", defaultAvatarMaxSize))
+
+	// The multipart envelope around the image is allowed a little more than the image itself
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+64<<10)
+
+	data, ok := readAvatar(ctx, maxSize)
+	if !ok {
+		return
+	}
+
+	// The declared content type of the part is not trusted, the type is sniffed from the image
+	uploadedType := http.DetectContentType(data)
+	if !avatarTypes[uploadedType] {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errAvatarType.Error()})
+		return
+	}
+
+	thumbnails, contentType, err := avatarThumbnails(data, uploadedType)
+	if errors.Is(err, errAvatarInvalid) || errors.Is(err, errAvatarDimensions) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, size := range avatarSizes {
+		if err := a.blobStore.Put(ctx.Request.Context(), avatarKey(id, size), thumbnails[i], contentType); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"url":         "/users/" + strconv.Itoa(id) + "/avatar",
+		"sizes":       avatarSizes,
+		"contentType": contentType,
+	})
+}
+
+// Get method takes a gin context, validates the path parameter and the size query parameter
+// authorizes the user based on JWT headers, fetches the thumbnail from the blob store
+// and writes it back to the API response with caching headers
+func (a *avatarController) Get(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	size := avatarSizes[0]
+	if value := ctx.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || !slices.Contains(avatarSizes, parsed) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": errAvatarSize.Error()})
+			return
+		}
+
+		size = parsed
+	}
+
+	blob, err := a.blobStore.Get(ctx.Request.Context(), avatarKey(id, size))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errAvatarNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sum := sha256.Sum256(blob.Data)
+	maxAge := durationFromEnv("AVATAR_CACHE_MAX_AGE", defaultAvatarCacheMaxAge)
+
+	ctx.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	ctx.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	ctx.Header("Content-Type", blob.ContentType)
+
+	// ServeContent answers If-None-Match and If-Modified-Since with 304 Not Modified
+	http.ServeContent(ctx.Writer, ctx.Request, "", blob.ModTime, bytes.NewReader(blob.Data))
+}
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/storage"
+	"gorm.io/gorm"
+)
+
+// pngImage function takes a width and a height and returns an encoded PNG of that size
+func pngImage(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Unexpected error '%v' when encoding an image", err)
+	}
+
+	return buf.Bytes()
+}
+
+// multipartBody function takes a field name and its content and
+// returns a multipart form along with its content type
+func multipartBody(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile(field, "avatar.png")
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when creating a multipart form", err)
+	}
+
+	part.Write(content)
+	writer.Close()
+
+	return &body, writer.FormDataContentType()
+}
+
+// Test_avatarThumbnails runs unit tests on the function avatarThumbnails
+func Test_avatarThumbnails(t *testing.T) {
+	thumbnails, contentType, err := avatarThumbnails(pngImage(t, 300, 200), "image/png")
+	if err != nil || contentType != "image/png" || len(thumbnails) != len(avatarSizes) {
+		t.Fatalf("avatarThumbnails() = %d thumbnails, %v, %v", len(thumbnails), contentType, err)
+	}
+
+	for i, size := range avatarSizes {
+		config, err := png.DecodeConfig(bytes.NewReader(thumbnails[i]))
+		if err != nil || config.Width != size || config.Height != size {
+			t.Errorf("avatarThumbnails() thumbnail %d is %dx%d, want %dx%d", i, config.Width, config.Height, size, size)
+		}
+	}
+
+	if _, _, err := avatarThumbnails(pngImage(t, maxAvatarDimension+1, 1), "image/png"); err != errAvatarDimensions {
+		t.Errorf("avatarThumbnails() error = %v, want %v", err, errAvatarDimensions)
+	}
+}
+
+// Test_avatarController_Upload runs unit tests on the method Upload
+func Test_avatarController_Upload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	blobStore := storage.NewMockBlobStore(ctrl)
+
+	t.Setenv("AVATAR_MAX_SIZE", "4096")
+
+	tests := []struct {
+		name     string
+		field    string
+		content  []byte
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:    "Success case",
+			field:   "avatar",
+			content: pngImage(t, 40, 30),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				blobStore.EXPECT().Put(gomock.Any(), "avatars/1/256", gomock.Any(), "image/png").Return(nil)
+				blobStore.EXPECT().Put(gomock.Any(), "avatars/1/128", gomock.Any(), "image/png").Return(nil)
+				blobStore.EXPECT().Put(gomock.Any(), "avatars/1/64", gomock.Any(), "image/png").Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Failure case due to missing field",
+			field:   "picture",
+			content: pngImage(t, 40, 30),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to unsupported type",
+			field:   "avatar",
+			content: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+			},
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:    "Failure case due to image too large",
+			field:   "avatar",
+			content: append(pngImage(t, 1, 1), make([]byte, 8192)...),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+			},
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "Failure case due to corrupt image",
+			field:   "avatar",
+			content: pngImage(t, 40, 30)[:64],
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to user not found",
+			field:   "avatar",
+			content: pngImage(t, 40, 30),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "Failure case due to blob store",
+			field:   "avatar",
+			content: pngImage(t, 40, 30),
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				blobStore.EXPECT().Put(gomock.Any(), "avatars/1/256", gomock.Any(), "image/png").Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			body, contentType := multipartBody(t, tt.field, tt.content)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPut, "/users/1/avatar", body)
+			ctx.Request.Header.Set("Content-Type", contentType)
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			aH := NewAvatarController(userModel, blobStore)
+
+			aH.Upload(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("avatarController.Upload() = %v, want %v, body %s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
+// Test_avatarController_Get runs unit tests on the method Get
+func Test_avatarController_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	blobStore := storage.NewMockBlobStore(ctrl)
+
+	blob := &storage.Blob{Data: pngImage(t, 1, 1), ContentType: "image/png", ModTime: time.Now().Add(-time.Hour)}
+	sum := sha256.Sum256(blob.Data)
+	avatarETag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	tests := []struct {
+		name        string
+		size        string
+		ifNoneMatch string
+		expMock     func()
+		wantCode    int
+	}{
+		{
+			name: "Success case",
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(blob, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case with a smaller size",
+			size: "64",
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/64").Return(blob, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "Success case with an unchanged avatar",
+			ifNoneMatch: avatarETag,
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(blob, nil)
+			},
+			wantCode: http.StatusNotModified,
+		},
+		{
+			name:        "Success case with a changed avatar",
+			ifNoneMatch: `"00000000000000000000000000000000"`,
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(blob, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Failure case due to unknown size",
+			size:     "100",
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to avatar not found",
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(nil, storage.ErrBlobNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "Failure case due to blob store",
+			expMock: func() {
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Method: http.MethodGet,
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+
+			if tt.size != "" {
+				ctx.Request.URL.RawQuery = "size=" + tt.size
+			}
+
+			if tt.ifNoneMatch != "" {
+				ctx.Request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			aH := NewAvatarController(userModel, blobStore)
+
+			aH.Get(ctx)
+
+			// gin writes the status of a response without a body, such as 304, once the handler returns
+			ctx.Writer.WriteHeaderNow()
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("avatarController.Get() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if w.Code == http.StatusOK && (w.Header().Get("ETag") == "" || w.Header().Get("Cache-Control") != "private, max-age=3600" ||
+				w.Header().Get("Content-Type") != "image/png") {
+				t.Errorf("avatarController.Get() headers = %v", w.Header())
+			}
+		})
+	}
+}
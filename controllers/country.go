@@ -20,9 +20,34 @@ type MetaCountry struct {
 		Official string `json:"official"`
 	}
 	Cca2      string   `json:"cca2"`
+	Cca3      string   `json:"cca3"`
 	Capital   []string `json:"capital"`
 	Region    string   `json:"region"`
 	SubRegion string   `json:"subregion"`
+	Idd       struct {
+		Root     string   `json:"root"`
+		Suffixes []string `json:"suffixes"`
+	} `json:"idd"`
+}
+
+// callingCodes function takes the international direct dialing data of a country and
+// returns its calling codes, the root followed by each suffix
+// Countries sharing a root such as +1 list their area codes as suffixes, so a phone number is matched to a single country
+func callingCodes(root string, suffixes []string) []string {
+	if root == "" {
+		return nil
+	}
+
+	if len(suffixes) == 0 {
+		return []string{root}
+	}
+
+	codes := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		codes = append(codes, root+suffix)
+	}
+
+	return codes
 }
 
 type countryController struct {
@@ -70,8 +95,10 @@ func (c *countryController) GetMetaCountries(ctx *gin.Context) {
 			CommonName:   mc.Name.Common,
 			OfficialName: mc.Name.Official,
 			CountryCode:  mc.Cca2,
+			Alpha3Code:   mc.Cca3,
 			Region:       mc.Region,
 			SubRegion:    mc.SubRegion,
+			CallingCodes: callingCodes(mc.Idd.Root, mc.Idd.Suffixes),
 		})
 
 		if len(mc.Capital) > 0 {
@@ -14,6 +14,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// Test_callingCodes runs unit tests on the function callingCodes
+func Test_callingCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		suffixes []string
+		want     []string
+	}{
+		{name: "Single suffix", root: "+9", suffixes: []string{"1"}, want: []string{"+91"}},
+		{name: "Area codes as suffixes", root: "+1", suffixes: []string{"201", "415"}, want: []string{"+1201", "+1415"}},
+		{name: "Root without suffixes", root: "+1", suffixes: nil, want: []string{"+1"}},
+		{name: "No calling code", root: "", suffixes: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callingCodes(tt.root, tt.suffixes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("callingCodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // Test_countryController_GetCountries runs unit tests on the method GetCountries
 func Test_countryController_GetCountries(t *testing.T) {
 	ctrl := gomock.NewController(t)
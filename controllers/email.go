@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"gorm.io/gorm"
+)
+
+const defaultEmailVerificationTTL = 48 * time.Hour
+
+// verifyEmailInput is the request body accepted by the verify email API
+type verifyEmailInput struct {
+	Token string `json:"token"`
+}
+
+type emailController struct {
+	userStore         models.Users
+	oneTimeTokenStore models.OneTimeTokens
+	notifier          notifier.Notifier
+}
+
+func NewEmailController(us models.Users, ot models.OneTimeTokens, n notifier.Notifier) *emailController {
+	return &emailController{
+		userStore:         us,
+		oneTimeTokenStore: ot,
+		notifier:          n,
+	}
+}
+
+// sendEmailVerification function takes the one time token model, a notifier and a user
+// invalidates the previous verification tokens, creates a new one bound to the user's email
+// notifies the user with the verification link and returns an error if any
+func sendEmailVerification(ot models.OneTimeTokens, n notifier.Notifier, user *models.User) error {
+	if err := ot.InvalidateByUser(user.ID, models.PurposeEmailVerification); err != nil {
+		return err
+	}
+
+	verificationToken, err := generateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := ot.Create(&models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   models.PurposeEmailVerification,
+		Email:     user.Email,
+		TokenHash: hashToken(verificationToken),
+		ExpiresAt: time.Now().Add(durationFromEnv("EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL)),
+	}); err != nil {
+		return err
+	}
+
+	return n.Notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body:    "Use the following link to verify your email: " + os.Getenv("EMAIL_VERIFICATION_URL") + "?token=" + verificationToken,
+	})
+}
+
+// Verify method takes a gin context, validates the request body
+// consumes the verification token and marks the email it was sent to
+// as verified using model and writes back to the API response
+func (e *emailController) Verify(ctx *gin.Context) {
+	var input verifyEmailInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	verificationToken, err := e.oneTimeTokenStore.GetByHash(models.PurposeEmailVerification, hashToken(input.Token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidVerificationToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if verificationToken.UsedAt != nil || verificationToken.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidVerificationToken.Error()})
+		return
+	}
+
+	consumed, err := e.oneTimeTokenStore.Consume(verificationToken.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !consumed {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidVerificationToken.Error()})
+		return
+	}
+
+	// The email may have been changed after this token was sent
+	err = e.userStore.MarkEmailVerified(verificationToken.UserID, verificationToken.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidVerificationToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// Resend method takes a gin context with verified JWT claims
+// sends a new verification link to the email of the authenticated user
+// and writes back to the API response
+func (e *emailController) Resend(ctx *gin.Context) {
+	userID, _ := UserIDFromClaims(claimsFromContext(ctx))
+
+	userData, err := e.userStore.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if userData.EmailVerifiedAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errEmailAlreadyVerified.Error()})
+		return
+	}
+
+	if err := sendEmailVerification(e.oneTimeTokenStore, e.notifier, userData); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "a verification link has been sent to " + userData.Email})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"gorm.io/gorm"
+)
+
+// Test_emailController_Verify runs unit tests on the method Verify
+func Test_emailController_Verify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		reqBody  verifyEmailInput
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to missing token",
+			expMock:  func() {},
+			reqBody:  verifyEmailInput{},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to unknown token",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeEmailVerification, hashToken("token")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody:  verifyEmailInput{Token: "token"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to expired token",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeEmailVerification, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					Email:     "test@gmail.com",
+					ExpiresAt: time.Now().Add(-time.Hour),
+				}, nil)
+			},
+			reqBody:  verifyEmailInput{Token: "token"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to changed email",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeEmailVerification, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					Email:     "old@gmail.com",
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				oneTimeTokenModel.EXPECT().Consume(1).Return(true, nil)
+				userModel.EXPECT().MarkEmailVerified(1, "old@gmail.com").Return(gorm.ErrRecordNotFound)
+			},
+			reqBody:  verifyEmailInput{Token: "token"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeEmailVerification, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					Email:     "test@gmail.com",
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				oneTimeTokenModel.EXPECT().Consume(1).Return(true, nil)
+				userModel.EXPECT().MarkEmailVerified(1, "test@gmail.com").Return(nil)
+			},
+			reqBody:  verifyEmailInput{Token: "token"},
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			jsonbytes, _ := json.Marshal(tt.reqBody)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+
+			eH := NewEmailController(userModel, oneTimeTokenModel, notifierMock)
+
+			eH.Verify(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("emailController.Verify() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_emailController_Resend runs unit tests on the method Resend
+func Test_emailController_Resend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Failure case due to verified email",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com", EmailVerifiedAt: &verifiedAt}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				oneTimeTokenModel.EXPECT().InvalidateByUser(1, models.PurposeEmailVerification).Return(nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				notifierMock.EXPECT().Notify(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1"})
+
+			eH := NewEmailController(userModel, oneTimeTokenModel, notifierMock)
+
+			eH.Resend(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("emailController.Resend() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -3,7 +3,74 @@ package controllers
 import "errors"
 
 var (
-	errPayload          = errors.New("invalid data in request body")
-	ErrMissingPathParam = errors.New("please check for missing path parameter")
-	ErrInvalidPathParam = errors.New("invalid path parameter")
+	errPayload                  = errors.New("invalid data in request body")
+	errInvalidRefreshToken      = errors.New("invalid refresh token")
+	errExpiredRefreshToken      = errors.New("refresh token is expired")
+	errRefreshTokenReused       = errors.New("refresh token was already used, all sessions of this token family are revoked")
+	errTokenNotRevocable        = errors.New("jwt token has no jti and cannot be revoked, please login again")
+	errInvalidResetToken        = errors.New("password reset token is invalid, expired or already used")
+	errInvalidVerificationToken = errors.New("email verification token is invalid, expired or already used")
+	errEmailAlreadyVerified     = errors.New("email is already verified")
+	errCurrentPassword          = errors.New("current password does not match")
+	errSamePassword             = errors.New("new password must be different from the current password")
+	errPatchContentType         = errors.New("patch must be sent as application/merge-patch+json or application/json-patch+json")
+	errPatchTestFailed          = errors.New("json patch test operation failed")
+	errVersionMismatch          = errors.New("user was modified since it was read, fetch it again and retry")
+	errAccountLocked            = errors.New("too many failed logins, the account is temporarily locked")
+	errTooManyLoginAttempts     = errors.New("too many failed logins, please retry later")
+	errUnlockTarget             = errors.New("an email or an ip is required to unlock logins")
+	errMFAAlreadyEnabled        = errors.New("two-factor authentication is already enabled")
+	errMFANotEnrolled           = errors.New("two-factor authentication is not enrolled")
+	errInvalidMFACode           = errors.New("two-factor authentication code is invalid or was already used")
+	errInvalidMFAToken          = errors.New("mfa token is invalid, expired or already used")
+	errMFAKeyNotConfigured      = errors.New("MFA_ENCRYPTION_KEY is not configured")
+	errInvalidRole              = errors.New("role must be one of user, support or admin")
+	errOwnRoleChange            = errors.New("admins cannot change their own role")
+	errNotRestorable            = errors.New("user is not deleted or can no longer be restored")
+	errEmailTaken               = errors.New("email is already used by another user")
+	errEmailOfDeletedUser       = errors.New("email belongs to a deleted account, login with it within the grace period to restore the account")
+	errExportNotFound           = errors.New("export not found")
+	errExportNotReady           = errors.New("export is not completed")
+	errExportExpired            = errors.New("export has expired, please request a new one")
+	errAvatarMissing            = errors.New("an image is required in the multipart field avatar")
+	errAvatarTooLarge           = errors.New("avatar image is too large")
+	errAvatarType               = errors.New("avatar must be a JPEG, PNG, GIF or WebP image")
+	errAvatarInvalid            = errors.New("avatar image could not be decoded")
+	errAvatarDimensions         = errors.New("avatar image must be at most 4096 pixels wide and high")
+	errAvatarSize               = errors.New("size must be one of 256, 128 or 64")
+	errAvatarNotFound           = errors.New("avatar not found")
+	errPhoneCountry             = errors.New("user phone does not start with a calling code of the user's country")
+	errUnknownCountry           = errors.New("user's country does not exist")
+	errCountryConflict          = errors.New("country and countryID refer to different countries")
+	errAddressCountry           = errors.New("address country does not exist")
+	errAddressNotFound          = errors.New("address not found")
+	errClientName               = errors.New("client name is required")
+	errRedirectURIs             = errors.New("at least one redirect URI is required, each an absolute https URL, or http on a loopback address, without fragment")
+	errClientNotFound           = errors.New("oauth client not found")
+	errUnknownClient            = errors.New("client_id is not a registered client")
+	errClientRedirect           = errors.New("redirect_uri is not registered for the client")
+	errResponseType             = errors.New("response_type must be code")
+	errOpenIDScope              = errors.New("scope must include openid")
+	errPKCERequired             = errors.New("a code_challenge with code_challenge_method S256 is required")
+	errInvalidClient            = errors.New("client authentication failed")
+	errGrantType                = errors.New("grant_type must be authorization_code")
+	errInvalidGrant             = errors.New("authorization code is invalid, expired, already used or was issued for another client or redirect_uri")
+	errCodeVerifier             = errors.New("code_verifier does not match the code_challenge")
+	errConsentDenied            = errors.New("the user denied the consent")
+	errIDTokenKey               = errors.New("ID tokens require an RS256 or EdDSA signing key in JWT_SIGNING_KEY_FILE")
+	errUnknownProvider          = errors.New("identity provider is not configured")
+	errIdentityState            = errors.New("state is invalid, expired or already used, please sign in again")
+	errIdentityCode             = errors.New("the identity provider rejected the code, please sign in again")
+	errIdentityProvider         = errors.New("the identity provider could not be reached, please try again")
+	errProviderEmail            = errors.New("the email of the identity provider account is not verified")
+	errIdentityCountry          = errors.New("a country is required to create an account")
+	errIdentityLinked           = errors.New("the identity provider account is linked to another user")
+	errUnverifiedAccount        = errors.New("an account with this email exists but its email is not verified, please log in or reset its password and link the identity")
+	errIdentityNotFound         = errors.New("identity not found")
+	errPublicClient             = errors.New("public clients cannot introspect tokens")
+	errIntrospectionToken       = errors.New("token is required")
+	ErrMissingPathParam         = errors.New("please check for missing path parameter")
+	ErrInvalidPathParam         = errors.New("invalid path parameter")
+	ErrTokenInvalid             = errors.New("jwt token is invalid")
+	ErrTokenRevoked             = errors.New("jwt token is revoked")
 )
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+// etag function takes a User object and
+// returns the entity tag identifying the version of the user
+func etag(user *models.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// ifMatch function takes a gin context and a User object and
+// reports whether the If-Match header, if present, matches the version of the user
+func ifMatch(ctx *gin.Context, user *models.User) bool {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+
+	current := etag(user)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeVersionConflict function takes a gin context and an error and
+// writes a precondition failure back to the API response if the error is a version conflict
+func writeVersionConflict(ctx *gin.Context, err error) bool {
+	var conflict *models.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	writePreconditionFailed(ctx, &models.User{Version: conflict.CurrentVersion})
+	return true
+}
+
+// writePreconditionFailed function takes a gin context and the current User object and
+// writes a precondition failure with the current entity tag back to the API response
+func writePreconditionFailed(ctx *gin.Context, current *models.User) {
+	ctx.Header("ETag", etag(current))
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": errVersionMismatch.Error()})
+}
@@ -0,0 +1,508 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/storage"
+	"gorm.io/gorm"
+)
+
+// defaultExportTTL is how long a generated archive can be downloaded
+const defaultExportTTL = 24 * time.Hour
+
+// exportSession is a session of the user, made of every refresh token of a token family
+type exportSession struct {
+	ID              string    `json:"id"`
+	StartedAt       time.Time `json:"startedAt"`
+	LastRefreshedAt time.Time `json:"lastRefreshedAt"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	Active          bool      `json:"active"`
+}
+
+// exportFailedLogins is the record of recent failed logins of the account
+type exportFailedLogins struct {
+	Failures     int        `json:"failures"`
+	LastFailedAt time.Time  `json:"lastFailedAt"`
+	LockedUntil  *time.Time `json:"lockedUntil"`
+}
+
+// exportMFA describes the two-factor authentication of the user without its secret
+type exportMFA struct {
+	Method      string     `json:"method"`
+	EnrolledAt  time.Time  `json:"enrolledAt"`
+	ConfirmedAt *time.Time `json:"confirmedAt"`
+}
+
+// exportDocument is everything held on a user, as written to export.json
+// Secrets such as the password hash, token hashes and the TOTP secret are left out
+// The audit trail is split into the logins and every other event such as role changes and lockouts
+type exportDocument struct {
+	ExportedAt   time.Time           `json:"exportedAt"`
+	Profile      models.User         `json:"profile"`
+	Country      *models.Country     `json:"country"`
+	Sessions     []exportSession     `json:"sessions"`
+	Logins       []models.AuditEvent `json:"logins"`
+	AuditEvents  []models.AuditEvent `json:"auditEvents"`
+	FailedLogins *exportFailedLogins `json:"failedLogins"`
+	MFA          *exportMFA          `json:"mfa"`
+	Addresses    []models.Address    `json:"addresses"`
+	Consents     []models.Consent    `json:"consents"`
+	Identities   []models.Identity   `json:"identities"`
+	// Avatar is the largest thumbnail of the uploaded avatar, written as a file of its own
+	Avatar *storage.Blob `json:"-"`
+}
+
+type exportController struct {
+	userStore         models.Users
+	countryStore      models.Countries
+	refreshTokenStore models.RefreshTokens
+	loginAttemptStore models.LoginAttempts
+	mfaStore          models.MFAFactors
+	dataExportStore   models.DataExports
+	addressStore      models.Addresses
+	consentStore      models.Consents
+	identityStore     models.Identities
+	auditEventStore   models.AuditEvents
+	blobStore         storage.BlobStore
+	// background runs the generation of an archive without blocking the request
+	background func(task func())
+}
+
+func NewExportController(us models.Users, cs models.Countries, rt models.RefreshTokens, la models.LoginAttempts, mf models.MFAFactors, de models.DataExports, ad models.Addresses, co models.Consents, is models.Identities, ae models.AuditEvents, bs storage.BlobStore) *exportController {
+	return &exportController{
+		userStore:         us,
+		countryStore:      cs,
+		refreshTokenStore: rt,
+		loginAttemptStore: la,
+		mfaStore:          mf,
+		dataExportStore:   de,
+		addressStore:      ad,
+		consentStore:      co,
+		identityStore:     is,
+		auditEventStore:   ae,
+		blobStore:         bs,
+		background:        func(task func()) { go task() },
+	}
+}
+
+// exportSessions function takes the refresh tokens of a user, oldest first, and
+// returns the sessions they belong to in the order they started
+func exportSessions(tokens []models.RefreshToken, now time.Time) []exportSession {
+	sessions := make([]exportSession, 0)
+	index := make(map[string]int)
+
+	for _, token := range tokens {
+		i, ok := index[token.FamilyID]
+		if !ok {
+			i = len(sessions)
+			index[token.FamilyID] = i
+			sessions = append(sessions, exportSession{ID: token.FamilyID, StartedAt: token.CreatedAt})
+		}
+
+		session := &sessions[i]
+		if token.CreatedAt.After(session.LastRefreshedAt) {
+			session.LastRefreshedAt = token.CreatedAt
+		}
+
+		if token.ExpiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = token.ExpiresAt
+		}
+
+		if token.RevokedAt == nil && token.ExpiresAt.After(now) {
+			session.Active = true
+		}
+	}
+
+	return sessions
+}
+
+// collectExport method takes a user ID
+// gathers everything held on the user from the models and
+// returns the export document along with an error if any
+func (e *exportController) collectExport(userID int) (*exportDocument, error) {
+	now := time.Now()
+
+	user, err := e.userStore.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	doc := &exportDocument{ExportedAt: now, Profile: *user}
+
+	doc.Country, err = e.countryStore.GetByID(user.CountryID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	tokens, err := e.refreshTokenStore.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Sessions = exportSessions(tokens, now)
+
+	events, err := e.auditEventStore.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Logins = make([]models.AuditEvent, 0)
+	doc.AuditEvents = make([]models.AuditEvent, 0)
+	for _, event := range events {
+		if event.Type == models.AuditLogin {
+			doc.Logins = append(doc.Logins, event)
+		} else {
+			doc.AuditEvents = append(doc.AuditEvents, event)
+		}
+	}
+
+	attempt, err := e.loginAttemptStore.Get(accountLoginKey(user.Email))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	} else if err == nil {
+		doc.FailedLogins = &exportFailedLogins{
+			Failures:     attempt.Failures,
+			LastFailedAt: attempt.LastFailedAt,
+			LockedUntil:  attempt.LockedUntil,
+		}
+	}
+
+	factor, err := e.mfaStore.GetTOTP(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	} else if err == nil {
+		doc.MFA = &exportMFA{Method: "totp", EnrolledAt: factor.CreatedAt, ConfirmedAt: factor.ConfirmedAt}
+	}
+
+	doc.Addresses, err = e.addressStore.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Consents, err = e.consentStore.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Identities, err = e.identityStore.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Avatar, err = e.blobStore.Get(context.Background(), avatarKey(userID, avatarSizes[0]))
+	if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// formatTime function takes an optional time and
+// returns it in RFC 3339 format, empty when it is not set
+func formatTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.UTC().Format(time.RFC3339)
+}
+
+// writeCSV function takes a zip writer, a file name and the rows of the file
+// and writes the rows as a CSV file into the archive
+func writeCSV(archive *zip.Writer, name string, rows [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// buildExportArchive function takes an export document and
+// returns a zip archive holding it as export.json along with CSV files of its sections and the avatar
+func buildExportArchive(doc *exportDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	profile := doc.Profile
+	country := ""
+	if doc.Country != nil {
+		country = doc.Country.CommonName
+	}
+
+	dateOfBirth := ""
+	if profile.DateOfBirth != nil {
+		dateOfBirth = profile.DateOfBirth.Format(models.DateLayout)
+	}
+
+	profileRows := [][]string{
+		{
+			"id", "name", "email", "countryID", "country", "role", "phone", "locale", "timezone", "dateOfBirth", "avatarURL",
+			"createdAt", "updatedAt", "emailVerifiedAt",
+		},
+		{
+			strconv.Itoa(profile.ID), profile.Name, profile.Email, strconv.Itoa(profile.CountryID), country, profile.Role,
+			profile.Phone, profile.Locale, profile.Timezone, dateOfBirth, profile.AvatarURL,
+			formatTime(&profile.CreatedAt), formatTime(&profile.UpdatedAt), formatTime(profile.EmailVerifiedAt),
+		},
+	}
+
+	sessionRows := [][]string{{"id", "startedAt", "lastRefreshedAt", "expiresAt", "active"}}
+	for _, session := range doc.Sessions {
+		sessionRows = append(sessionRows, []string{
+			session.ID, formatTime(&session.StartedAt), formatTime(&session.LastRefreshedAt),
+			formatTime(&session.ExpiresAt), strconv.FormatBool(session.Active),
+		})
+	}
+
+	loginRows := [][]string{{"loggedInAt", "method", "ip"}}
+	for _, login := range doc.Logins {
+		loginRows = append(loginRows, []string{formatTime(&login.CreatedAt), login.Detail, login.IP})
+	}
+
+	if err := writeCSV(archive, "profile.csv", profileRows); err != nil {
+		return nil, err
+	}
+
+	if err := writeCSV(archive, "sessions.csv", sessionRows); err != nil {
+		return nil, err
+	}
+
+	if err := writeCSV(archive, "logins.csv", loginRows); err != nil {
+		return nil, err
+	}
+
+	auditRows := [][]string{{"type", "detail", "ip", "createdAt"}}
+	for _, event := range doc.AuditEvents {
+		auditRows = append(auditRows, []string{event.Type, event.Detail, event.IP, formatTime(&event.CreatedAt)})
+	}
+
+	if err := writeCSV(archive, "audit.csv", auditRows); err != nil {
+		return nil, err
+	}
+
+	addressRows := [][]string{{"id", "type", "recipient", "line1", "line2", "city", "subdivision", "postalCode", "countryID"}}
+	for _, address := range doc.Addresses {
+		addressRows = append(addressRows, []string{
+			strconv.Itoa(address.ID), address.Type, address.Recipient, address.Line1, address.Line2,
+			address.City, address.Subdivision, address.PostalCode, strconv.Itoa(address.CountryID),
+		})
+	}
+
+	if err := writeCSV(archive, "addresses.csv", addressRows); err != nil {
+		return nil, err
+	}
+
+	consentRows := [][]string{{"clientID", "scope", "grantedAt"}}
+	for _, consent := range doc.Consents {
+		consentRows = append(consentRows, []string{consent.ClientID, consent.Scope, formatTime(&consent.GrantedAt)})
+	}
+
+	if err := writeCSV(archive, "consents.csv", consentRows); err != nil {
+		return nil, err
+	}
+
+	identityRows := [][]string{{"provider", "subject", "email", "linkedAt"}}
+	for _, identity := range doc.Identities {
+		identityRows = append(identityRows, []string{identity.Provider, identity.Subject, identity.Email, formatTime(&identity.CreatedAt)})
+	}
+
+	if err := writeCSV(archive, "identities.csv", identityRows); err != nil {
+		return nil, err
+	}
+
+	if doc.Avatar != nil {
+		name := "avatar.png"
+		if doc.Avatar.ContentType == "image/jpeg" {
+			name = "avatar.jpg"
+		}
+
+		file, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := file.Write(doc.Avatar.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// generate method takes an export ID and a user ID
+// builds the archive of the user's data and
+// stores it in the export, or marks the export failed
+func (e *exportController) generate(exportID string, userID int) {
+	doc, err := e.collectExport(userID)
+
+	var archive []byte
+	if err == nil {
+		archive, err = buildExportArchive(doc)
+	}
+
+	if err != nil {
+		log.Printf("failed to export the data of user %d: %v", userID, err)
+		if err := e.dataExportStore.Fail(exportID, err.Error()); err != nil {
+			log.Printf("failed to mark export %s as failed: %v", exportID, err)
+		}
+		return
+	}
+
+	if err := e.dataExportStore.Complete(exportID, archive); err != nil {
+		log.Printf("failed to store export %s: %v", exportID, err)
+	}
+}
+
+// exportOfUser method takes a gin context and a user ID
+// fetches the export in the path parameter only if it belongs to the user
+// and writes back the error to the API response otherwise
+func (e *exportController) exportOfUser(ctx *gin.Context, userID int) (*models.DataExport, bool) {
+	export, err := e.dataExportStore.Get(ctx.Param("exportID"))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && export.UserID != userID) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errExportNotFound.Error()})
+		return nil, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return export, true
+}
+
+// Start method takes a gin context, validates the path parameter
+// authorizes the user based on JWT headers, starts generating
+// an archive of everything held on the user in the background
+// and writes back the export to be polled to the API response
+// A pending or completed export which has not expired yet is written back instead of starting another
+func (e *exportController) Start(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if _, err := e.userStore.GetByID(id); errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	latest, err := e.dataExportStore.GetLatest(id, now)
+	if err == nil {
+		ctx.Header("Location", "/users/"+strconv.Itoa(id)+"/exports/"+latest.ID)
+		if latest.Status == models.ExportPending {
+			ctx.JSON(http.StatusAccepted, latest)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, latest)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	exportID, err := generateRandomToken(16)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	export := &models.DataExport{
+		ID:        exportID,
+		UserID:    id,
+		Status:    models.ExportPending,
+		ExpiresAt: now.Add(durationFromEnv("EXPORT_TTL", defaultExportTTL)),
+	}
+
+	if err := e.dataExportStore.Create(export); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	e.background(func() { e.generate(export.ID, id) })
+
+	ctx.Header("Location", "/users/"+strconv.Itoa(id)+"/exports/"+export.ID)
+	ctx.JSON(http.StatusAccepted, export)
+}
+
+// Status method takes a gin context, validates the path parameters
+// authorizes the user based on JWT headers, interacts with the model
+// and writes back the state of the export to the API response
+func (e *exportController) Status(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	export, ok := e.exportOfUser(ctx, id)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, export)
+}
+
+// Download method takes a gin context, validates the path parameters
+// authorizes the user based on JWT headers, interacts with the model
+// and writes back the zip archive of a completed export to the API response
+func (e *exportController) Download(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	export, ok := e.exportOfUser(ctx, id)
+	if !ok {
+		return
+	}
+
+	if export.Status != models.ExportCompleted {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errExportNotReady.Error(), "status": export.Status})
+		return
+	}
+
+	if export.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(http.StatusGone, gin.H{"error": errExportExpired.Error()})
+		return
+	}
+
+	archive, err := e.dataExportStore.GetArchive(export.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="user-`+strconv.Itoa(id)+`-export.zip"`)
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/storage"
+	"gorm.io/gorm"
+)
+
+// Test_exportSessions runs unit tests on the function exportSessions
+func Test_exportSessions(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Hour)
+
+	tokens := []models.RefreshToken{
+		{FamilyID: "a", CreatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+		{FamilyID: "b", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Minute)},
+		{FamilyID: "a", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(2 * time.Hour)},
+	}
+
+	want := []exportSession{
+		{ID: "a", StartedAt: now.Add(-3 * time.Hour), LastRefreshedAt: now.Add(-time.Hour), ExpiresAt: now.Add(2 * time.Hour), Active: true},
+		{ID: "b", StartedAt: now.Add(-2 * time.Hour), LastRefreshedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Minute), Active: false},
+	}
+
+	if got := exportSessions(tokens, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("exportSessions() = %v, want %v", got, want)
+	}
+}
+
+// Test_buildExportArchive runs unit tests on the function buildExportArchive
+func Test_buildExportArchive(t *testing.T) {
+	now := time.Now()
+	doc := &exportDocument{
+		ExportedAt:  now,
+		Profile:     models.User{ID: 1, Name: "Test User", Email: "test@gmail.com", CountryID: 1, Role: models.RoleUser},
+		Country:     &models.Country{ID: 1, CommonName: "India"},
+		Sessions:    []exportSession{{ID: "a", StartedAt: now}},
+		Logins:      []models.AuditEvent{{UserID: 1, Type: models.AuditLogin, Detail: "password", CreatedAt: now}},
+		AuditEvents: []models.AuditEvent{{UserID: 1, Type: models.AuditRoleChanged, Detail: models.RoleAdmin, CreatedAt: now}},
+		Avatar:      &storage.Blob{Data: []byte("\x89PNG\r\n\x1a\n"), ContentType: "image/png"},
+	}
+
+	archive, err := buildExportArchive(doc)
+	if err != nil {
+		t.Fatalf("buildExportArchive() error = %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("buildExportArchive() is not a zip archive: %v", err)
+	}
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+
+	if want := []string{"export.json", "profile.csv", "sessions.csv", "logins.csv", "audit.csv", "addresses.csv", "consents.csv", "identities.csv", "avatar.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("buildExportArchive() files = %v, want %v", names, want)
+	}
+
+	file, _ := reader.File[0].Open()
+	data, _ := io.ReadAll(file)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("export.json is not valid json: %v", err)
+	}
+
+	if _, ok := decoded["profile"].(map[string]interface{})["password"]; ok {
+		t.Errorf("export.json contains the password")
+	}
+}
+
+// Test_exportController_collectExport runs unit tests on the method collectExport
+func Test_exportController_collectExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+	consentModel := models.NewMockConsents(ctrl)
+	identityModel := models.NewMockIdentities(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	blobStore := storage.NewMockBlobStore(ctrl)
+
+	login := models.AuditEvent{ID: 1, UserID: 1, Type: models.AuditLogin, Detail: "password", IP: "10.0.0.1"}
+	locked := models.AuditEvent{ID: 2, UserID: 1, Type: models.AuditAccountLocked, IP: "10.0.0.2"}
+	roleChanged := models.AuditEvent{ID: 3, UserID: 1, Type: models.AuditRoleChanged, Detail: models.RoleAdmin}
+
+	tests := []struct {
+		name       string
+		expMock    func()
+		wantLogins []models.AuditEvent
+		wantEvents []models.AuditEvent
+		wantErr    error
+	}{
+		{
+			name: "Success case",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com", CountryID: 1}, nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CommonName: "India"}, nil)
+				refreshTokenModel.EXPECT().ListByUser(1).Return([]models.RefreshToken{}, nil)
+				auditEventModel.EXPECT().ListByUser(1).Return([]models.AuditEvent{login, locked, roleChanged}, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				addressModel.EXPECT().ListByUser(1).Return([]models.Address{}, nil)
+				consentModel.EXPECT().ListByUser(1).Return([]models.Consent{}, nil)
+				identityModel.EXPECT().ListByUser(1).Return([]models.Identity{}, nil)
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(nil, storage.ErrBlobNotFound)
+			},
+			wantLogins: []models.AuditEvent{login},
+			wantEvents: []models.AuditEvent{locked, roleChanged},
+		},
+		{
+			name: "Failure case due to audit event model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com", CountryID: 1}, nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CommonName: "India"}, nil)
+				refreshTokenModel.EXPECT().ListByUser(1).Return([]models.RefreshToken{}, nil)
+				auditEventModel.EXPECT().ListByUser(1).Return(nil, sql.ErrConnDone)
+			},
+			wantErr: sql.ErrConnDone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+
+			eH := NewExportController(userModel, countryModel, refreshTokenModel, loginAttemptModel, mfaModel, nil, addressModel, consentModel, identityModel, auditEventModel, blobStore)
+
+			doc, err := eH.collectExport(1)
+			if err != tt.wantErr {
+				t.Fatalf("exportController.collectExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && (!reflect.DeepEqual(doc.Logins, tt.wantLogins) || !reflect.DeepEqual(doc.AuditEvents, tt.wantEvents)) {
+				t.Errorf("exportController.collectExport() logins = %v, events = %v, want %v, %v", doc.Logins, doc.AuditEvents, tt.wantLogins, tt.wantEvents)
+			}
+		})
+	}
+}
+
+// Test_exportController_Start runs unit tests on the method Start
+func Test_exportController_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	dataExportModel := models.NewMockDataExports(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+	consentModel := models.NewMockConsents(ctrl)
+	identityModel := models.NewMockIdentities(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	blobStore := storage.NewMockBlobStore(ctrl)
+
+	tests := []struct {
+		name         string
+		expMock      func()
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name: "Success case",
+			expMock: func() {
+				user := &models.User{ID: 1, Email: "test@gmail.com", CountryID: 1, Password: "hash"}
+				userModel.EXPECT().GetByID(1).Return(user, nil).Times(2)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				dataExportModel.EXPECT().Create(gomock.Any()).Return(nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CommonName: "India"}, nil)
+				refreshTokenModel.EXPECT().ListByUser(1).Return([]models.RefreshToken{}, nil)
+				auditEventModel.EXPECT().ListByUser(1).Return([]models.AuditEvent{}, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				addressModel.EXPECT().ListByUser(1).Return([]models.Address{}, nil)
+				consentModel.EXPECT().ListByUser(1).Return([]models.Consent{}, nil)
+				identityModel.EXPECT().ListByUser(1).Return([]models.Identity{}, nil)
+				blobStore.EXPECT().Get(gomock.Any(), "avatars/1/256").Return(nil, storage.ErrBlobNotFound)
+				dataExportModel.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Success case with a failed generation",
+			expMock: func() {
+				user := &models.User{ID: 1, Email: "test@gmail.com", CountryID: 1}
+				userModel.EXPECT().GetByID(1).Return(user, nil).Times(2)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				dataExportModel.EXPECT().Create(gomock.Any()).Return(nil)
+				countryModel.EXPECT().GetByID(1).Return(nil, sql.ErrConnDone)
+				dataExportModel.EXPECT().Fail(gomock.Any(), sql.ErrConnDone.Error()).Return(nil)
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Success case with a pending export",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(&models.DataExport{ID: "pending", UserID: 1, Status: models.ExportPending}, nil)
+			},
+			wantCode:     http.StatusAccepted,
+			wantLocation: "/users/1/exports/pending",
+		},
+		{
+			name: "Success case with a completed export not expired yet",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(&models.DataExport{ID: "completed", UserID: 1, Status: models.ExportCompleted}, nil)
+			},
+			wantCode:     http.StatusOK,
+			wantLocation: "/users/1/exports/completed",
+		},
+		{
+			name: "Failure case due to user not found",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				dataExportModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Failure case due to latest export model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1}, nil)
+				dataExportModel.EXPECT().GetLatest(1, gomock.Any()).Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "GET"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			eH := NewExportController(userModel, countryModel, refreshTokenModel, loginAttemptModel, mfaModel, dataExportModel, addressModel, consentModel, identityModel, auditEventModel, blobStore)
+			eH.background = func(task func()) { task() }
+
+			eH.Start(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("exportController.Start() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantLocation != "" && w.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("exportController.Start() Location = %v, want %v", w.Header().Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+// Test_exportController_Download runs unit tests on the method Download
+func Test_exportController_Download(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	dataExportModel := models.NewMockDataExports(ctrl)
+	addressModel := models.NewMockAddresses(ctrl)
+	consentModel := models.NewMockConsents(ctrl)
+	identityModel := models.NewMockIdentities(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	blobStore := storage.NewMockBlobStore(ctrl)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name: "Success case",
+			expMock: func() {
+				dataExportModel.EXPECT().Get("export").Return(&models.DataExport{ID: "export", UserID: 1, Status: models.ExportCompleted, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				dataExportModel.EXPECT().GetArchive("export").Return([]byte("zip"), nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Failure case due to export of another user",
+			expMock: func() {
+				dataExportModel.EXPECT().Get("export").Return(&models.DataExport{ID: "export", UserID: 2, Status: models.ExportCompleted, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "Failure case due to pending export",
+			expMock: func() {
+				dataExportModel.EXPECT().Get("export").Return(&models.DataExport{ID: "export", UserID: 1, Status: models.ExportPending, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "Failure case due to expired export",
+			expMock: func() {
+				dataExportModel.EXPECT().Get("export").Return(&models.DataExport{ID: "export", UserID: 1, Status: models.ExportCompleted, ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+			},
+			wantCode: http.StatusGone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "GET"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}, {Key: "exportID", Value: "export"}}
+
+			eH := NewExportController(userModel, countryModel, refreshTokenModel, loginAttemptModel, mfaModel, dataExportModel, addressModel, consentModel, identityModel, auditEventModel, blobStore)
+
+			eH.Download(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("exportController.Download() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/identity"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// defaultIdentityStateTTL is how long a user has to sign in at the identity provider
+const defaultIdentityStateTTL = 10 * time.Minute
+
+// maxNameLength is the length of the name column of users
+const maxNameLength = 50
+
+// identityCallbackInput is the request body the login frontend posts once the identity provider redirected back to it
+type identityCallbackInput struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+	// Country is only needed when no account exists for the email of the identity yet
+	Country *models.Country `json:"country"`
+}
+
+type identityController struct {
+	userStore         models.Users
+	countryStore      models.Countries
+	identityStore     models.Identities
+	refreshTokenStore models.RefreshTokens
+	oneTimeTokenStore models.OneTimeTokens
+	mfaStore          models.MFAFactors
+	auditEventStore   models.AuditEvents
+	providers         map[string]identity.IdentityProvider
+	keys              *signing.KeySet
+}
+
+func NewIdentityController(us models.Users, cs models.Countries, is models.Identities, rt models.RefreshTokens, ot models.OneTimeTokens, mf models.MFAFactors, ae models.AuditEvents, ip []identity.IdentityProvider, ks *signing.KeySet) *identityController {
+	providers := make(map[string]identity.IdentityProvider, len(ip))
+	for _, provider := range ip {
+		providers[provider.Name()] = provider
+	}
+
+	return &identityController{
+		userStore:         us,
+		countryStore:      cs,
+		identityStore:     is,
+		refreshTokenStore: rt,
+		oneTimeTokenStore: ot,
+		mfaStore:          mf,
+		auditEventStore:   ae,
+		providers:         providers,
+		keys:              ks,
+	}
+}
+
+// provider method takes a gin context and
+// returns the identity provider named by the path parameter or writes back the error to the API response
+func (i *identityController) provider(ctx *gin.Context) (identity.IdentityProvider, bool) {
+	provider, ok := i.providers[ctx.Param("provider")]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errUnknownProvider.Error()})
+		return nil, false
+	}
+
+	return provider, true
+}
+
+// authorize method takes a gin context, an identity provider and the ID of the user linking an identity, if any
+// creates a single use state holding a PKCE code verifier using model and
+// writes back the page of the provider to send the user to
+func (i *identityController) authorize(ctx *gin.Context, provider identity.IdentityProvider, userID *int) {
+	state, err := generateRandomToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	codeVerifier, err := generateRandomToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := i.identityStore.CreateState(&models.IdentityState{
+		StateHash:    hashToken(state),
+		Provider:     provider.Name(),
+		UserID:       userID,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(durationFromEnv("IDENTITY_STATE_TTL", defaultIdentityStateTTL)),
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"authorizationURL": provider.AuthCodeURL(state, codeChallengeOf(codeVerifier))})
+}
+
+// exchange method takes a gin context, an identity provider and the ID of the user linking an identity, if any
+// consumes the state of the request body, checking it was created for the provider and the user, using model
+// exchanges the code at the provider and
+// returns the profile of the user at the provider along with the request body, writing back the error response when it fails
+func (i *identityController) exchange(ctx *gin.Context, provider identity.IdentityProvider, userID *int) (*identity.Profile, *identityCallbackInput, bool) {
+	var input identityCallbackInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Code == "" || input.State == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return nil, nil, false
+	}
+
+	state, err := i.identityStore.ConsumeState(hashToken(input.State))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errIdentityState.Error()})
+		return nil, nil, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	// A state created to link an identity cannot sign in, nor link it to another user
+	sameUser := (state.UserID == nil && userID == nil) || (state.UserID != nil && userID != nil && *state.UserID == *userID)
+	if state.Provider != provider.Name() || !sameUser {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errIdentityState.Error()})
+		return nil, nil, false
+	}
+
+	profile, err := provider.Exchange(ctx.Request.Context(), input.Code, state.CodeVerifier)
+	if errors.Is(err, identity.ErrExchange) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errIdentityCode.Error()})
+		return nil, nil, false
+	} else if err != nil {
+		log.Printf("failed to exchange the code at %s: %v", provider.Name(), err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": errIdentityProvider.Error()})
+		return nil, nil, false
+	}
+
+	return profile, &input, true
+}
+
+// nameOf function takes the profile of a user at an identity provider and
+// returns the name of the account created for it, the local part of the email when the provider has none
+func nameOf(profile *identity.Profile) string {
+	name := strings.TrimSpace(profile.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(profile.Email, "@")
+	}
+
+	for utf8.RuneCountInString(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	return name
+}
+
+// findOrCreateUser method takes a gin context, the profile of a user at an identity provider and the callback request body
+// finds the user with the verified email of the profile, restoring it when it was deleted within the grace period,
+// or creates one in the country of the request body with an unusable password using model
+// returns the user and whether it was created, writing back the error response when it fails
+func (i *identityController) findOrCreateUser(ctx *gin.Context, profile *identity.Profile, input *identityCallbackInput) (*models.User, bool, bool) {
+	// Accounts are only matched by an email the provider verified so that nobody can take over an account by claiming its email
+	if !profile.EmailVerified || profile.Email == "" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": errProviderEmail.Error()})
+		return nil, false, false
+	}
+
+	now := time.Now()
+
+	user, err := i.userStore.GetByEmail(profile.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = i.userStore.GetDeletedByEmail(profile.Email, now.Add(-DeletionGracePeriod()))
+	}
+
+	if err == nil {
+		// Whoever signed up with an email they do not own must not share the account with its owner
+		if user.EmailVerifiedAt == nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": errUnverifiedAccount.Error()})
+			return nil, false, false
+		}
+
+		// A verified email proves the ownership of the account as much as its password does,
+		// a deleted user is restored by the caller once any second factor succeeded
+		return user, false, true
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	if input.Country == nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": errIdentityCountry.Error(), "field": "country"})
+		return nil, false, false
+	}
+
+	user = &models.User{Name: nameOf(profile), Email: profile.Email, Country: input.Country}
+
+	country, err := countryOf(i.countryStore, user)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": errUnknownCountry.Error(), "field": "country"})
+		return nil, false, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	// Nobody knows the password until it is set with a password reset
+	password, err := generateRandomToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	user.CountryID = country.ID
+	user.Country = country
+	user.Password = string(hash)
+	user.EmailVerifiedAt = &now
+	user.Role = models.RoleUser
+
+	id, err := i.userStore.Create(user)
+	if writeUnknownCountry(ctx, err) || writeEmailConflict(ctx, err) {
+		// A user past the grace period keeps the email until it is erased
+		return nil, false, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+
+	user.ID = id
+
+	return user, true, true
+}
+
+// Authorize method takes a gin context, validates the provider path parameter
+// and writes back the page of the identity provider the login frontend sends the user to
+func (i *identityController) Authorize(ctx *gin.Context) {
+	provider, ok := i.provider(ctx)
+	if !ok {
+		return
+	}
+
+	i.authorize(ctx, provider, nil)
+}
+
+// Callback method takes a gin context, exchanges the code the identity provider redirected back with
+// signs in the user the identity is linked to, or finds or creates the user with its verified email and links the identity,
+// returns an MFA challenge token when two-factor authentication is enabled,
+// otherwise creates a JWT token with a refresh token and writes back to the API response
+func (i *identityController) Callback(ctx *gin.Context) {
+	provider, ok := i.provider(ctx)
+	if !ok {
+		return
+	}
+
+	profile, input, ok := i.exchange(ctx, provider, nil)
+	if !ok {
+		return
+	}
+
+	linked, err := i.identityStore.Get(provider.Name(), profile.Subject)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user *models.User
+	created := false
+
+	if linked != nil {
+		// A user deleted within the grace period is restored like a login with the password does
+		user, err = i.userStore.GetByID(linked.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user, err = i.userStore.GetDeletedByID(linked.UserID, time.Now().Add(-DeletionGracePeriod()))
+		}
+
+		// Past the grace period the user is about to be erased along with the identity,
+		// which must not sign in to another user found by the email of the provider
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": errNotRestorable.Error()})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		user, created, ok = i.findOrCreateUser(ctx, profile, input)
+		if !ok {
+			return
+		}
+
+		err := i.identityStore.Create(&models.Identity{
+			UserID:   user.ID,
+			Provider: provider.Name(),
+			Subject:  profile.Subject,
+			Email:    profile.Email,
+		})
+		if errors.Is(err, models.ErrIdentityLinked) {
+			// Linked by a concurrent sign in
+			ctx.JSON(http.StatusConflict, gin.H{"error": errIdentityLinked.Error()})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// The identity provider replaces the password, not the second factor
+	mfaRequired, err := mfaEnabled(i.mfaStore, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A deleted user is only restored by the second step so that the identity provider alone cannot cancel the deletion
+	if mfaRequired {
+		challengeToken, err := createMFAChallenge(i.oneTimeTokenStore, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaToken": challengeToken})
+		return
+	}
+
+	if user.DeletedAt.Valid {
+		if err := i.userStore.Restore(user.ID, time.Now().Add(-DeletionGracePeriod())); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tokens, err := issueTokens(i.refreshTokenStore, i.keys, user.ID, user.Role, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
+		return
+	}
+
+	recordAudit(i.auditEventStore, user.ID, models.AuditLogin, provider.Name(), ctx.ClientIP())
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	ctx.JSON(status, gin.H{"id": user.ID, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
+}
+
+// Link method takes a gin context with verified JWT claims, validates the provider path parameter
+// and writes back the page of the identity provider the login frontend sends the user to
+// for the identity to be linked to the user of the path
+func (i *identityController) Link(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	provider, ok := i.provider(ctx)
+	if !ok {
+		return
+	}
+
+	i.authorize(ctx, provider, &id)
+}
+
+// LinkCallback method takes a gin context with verified JWT claims
+// exchanges the code the identity provider redirected back with for a state created by the same user
+// links the identity to the user using model and writes back to the API response
+func (i *identityController) LinkCallback(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	provider, ok := i.provider(ctx)
+	if !ok {
+		return
+	}
+
+	profile, _, ok := i.exchange(ctx, provider, &id)
+	if !ok {
+		return
+	}
+
+	linked, err := i.identityStore.Get(provider.Name(), profile.Subject)
+	if err == nil {
+		if linked.UserID != id {
+			ctx.JSON(http.StatusConflict, gin.H{"error": errIdentityLinked.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, linked)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := &models.Identity{
+		UserID:   id,
+		Provider: provider.Name(),
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+	}
+
+	err = i.identityStore.Create(link)
+	if errors.Is(err, models.ErrIdentityLinked) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errIdentityLinked.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, link)
+}
+
+// List method takes a gin context with verified JWT claims
+// fetches the identities linked to the user of the path using model
+// and writes back to the API response
+func (i *identityController) List(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	identities, err := i.identityStore.ListByUser(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, identities)
+}
+
+// Unlink method takes a gin context with verified JWT claims, validates the identity path parameter
+// unlinks the identity from the user of the path using model and writes back to the API response
+// Users created by an identity provider set a password with a password reset before unlinking their last identity
+func (i *identityController) Unlink(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	identityID, err := strconv.Atoi(ctx.Param("identityID"))
+	if err != nil || identityID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPathParam.Error()})
+		return
+	}
+
+	err = i.identityStore.Delete(id, identityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errIdentityNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,464 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/identity"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_identityController_Authorize runs unit tests on the method Authorize
+func Test_identityController_Authorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	identityModel := models.NewMockIdentities(ctrl)
+	provider := identity.NewMockIdentityProvider(ctrl)
+	provider.EXPECT().Name().Return("google").AnyTimes()
+
+	tests := []struct {
+		name      string
+		pathParam string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Success case",
+			pathParam: "google",
+			expMock: func() {
+				identityModel.EXPECT().CreateState(gomock.Any()).DoAndReturn(func(state *models.IdentityState) error {
+					if state.Provider != "google" || state.UserID != nil || state.CodeVerifier == "" {
+						t.Errorf("identityController.Authorize() state = %+v", state)
+					}
+					return nil
+				})
+				provider.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any()).Return("https://accounts.google.com/o/oauth2/v2/auth?state=s")
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Failure case due to provider not configured",
+			pathParam: "github",
+			expMock:   func() {},
+			wantCode:  http.StatusNotFound,
+		},
+		{
+			name:      "Failure case due to model",
+			pathParam: "google",
+			expMock: func() {
+				identityModel.EXPECT().CreateState(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+			ctx.Params = []gin.Param{{Key: "provider", Value: tt.pathParam}}
+
+			iH := NewIdentityController(nil, nil, identityModel, nil, nil, nil, nil, []identity.IdentityProvider{provider}, testKeys)
+
+			iH.Authorize(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("identityController.Authorize() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_identityController_Callback runs unit tests on the method Callback
+func Test_identityController_Callback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	identityModel := models.NewMockIdentities(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	provider := identity.NewMockIdentityProvider(ctrl)
+	provider.EXPECT().Name().Return("google").AnyTimes()
+
+	verifiedAt := time.Now()
+	linkingUser := 1
+	loginState := &models.IdentityState{ID: 1, Provider: "google", CodeVerifier: "verifier"}
+	profile := &identity.Profile{Subject: "108", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+	user := &models.User{ID: 1, Email: "jane@example.com", Role: models.RoleUser, EmailVerifiedAt: &verifiedAt}
+	deletedUser := &models.User{
+		ID: 1, Email: "jane@example.com", Role: models.RoleUser, EmailVerifiedAt: &verifiedAt,
+		DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true},
+	}
+
+	exchanged := func(profile *identity.Profile) {
+		identityModel.EXPECT().ConsumeState(hashToken("state")).Return(loginState, nil)
+		provider.EXPECT().Exchange(gomock.Any(), "code", "verifier").Return(profile, nil)
+	}
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+		wantMFA  bool
+	}{
+		{
+			name:    "Success case signing in the user the identity is linked to",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "google", IP: "192.0.2.1"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case linking the identity to the user with the verified email",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetByEmail("jane@example.com").Return(user, nil)
+				identityModel.EXPECT().Create(&models.Identity{UserID: 1, Provider: "google", Subject: "108", Email: "jane@example.com"}).Return(nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "google", IP: "192.0.2.1"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case creating a user in the given country",
+			reqBody: `{"code": "code", "state": "state", "country": "IN"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetByEmail("jane@example.com").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByEmail("jane@example.com", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				countryModel.EXPECT().GetByCode("IN").Return(&models.Country{ID: 1, CountryCode: "IN"}, nil)
+				userModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(user *models.User) (int, error) {
+					if user.Name != "Jane Doe" || user.CountryID != 1 || user.EmailVerifiedAt == nil || user.Password == "" {
+						t.Errorf("identityController.Callback() created user = %+v", user)
+					}
+					return 2, nil
+				})
+				identityModel.EXPECT().Create(&models.Identity{UserID: 2, Provider: "google", Subject: "108", Email: "jane@example.com"}).Return(nil)
+				mfaModel.EXPECT().GetTOTP(2).Return(nil, gorm.ErrRecordNotFound)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 2, Type: models.AuditLogin, Detail: "google", IP: "192.0.2.1"}).Return(nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "Success case asking for the second factor",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, ConfirmedAt: &verifiedAt}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusOK,
+			wantMFA:  true,
+		},
+		{
+			name:    "Success case restoring a user deleted within the grace period",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(deletedUser, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "google", IP: "192.0.2.1"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case restoring the deleted user the identity is linked to whatever the email of the provider",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(&identity.Profile{Subject: "108", Email: "jane.doe@example.org", EmailVerified: true, Name: "Jane Doe"})
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(deletedUser, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "google", IP: "192.0.2.1"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case leaving a deleted MFA user deleted until the second step",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(deletedUser, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, ConfirmedAt: &verifiedAt}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusOK,
+			wantMFA:  true,
+		},
+		{
+			name:    "Failure case due to the linked user deleted past the grace period",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(&models.Identity{ID: 1, UserID: 1, Provider: "google", Subject: "108"}, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "Failure case due to account with an unverified email",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetByEmail("jane@example.com").Return(&models.User{ID: 1, Email: "jane@example.com"}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:    "Failure case due to email not verified by the provider",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(&identity.Profile{Subject: "42", Email: "jane@example.com"})
+				identityModel.EXPECT().Get("google", "42").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "Failure case due to missing country for a new user",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				exchanged(profile)
+				identityModel.EXPECT().Get("google", "108").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetByEmail("jane@example.com").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByEmail("jane@example.com", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Failure case due to code rejected by the provider",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(loginState, nil)
+				provider.EXPECT().Exchange(gomock.Any(), "code", "verifier").Return(nil, identity.ErrExchange)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "Failure case due to provider unreachable",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(loginState, nil)
+				provider.EXPECT().Exchange(gomock.Any(), "code", "verifier").Return(nil, errors.New("connection refused"))
+			},
+			wantCode: http.StatusBadGateway,
+		},
+		{
+			name:    "Failure case due to state created to link an identity",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).
+					Return(&models.IdentityState{ID: 1, Provider: "google", UserID: &linkingUser, CodeVerifier: "verifier"}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to state expired or already used",
+			reqBody: `{"code": "code", "state": "state"}`,
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to missing code",
+			reqBody:  `{"state": "state"}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/auth/google/callback", io.NopCloser(bytes.NewBufferString(tt.reqBody)))
+			ctx.Params = []gin.Param{{Key: "provider", Value: "google"}}
+
+			iH := NewIdentityController(userModel, countryModel, identityModel, refreshTokenModel, oneTimeTokenModel, mfaModel, auditEventModel, []identity.IdentityProvider{provider}, testKeys)
+
+			iH.Callback(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("identityController.Callback() = %v, want %v: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+
+			if got := strings.Contains(w.Body.String(), `"mfaRequired":true`); got != tt.wantMFA {
+				t.Errorf("identityController.Callback() mfaRequired = %v, want %v", got, tt.wantMFA)
+			}
+		})
+	}
+}
+
+// Test_identityController_LinkCallback runs unit tests on the method LinkCallback
+func Test_identityController_LinkCallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	identityModel := models.NewMockIdentities(ctrl)
+	provider := identity.NewMockIdentityProvider(ctrl)
+	provider.EXPECT().Name().Return("github").AnyTimes()
+
+	userID := 1
+	linkState := &models.IdentityState{ID: 1, Provider: "github", UserID: &userID, CodeVerifier: "verifier"}
+	profile := &identity.Profile{Subject: "42", Email: "jane@example.com", Name: "jane"}
+
+	tests := []struct {
+		name      string
+		pathParam string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Success case",
+			pathParam: "1",
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(linkState, nil)
+				provider.EXPECT().Exchange(gomock.Any(), "code", "verifier").Return(profile, nil)
+				identityModel.EXPECT().Get("github", "42").Return(nil, gorm.ErrRecordNotFound)
+				identityModel.EXPECT().Create(&models.Identity{UserID: 1, Provider: "github", Subject: "42", Email: "jane@example.com"}).Return(nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:      "Failure case due to identity linked to another user",
+			pathParam: "1",
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(linkState, nil)
+				provider.EXPECT().Exchange(gomock.Any(), "code", "verifier").Return(profile, nil)
+				identityModel.EXPECT().Get("github", "42").Return(&models.Identity{ID: 3, UserID: 2, Provider: "github", Subject: "42"}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:      "Failure case due to state created by another user",
+			pathParam: "2",
+			expMock: func() {
+				identityModel.EXPECT().ConsumeState(hashToken("state")).Return(linkState, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/users/"+tt.pathParam+"/identities/github/callback", strings.NewReader(`{"code": "code", "state": "state"}`))
+			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}, {Key: "provider", Value: "github"}}
+
+			iH := NewIdentityController(nil, nil, identityModel, nil, nil, nil, nil, []identity.IdentityProvider{provider}, testKeys)
+
+			iH.LinkCallback(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("identityController.LinkCallback() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_identityController_Unlink runs unit tests on the method Unlink
+func Test_identityController_Unlink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	identityModel := models.NewMockIdentities(ctrl)
+
+	tests := []struct {
+		name       string
+		identityID string
+		expMock    func()
+		wantCode   int
+	}{
+		{
+			name:       "Success case",
+			identityID: "3",
+			expMock: func() {
+				identityModel.EXPECT().Delete(1, 3).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:       "Failure case due to identity not found",
+			identityID: "3",
+			expMock: func() {
+				identityModel.EXPECT().Delete(1, 3).Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:       "Failure case due to invalid path parameter",
+			identityID: "a",
+			expMock:    func() {},
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "DELETE"
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}, {Key: "identityID", Value: tt.identityID}}
+
+			iH := NewIdentityController(nil, nil, identityModel, nil, nil, nil, nil, nil, testKeys)
+
+			iH.Unlink(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, ctx.Writer.Status()) {
+				t.Errorf("identityController.Unlink() = %v, want %v", ctx.Writer.Status(), tt.wantCode)
+			}
+		})
+	}
+}
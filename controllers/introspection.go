@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// States of an introspected token, only active tokens carry their claims
+const (
+	tokenStatusActive  = "active"
+	tokenStatusExpired = "expired"
+	tokenStatusRevoked = "revoked"
+	tokenStatusInvalid = "invalid"
+)
+
+// TokenVerifier verifies an access token and returns its claims
+// The error wraps ErrTokenInvalid when the token cannot be trusted, along with jwt.ErrTokenExpired when it is expired,
+// and is ErrTokenRevoked when it has been revoked, any other error is an issue verifying it
+type TokenVerifier func(jwtToken string) (jwt.MapClaims, error)
+
+// introspectionRequest is the form posted to the introspection endpoint
+// The token type hint is accepted but not needed, as access tokens are JWTs and refresh tokens are not
+type introspectionRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// introspectionOutput is the RFC 7662 response of the introspection endpoint
+// Status and Roles extend it with why a token is inactive and the current roles of its user
+type introspectionOutput struct {
+	Active    bool        `json:"active"`
+	Status    string      `json:"status"`
+	Scope     string      `json:"scope,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	TokenType string      `json:"token_type,omitempty"`
+	Exp       int64       `json:"exp,omitempty"`
+	Iat       int64       `json:"iat,omitempty"`
+	Nbf       int64       `json:"nbf,omitempty"`
+	Sub       string      `json:"sub,omitempty"`
+	Aud       interface{} `json:"aud,omitempty"`
+	Iss       string      `json:"iss,omitempty"`
+	Jti       string      `json:"jti,omitempty"`
+	Roles     []string    `json:"roles,omitempty"`
+}
+
+type introspectionController struct {
+	userStore         models.Users
+	oauthClientStore  models.OAuthClients
+	refreshTokenStore models.RefreshTokens
+	verifyToken       TokenVerifier
+}
+
+func NewIntrospectionController(us models.Users, oc models.OAuthClients, rt models.RefreshTokens, verify TokenVerifier) *introspectionController {
+	return &introspectionController{
+		userStore:         us,
+		oauthClientStore:  oc,
+		refreshTokenStore: rt,
+		verifyToken:       verify,
+	}
+}
+
+// inactive function takes the status of a token which is not active and
+// returns its introspection response
+func inactive(status string) *introspectionOutput {
+	return &introspectionOutput{Active: false, Status: status}
+}
+
+// numericClaim function takes the claims of a token and the name of a NumericDate claim and
+// returns its value in seconds, zero when it is missing
+func numericClaim(claims jwt.MapClaims, name string) int64 {
+	value, _ := claims[name].(float64)
+	return int64(value)
+}
+
+// withUser method takes the introspection response of an active token and the ID of its user
+// adds the roles and, unless the token was granted a scope, the permissions of the user's current role
+// and returns the response, or an inactive one when the user no longer exists, along with any error
+func (i *introspectionController) withUser(output *introspectionOutput, userID int) (*introspectionOutput, error) {
+	user, err := i.userStore.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return inactive(tokenStatusInvalid), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	output.Sub = strconv.Itoa(user.ID)
+	output.Roles = []string{user.Role}
+	if output.Scope == "" {
+		output.Scope = strings.Join(models.Permissions(user.Role), " ")
+	}
+
+	return output, nil
+}
+
+// introspectAccessToken method takes a JWT access token, verifies it and
+// returns its introspection response along with any error
+func (i *introspectionController) introspectAccessToken(token string) (*introspectionOutput, error) {
+	claims, err := i.verifyToken(token)
+	switch {
+	case errors.Is(err, ErrTokenRevoked):
+		return inactive(tokenStatusRevoked), nil
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return inactive(tokenStatusExpired), nil
+	case errors.Is(err, ErrTokenInvalid):
+		return inactive(tokenStatusInvalid), nil
+	case err != nil:
+		return nil, err
+	}
+
+	userID, _ := UserIDFromClaims(claims)
+	scope, _ := claims["scope"].(string)
+	clientID, _ := claims["client_id"].(string)
+	issuer, _ := claims["iss"].(string)
+	jti, _ := claims["jti"].(string)
+
+	return i.withUser(&introspectionOutput{
+		Active:    true,
+		Status:    tokenStatusActive,
+		Scope:     scope,
+		ClientID:  clientID,
+		TokenType: "Bearer",
+		Exp:       numericClaim(claims, "exp"),
+		Iat:       numericClaim(claims, "iat"),
+		Nbf:       numericClaim(claims, "nbf"),
+		Aud:       claims["aud"],
+		Iss:       issuer,
+		Jti:       jti,
+	}, userID)
+}
+
+// introspectRefreshToken method takes an opaque refresh token, looks it up by its hash and
+// returns its introspection response along with any error
+func (i *introspectionController) introspectRefreshToken(token string) (*introspectionOutput, error) {
+	refreshToken, err := i.refreshTokenStore.GetByHash(hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return inactive(tokenStatusInvalid), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if refreshToken.RevokedAt != nil {
+		return inactive(tokenStatusRevoked), nil
+	}
+
+	if !refreshToken.ExpiresAt.After(time.Now()) {
+		return inactive(tokenStatusExpired), nil
+	}
+
+	return i.withUser(&introspectionOutput{
+		Active: true,
+		Status: tokenStatusActive,
+		Exp:    refreshToken.ExpiresAt.Unix(),
+		Iat:    refreshToken.CreatedAt.Unix(),
+		Iss:    TokenIssuer(),
+	}, refreshToken.UserID)
+}
+
+// Introspect method takes a gin context, authenticates the confidential client and
+// verifies the access or refresh token in the form like the authentication middleware and the refresh API do
+// and writes back whether it is active, expired or revoked along with its user's ID, roles and scopes
+func (i *introspectionController) Introspect(ctx *gin.Context) {
+	var req introspectionRequest
+	if err := ctx.ShouldBindWith(&req, binding.FormPost); err != nil {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_request", errPayload)
+		return
+	}
+
+	client, ok := authenticateOAuthClient(ctx, i.oauthClientStore, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	// Public clients only prove their ID which anybody can send
+	if !client.Confidential {
+		writeOAuthError(ctx, http.StatusUnauthorized, "invalid_client", errPublicClient)
+		return
+	}
+
+	if req.Token == "" {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_request", errIntrospectionToken)
+		return
+	}
+
+	var output *introspectionOutput
+	var err error
+	if strings.Count(req.Token, ".") == 2 {
+		output, err = i.introspectAccessToken(req.Token)
+	} else {
+		output, err = i.introspectRefreshToken(req.Token)
+	}
+
+	if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, output)
+}
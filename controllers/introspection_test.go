@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_introspectionController_Introspect runs unit tests on the method Introspect
+func Test_introspectionController_Introspect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+
+	gatewayClient := &models.OAuthClient{
+		ClientID:     "gateway",
+		Confidential: true,
+		SecretHash:   hashToken("secret"),
+	}
+
+	userClaims := jwt.MapClaims{
+		"sub": "1", "iss": "http://localhost:3000", "aud": "http://localhost:3000",
+		"iat": float64(1700000000), "nbf": float64(1700000000), "exp": float64(1700000900), "jti": "abc", "role": "user",
+	}
+	clientClaims := jwt.MapClaims{
+		"sub": "1", "iss": "http://localhost:3000", "aud": "http://localhost:3000/userinfo",
+		"iat": float64(1700000000), "nbf": float64(1700000000), "exp": float64(1700000900), "jti": "def",
+		"client_id": "billing", "scope": "openid email",
+	}
+
+	// verifier returns the token verifier of the test cases, accepting the JWT "a.b.c" with the given claims or error
+	verifier := func(claims jwt.MapClaims, err error) TokenVerifier {
+		return func(jwtToken string) (jwt.MapClaims, error) {
+			if jwtToken != "a.b.c" {
+				t.Errorf("introspectionController.Introspect() verified token = %v, want a.b.c", jwtToken)
+			}
+			return claims, err
+		}
+	}
+
+	withForm := func(token string) string {
+		return url.Values{"token": {token}}.Encode()
+	}
+
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		basicAuth  []string
+		verify     TokenVerifier
+		expMock    func()
+		wantCode   int
+		wantError  string
+		wantOutput *introspectionOutput
+	}{
+		{
+			name:      "Success case with an access token of the user APIs",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(userClaims, nil),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Role: models.RoleSupport}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantOutput: &introspectionOutput{
+				Active: true, Status: tokenStatusActive, Scope: models.PermissionReadUsers, TokenType: "Bearer",
+				Exp: 1700000900, Iat: 1700000000, Nbf: 1700000000, Sub: "1", Aud: "http://localhost:3000",
+				Iss: "http://localhost:3000", Jti: "abc", Roles: []string{models.RoleSupport},
+			},
+		},
+		{
+			name:    "Success case with an access token issued to a client and credentials in the form",
+			reqBody: withForm("a.b.c") + "&client_id=gateway&client_secret=secret&token_type_hint=access_token",
+			verify:  verifier(clientClaims, nil),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantOutput: &introspectionOutput{
+				Active: true, Status: tokenStatusActive, Scope: "openid email", ClientID: "billing", TokenType: "Bearer",
+				Exp: 1700000900, Iat: 1700000000, Nbf: 1700000000, Sub: "1", Aud: "http://localhost:3000/userinfo",
+				Iss: "http://localhost:3000", Jti: "def", Roles: []string{models.RoleUser},
+			},
+		},
+		{
+			name:      "Success case with an expired access token",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(nil, fmt.Errorf("%w: %w", ErrTokenInvalid, jwt.ErrTokenExpired)),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusExpired},
+		},
+		{
+			name:      "Success case with a revoked access token",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(nil, ErrTokenRevoked),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusRevoked},
+		},
+		{
+			name:      "Success case with a forged access token",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(nil, fmt.Errorf("%w: %w", ErrTokenInvalid, jwt.ErrTokenSignatureInvalid)),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusInvalid},
+		},
+		{
+			name:      "Success case with an access token of a deleted user",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(userClaims, nil),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusInvalid},
+		},
+		{
+			name:      "Success case with a refresh token",
+			reqBody:   withForm("refresh"),
+			basicAuth: []string{"gateway", "secret"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				refreshTokenModel.EXPECT().GetByHash(hashToken("refresh")).Return(&models.RefreshToken{
+					UserID: 1, ExpiresAt: time.Unix(1900000000, 0), CreatedAt: time.Unix(1700000000, 0),
+				}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantOutput: &introspectionOutput{
+				Active: true, Status: tokenStatusActive, Scope: "users:manage users:read",
+				Exp: 1900000000, Iat: 1700000000, Sub: "1", Iss: TokenIssuer(), Roles: []string{models.RoleAdmin},
+			},
+		},
+		{
+			name:      "Success case with a revoked refresh token",
+			reqBody:   withForm("refresh"),
+			basicAuth: []string{"gateway", "secret"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				refreshTokenModel.EXPECT().GetByHash(hashToken("refresh")).Return(&models.RefreshToken{
+					UserID: 1, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt,
+				}, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusRevoked},
+		},
+		{
+			name:      "Success case with an expired refresh token",
+			reqBody:   withForm("refresh"),
+			basicAuth: []string{"gateway", "secret"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				refreshTokenModel.EXPECT().GetByHash(hashToken("refresh")).Return(&models.RefreshToken{
+					UserID: 1, ExpiresAt: time.Now().Add(-time.Minute),
+				}, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusExpired},
+		},
+		{
+			name:      "Success case with an unknown refresh token",
+			reqBody:   withForm("unknown"),
+			basicAuth: []string{"gateway", "secret"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+				refreshTokenModel.EXPECT().GetByHash(hashToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode:   http.StatusOK,
+			wantOutput: &introspectionOutput{Status: tokenStatusInvalid},
+		},
+		{
+			name:      "Failure case due to wrong client secret",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "wrong"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "invalid_client",
+		},
+		{
+			name:    "Failure case due to public client",
+			reqBody: withForm("a.b.c") + "&client_id=client",
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "invalid_client",
+		},
+		{
+			name:      "Failure case due to missing token",
+			reqBody:   "token=",
+			basicAuth: []string{"gateway", "secret"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_request",
+		},
+		{
+			name:      "Failure case due to revocation check error",
+			reqBody:   withForm("a.b.c"),
+			basicAuth: []string{"gateway", "secret"},
+			verify:    verifier(nil, sql.ErrConnDone),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("gateway").Return(gatewayClient, nil)
+			},
+			wantCode:  http.StatusInternalServerError,
+			wantError: "server_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(tt.reqBody))
+			ctx.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuth != nil {
+				ctx.Request.SetBasicAuth(tt.basicAuth[0], tt.basicAuth[1])
+			}
+
+			iH := NewIntrospectionController(userModel, oauthClientModel, refreshTokenModel, tt.verify)
+
+			iH.Introspect(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("introspectionController.Introspect() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				var output map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+					t.Fatal(err)
+				}
+
+				if output["error"] != tt.wantError {
+					t.Errorf("introspectionController.Introspect() error = %v, want %v", output["error"], tt.wantError)
+				}
+				return
+			}
+
+			var output introspectionOutput
+			if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(&output, tt.wantOutput) {
+				t.Errorf("introspectionController.Introspect() = %+v, want %+v", output, *tt.wantOutput)
+			}
+		})
+	}
+}
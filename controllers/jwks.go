@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+)
+
+// defaultJWKSMaxAge is how long downstream services may cache the key set,
+// a new key must be published at least this long before it signs tokens
+const defaultJWKSMaxAge = time.Hour
+
+type keyController struct {
+	keys *signing.KeySet
+}
+
+func NewKeyController(ks *signing.KeySet) *keyController {
+	return &keyController{
+		keys: ks,
+	}
+}
+
+// JWKS method takes a gin context and
+// writes back the public keys access tokens are verified with as a JSON Web Key Set
+// cacheable for JWKS_MAX_AGE (1 hour by default)
+func (k *keyController) JWKS(ctx *gin.Context) {
+	maxAge := durationFromEnv("JWKS_MAX_AGE", defaultJWKSMaxAge)
+
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	ctx.JSON(http.StatusOK, k.keys.JWKS())
+}
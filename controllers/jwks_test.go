@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+)
+
+// testKeys signs the access tokens issued by the controllers under test
+var testKeys, _ = signing.NewKeySet(signing.NewSecretKey([]byte("test-secret")))
+
+// Test_keyController_JWKS runs unit tests on the method JWKS
+func Test_keyController_JWKS(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := signing.NewPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := signing.NewPublicKey(previousPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := signing.NewKeySet(current, previous, signing.NewSecretKey([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		keys         *signing.KeySet
+		maxAge       string
+		wantKeyIDs   []string
+		wantCacheHdr string
+	}{
+		{
+			name:         "Success case without published keys while signing with the secret",
+			keys:         testKeys,
+			wantKeyIDs:   []string{},
+			wantCacheHdr: "public, max-age=3600",
+		},
+		{
+			name:         "Success case with the current and previous keys but not the secret",
+			keys:         rotated,
+			maxAge:       "5m",
+			wantKeyIDs:   []string{current.ID, previous.ID},
+			wantCacheHdr: "public, max-age=300",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWKS_MAX_AGE", tt.maxAge)
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+			kH := NewKeyController(tt.keys)
+			kH.JWKS(ctx)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("keyController.JWKS() = %v, want %v", w.Code, http.StatusOK)
+			}
+
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCacheHdr {
+				t.Errorf("keyController.JWKS() Cache-Control = %v, want %v", got, tt.wantCacheHdr)
+			}
+
+			var jwks signing.JWKS
+			if err := json.Unmarshal(w.Body.Bytes(), &jwks); err != nil {
+				t.Fatal(err)
+			}
+
+			gotKeyIDs := make([]string, 0, len(jwks.Keys))
+			for _, jwk := range jwks.Keys {
+				gotKeyIDs = append(gotKeyIDs, jwk.KeyID)
+			}
+
+			if !reflect.DeepEqual(gotKeyIDs, tt.wantKeyIDs) {
+				t.Errorf("keyController.JWKS() key IDs = %v, want %v", gotKeyIDs, tt.wantKeyIDs)
+			}
+		})
+	}
+}
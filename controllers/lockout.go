@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLoginLockoutThreshold   = 5
+	defaultLoginIPLockoutThreshold = 20
+	defaultLoginLockoutDuration    = 15 * time.Minute
+	defaultLoginBackoffBase        = time.Second
+	defaultLoginBackoffMax         = 30 * time.Second
+)
+
+// unlockInput is the request body of the admin API unlocking an account or a client IP
+type unlockInput struct {
+	Email string `json:"email"`
+	IP    string `json:"ip"`
+}
+
+// loginKey is a failed login counter along with its lockout threshold, the failures tolerated
+// before it starts backing off and the status responded while it blocks logins
+type loginKey struct {
+	key       string
+	threshold int
+	grace     int
+	status    int
+}
+
+// loginBlock describes why and for how long logins are blocked
+type loginBlock struct {
+	status     int
+	retryAfter time.Duration
+}
+
+type lockoutController struct {
+	userStore         models.Users
+	loginAttemptStore models.LoginAttempts
+	auditEventStore   models.AuditEvents
+}
+
+func NewLockoutController(us models.Users, la models.LoginAttempts, ae models.AuditEvents) *lockoutController {
+	return &lockoutController{
+		userStore:         us,
+		loginAttemptStore: la,
+		auditEventStore:   ae,
+	}
+}
+
+// intFromEnv function takes an environment variable name and a fallback
+// parses the variable as a positive integer and
+// returns the fallback when it is missing or invalid
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// accountLoginKey function takes an email and
+// returns the key counting failed logins of that account
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipLoginKey function takes a client IP and
+// returns the key counting failed logins from that IP
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginKeys function takes an email and a client IP and
+// returns the counters a login attempt is checked against
+// a locked account responds 423 Locked while a throttled IP responds 429 Too Many Requests
+// An IP is shared by every user behind the same NAT or proxy, so it only backs off past half of its threshold
+func loginKeys(email, ip string) []loginKey {
+	ipThreshold := intFromEnv("LOGIN_IP_LOCKOUT_THRESHOLD", defaultLoginIPLockoutThreshold)
+
+	return append(accountKeys(email),
+		loginKey{key: ipLoginKey(ip), threshold: ipThreshold, grace: ipThreshold / 2, status: http.StatusTooManyRequests},
+	)
+}
+
+// accountKeys function takes an email and
+// returns the counter of the account alone, which the APIs checking the password or a code of a signed in user
+// share with the login so that a stolen access token cannot be used to guess them
+func accountKeys(email string) []loginKey {
+	return []loginKey{
+		{key: accountLoginKey(email), threshold: intFromEnv("LOGIN_LOCKOUT_THRESHOLD", defaultLoginLockoutThreshold), status: http.StatusLocked},
+	}
+}
+
+// loginBackoff function takes the number of consecutive failures and
+// returns the delay to wait before the next login, doubling with every failure
+func loginBackoff(failures int) time.Duration {
+	base := durationFromEnv("LOGIN_BACKOFF_BASE", defaultLoginBackoffBase)
+	max := durationFromEnv("LOGIN_BACKOFF_MAX", defaultLoginBackoffMax)
+
+	if failures <= 0 {
+		return 0
+	}
+
+	if failures > 31 {
+		return max
+	}
+
+	delay := base * time.Duration(1<<(failures-1))
+	if delay <= 0 || delay > max {
+		return max
+	}
+
+	return delay
+}
+
+// blockedBy function takes a LoginAttempt, its key and the current time and
+// returns the block while the key is locked or still backing off, or nil otherwise
+func blockedBy(attempt *models.LoginAttempt, k loginKey, now time.Time) *loginBlock {
+	if attempt.LockedUntil != nil {
+		if now.Before(*attempt.LockedUntil) {
+			return &loginBlock{status: k.status, retryAfter: attempt.LockedUntil.Sub(now)}
+		}
+
+		// The lock expired so the account is unlocked and starts over
+		return nil
+	}
+
+	if next := attempt.LastFailedAt.Add(loginBackoff(attempt.Failures - k.grace)); now.Before(next) {
+		return &loginBlock{status: http.StatusTooManyRequests, retryAfter: next.Sub(now)}
+	}
+
+	return nil
+}
+
+// checkLogin function takes the failed login store, the counters of the attempt and the current time
+// looks for a lock or a pending backoff and
+// returns the first block found along with an error if any
+func checkLogin(la models.LoginAttempts, keys []loginKey, now time.Time) (*loginBlock, error) {
+	for _, k := range keys {
+		attempt, err := la.Get(k.key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if block := blockedBy(attempt, k, now); block != nil {
+			return block, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// recordLoginFailure function takes the failed login store, the counters of the attempt and the current time
+// counts the failure on every counter, locks the ones reaching their threshold and
+// returns the lock applied by this failure if any
+// store failures are only logged so that a broken store never hides the credentials error
+func recordLoginFailure(la models.LoginAttempts, keys []loginKey, now time.Time) *loginBlock {
+	var block *loginBlock
+	duration := durationFromEnv("LOGIN_LOCKOUT_DURATION", defaultLoginLockoutDuration)
+
+	for _, k := range keys {
+		// Failures older than a lockout duration are forgotten
+		attempt, err := la.RecordFailure(k.key, now, duration)
+		if err != nil {
+			log.Printf("failed to record failed login for %s: %v", k.key, err)
+			continue
+		}
+
+		if attempt.Failures < k.threshold {
+			continue
+		}
+
+		until := now.Add(duration)
+		if err := la.Lock(k.key, until); err != nil {
+			log.Printf("failed to lock logins for %s: %v", k.key, err)
+			continue
+		}
+
+		if block == nil {
+			block = &loginBlock{status: k.status, retryAfter: until.Sub(now)}
+		}
+	}
+
+	return block
+}
+
+// writeLoginBlock function takes a gin context and a loginBlock
+// sets the Retry-After header in seconds and writes back to the API response
+func writeLoginBlock(ctx *gin.Context, block *loginBlock) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(block.retryAfter.Seconds()))))
+
+	if block.status == http.StatusLocked {
+		ctx.JSON(http.StatusLocked, gin.H{"error": errAccountLocked.Error()})
+		return
+	}
+
+	ctx.JSON(block.status, gin.H{"error": errTooManyLoginAttempts.Error()})
+}
+
+// Unlock method takes a gin context, validates the request body
+// clears the failed logins and the lock of the given account and/or client IP
+// and writes back to the API response
+func (l *lockoutController) Unlock(ctx *gin.Context) {
+	var input unlockInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	var keys []string
+	if input.Email != "" {
+		keys = append(keys, accountLoginKey(input.Email))
+	}
+	if input.IP != "" {
+		keys = append(keys, ipLoginKey(input.IP))
+	}
+
+	if len(keys) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errUnlockTarget.Error()})
+		return
+	}
+
+	if err := l.loginAttemptStore.Reset(keys...); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Accounts are unlocked by email, which may not belong to any user
+	if input.Email != "" {
+		user, err := l.userStore.GetByEmail(input.Email)
+		if err == nil {
+			recordAudit(l.auditEventStore, user.ID, models.AuditAccountUnlocked, "", ctx.ClientIP())
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("failed to find the user unlocked by email: %v", err)
+		}
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_loginBackoff runs unit tests on the function loginBackoff
+func Test_loginBackoff(t *testing.T) {
+	t.Setenv("LOGIN_BACKOFF_BASE", "1s")
+	t.Setenv("LOGIN_BACKOFF_MAX", "30s")
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "No failures", failures: 0, want: 0},
+		{name: "First failure", failures: 1, want: time.Second},
+		{name: "Doubling delay", failures: 4, want: 8 * time.Second},
+		{name: "Capped delay", failures: 10, want: 30 * time.Second},
+		{name: "Capped delay without overflow", failures: 100, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loginBackoff(tt.failures); got != tt.want {
+				t.Errorf("loginBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_blockedBy runs unit tests on the function blockedBy
+func Test_blockedBy(t *testing.T) {
+	t.Setenv("LOGIN_BACKOFF_BASE", "1s")
+	t.Setenv("LOGIN_BACKOFF_MAX", "30s")
+
+	now := time.Now()
+	lockedUntil := now.Add(time.Minute)
+	expiredLock := now.Add(-time.Second)
+
+	accountKey := loginKey{key: "account:test@gmail.com", threshold: 5, status: http.StatusLocked}
+	ipKey := loginKey{key: "ip:127.0.0.1", threshold: 20, grace: 10, status: http.StatusTooManyRequests}
+
+	tests := []struct {
+		name    string
+		key     loginKey
+		attempt *models.LoginAttempt
+		want    *loginBlock
+	}{
+		{
+			name:    "Locked case",
+			key:     accountKey,
+			attempt: &models.LoginAttempt{Failures: 5, LastFailedAt: now, LockedUntil: &lockedUntil},
+			want:    &loginBlock{status: http.StatusLocked, retryAfter: time.Minute},
+		},
+		{
+			name:    "Expired lock case",
+			key:     accountKey,
+			attempt: &models.LoginAttempt{Failures: 5, LastFailedAt: now.Add(-time.Hour), LockedUntil: &expiredLock},
+			want:    nil,
+		},
+		{
+			name:    "Backing off case",
+			key:     accountKey,
+			attempt: &models.LoginAttempt{Failures: 3, LastFailedAt: now.Add(-time.Second)},
+			want:    &loginBlock{status: http.StatusTooManyRequests, retryAfter: 3 * time.Second},
+		},
+		{
+			name:    "Backoff elapsed case",
+			key:     accountKey,
+			attempt: &models.LoginAttempt{Failures: 3, LastFailedAt: now.Add(-5 * time.Second)},
+			want:    nil,
+		},
+		{
+			name:    "IP within its grace case",
+			key:     ipKey,
+			attempt: &models.LoginAttempt{Failures: 10, LastFailedAt: now},
+			want:    nil,
+		},
+		{
+			name:    "IP backing off past its grace case",
+			key:     ipKey,
+			attempt: &models.LoginAttempt{Failures: 12, LastFailedAt: now.Add(-time.Second)},
+			want:    &loginBlock{status: http.StatusTooManyRequests, retryAfter: time.Second},
+		},
+		{
+			name:    "Locked IP case",
+			key:     ipKey,
+			attempt: &models.LoginAttempt{Failures: 20, LastFailedAt: now, LockedUntil: &lockedUntil},
+			want:    &loginBlock{status: http.StatusTooManyRequests, retryAfter: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blockedBy(tt.attempt, tt.key, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("blockedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_lockoutController_Unlock runs unit tests on the method Unlock
+func Test_lockoutController_Unlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:    "Success case unlocking an account and an IP",
+			reqBody: `{"email": "Test@gmail.com", "ip": "127.0.0.1"}`,
+			expMock: func() {
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com", "ip:127.0.0.1").Return(nil)
+				userModel.EXPECT().GetByEmail("Test@gmail.com").Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditAccountUnlocked, IP: ""}).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Success case unlocking an email without a user",
+			reqBody: `{"email": "unknown@gmail.com"}`,
+			expMock: func() {
+				loginAttemptModel.EXPECT().Reset("account:unknown@gmail.com").Return(nil)
+				userModel.EXPECT().GetByEmail("unknown@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Success case despite a failure recording the unlock",
+			reqBody: `{"email": "test@gmail.com"}`,
+			expMock: func() {
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				auditEventModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Success case unlocking an IP",
+			reqBody: `{"ip": "127.0.0.1"}`,
+			expMock: func() {
+				loginAttemptModel.EXPECT().Reset("ip:127.0.0.1").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Failure case due to missing target",
+			reqBody:  `{}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to request body",
+			reqBody:  `{"email": 1}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to model",
+			reqBody: `{"email": "test@gmail.com"}`,
+			expMock: func() {
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			lH := NewLockoutController(userModel, loginAttemptModel, auditEventModel)
+
+			lH.Unlock(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("lockoutController.Unlock() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const defaultMFAChallengeTTL = 5 * time.Minute
+
+// mfaCodeInput is the request body carrying a code of the authenticator app
+type mfaCodeInput struct {
+	Code string `json:"code"`
+}
+
+// mfaLoginInput is the request body completing a login with the MFA challenge token
+// Code is either a code of the authenticator app or a backup code
+type mfaLoginInput struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+// disableMFAInput is the request body re-authenticating the user to disable MFA
+type disableMFAInput struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type mfaController struct {
+	userStore         models.Users
+	mfaStore          models.MFAFactors
+	oneTimeTokenStore models.OneTimeTokens
+	refreshTokenStore models.RefreshTokens
+	loginAttemptStore models.LoginAttempts
+	auditEventStore   models.AuditEvents
+	keys              *signing.KeySet
+}
+
+func NewMFAController(us models.Users, mf models.MFAFactors, ot models.OneTimeTokens, rt models.RefreshTokens, la models.LoginAttempts, ae models.AuditEvents, ks *signing.KeySet) *mfaController {
+	return &mfaController{
+		userStore:         us,
+		mfaStore:          mf,
+		oneTimeTokenStore: ot,
+		refreshTokenStore: rt,
+		loginAttemptStore: la,
+		auditEventStore:   ae,
+		keys:              ks,
+	}
+}
+
+// mfaEnabled function takes the MFA model and a user ID and
+// returns whether the user confirmed a TOTP factor along with an error if any
+func mfaEnabled(mf models.MFAFactors, userID int) (bool, error) {
+	factor, err := mf.GetTOTP(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return factor.ConfirmedAt != nil, nil
+}
+
+// createMFAChallenge function takes the one time token model and a user ID
+// creates a single use, short lived challenge token proving the password was verified and
+// returns the token along with an error if any
+func createMFAChallenge(ot models.OneTimeTokens, userID int) (string, error) {
+	challengeToken, err := generateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ot.Create(&models.OneTimeToken{
+		UserID:    userID,
+		Purpose:   models.PurposeMFAChallenge,
+		TokenHash: hashToken(challengeToken),
+		ExpiresAt: time.Now().Add(durationFromEnv("MFA_CHALLENGE_TTL", defaultMFAChallengeTTL)),
+	}); err != nil {
+		return "", err
+	}
+
+	return challengeToken, nil
+}
+
+// verifyMFACode function takes the MFA model, the confirmed factor, a code and the current time
+// accepts a code of the authenticator app that was not used before or an unused backup code and
+// returns whether the code was accepted along with an error if any
+func verifyMFACode(mf models.MFAFactors, factor *models.TOTPFactor, code string, now time.Time) (bool, error) {
+	secret, err := openSecret(factor.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	if step, ok := matchTOTP(secret, code, now); ok {
+		return mf.UseTOTPStep(factor.UserID, step)
+	}
+
+	return mf.UseBackupCode(factor.UserID, hashToken(normalizeBackupCode(code)))
+}
+
+// Enroll method takes a gin context, validates the path parameter
+// creates a pending TOTP factor with a new secret using model and
+// writes the secret and its otpauth URI back to the API response
+func (m *mfaController) Enroll(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	user, err := m.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	enabled, err := mfaEnabled(m.mfaStore, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if enabled {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errMFAAlreadyEnabled.Error()})
+		return
+	}
+
+	secret, err := generateRandomBytes(totpSecretSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sealed, err := sealSecret(secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.mfaStore.SaveTOTP(&models.TOTPFactor{UserID: id, Secret: sealed}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	encoded := totpEncoding.EncodeToString(secret)
+
+	ctx.JSON(http.StatusCreated, gin.H{"secret": encoded, "otpauthURI": otpauthURI(encoded, user.Email)})
+}
+
+// Confirm method takes a gin context, validates the path parameter and the request body
+// enables the pending TOTP factor once a code of the authenticator app matches
+// and writes the new backup codes back to the API response
+func (m *mfaController) Confirm(ctx *gin.Context) {
+	var input mfaCodeInput
+
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	factor, err := m.mfaStore.GetTOTP(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errMFANotEnrolled.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if factor.ConfirmedAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errMFAAlreadyEnabled.Error()})
+		return
+	}
+
+	secret, err := openSecret(factor.Secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	step, ok := matchTOTP(secret, input.Code, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMFACode.Error()})
+		return
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err1 := m.mfaStore.ConfirmTOTP(id, step, hashes)
+	if errors.Is(err1, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errMFAAlreadyEnabled.Error()})
+		return
+	} else if err1 != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err1.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"backupCodes": codes})
+}
+
+// Login method takes a gin context, validates the request body
+// checks the MFA challenge token issued by the password login and the code,
+// counting failed codes towards a lockout,
+// creates a JWT token with a refresh token and writes back to the API response
+func (m *mfaController) Login(ctx *gin.Context) {
+	var input mfaLoginInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.MFAToken == "" || input.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	challenge, err := m.oneTimeTokenStore.GetByHash(models.PurposeMFAChallenge, hashToken(input.MFAToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if challenge.UsedAt != nil || challenge.ExpiresAt.Before(now) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	}
+
+	// The user may have been deleted within the grace period, to be restored once the code is accepted
+	user, err := m.userStore.GetByID(challenge.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = m.userStore.GetDeletedByID(challenge.UserID, now.Add(-DeletionGracePeriod()))
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys := loginKeys(user.Email, ctx.ClientIP())
+
+	block, err := checkLogin(m.loginAttemptStore, keys, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if block != nil {
+		writeLoginBlock(ctx, block)
+		return
+	}
+
+	factor, err := m.mfaStore.GetTOTP(user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// MFA was disabled since the challenge was issued so the password login has to be repeated
+	if factor.ConfirmedAt == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	}
+
+	accepted, err := verifyMFACode(m.mfaStore, factor, input.Code, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !accepted {
+		if block := recordLoginFailure(m.loginAttemptStore, keys, now); block != nil {
+			recordLock(m.auditEventStore, user.ID, block, ctx.ClientIP(), now)
+			writeLoginBlock(ctx, block)
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFACode.Error()})
+		return
+	}
+
+	consumed, err := m.oneTimeTokenStore.Consume(challenge.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !consumed {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidMFAToken.Error()})
+		return
+	}
+
+	if user.DeletedAt.Valid {
+		if err := m.userStore.Restore(user.ID, now.Add(-DeletionGracePeriod())); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := m.loginAttemptStore.Reset(accountLoginKey(user.Email)); err != nil {
+		log.Printf("failed to reset failed logins of user %d: %v", user.ID, err)
+	}
+
+	tokens, err := issueTokens(m.refreshTokenStore, m.keys, user.ID, user.Role, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
+		return
+	}
+
+	recordAudit(m.auditEventStore, user.ID, models.AuditLogin, "mfa", ctx.ClientIP())
+
+	ctx.JSON(http.StatusOK, gin.H{"id": user.ID, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
+}
+
+// Disable method takes a gin context, validates the path parameter and the request body
+// re-authenticates the user with the password and a code when MFA is enabled
+// removes the TOTP factor and backup codes using model and writes back to the API response
+func (m *mfaController) Disable(ctx *gin.Context) {
+	var input disableMFAInput
+
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	user, err := m.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	keys := accountKeys(user.Email)
+
+	block, err := checkLogin(m.loginAttemptStore, keys, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if block != nil {
+		writeLoginBlock(ctx, block)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		if block := recordLoginFailure(m.loginAttemptStore, keys, now); block != nil {
+			recordLock(m.auditEventStore, user.ID, block, ctx.ClientIP(), now)
+			writeLoginBlock(ctx, block)
+			return
+		}
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": errCurrentPassword.Error()})
+		return
+	}
+
+	factor, err := m.mfaStore.GetTOTP(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errMFANotEnrolled.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A pending enrollment never protected a login so the password is enough to cancel it
+	if factor.ConfirmedAt != nil {
+		accepted, err := verifyMFACode(m.mfaStore, factor, input.Code, now)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !accepted {
+			if block := recordLoginFailure(m.loginAttemptStore, keys, now); block != nil {
+				recordLock(m.auditEventStore, user.ID, block, ctx.ClientIP(), now)
+				writeLoginBlock(ctx, block)
+				return
+			}
+
+			ctx.JSON(http.StatusForbidden, gin.H{"error": errInvalidMFACode.Error()})
+			return
+		}
+	}
+
+	if err := m.mfaStore.Disable(id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
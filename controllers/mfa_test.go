@@ -0,0 +1,612 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Test_totpCode runs unit tests on the function totpCode using the RFC 6238 SHA-1 test vectors
+func Test_totpCode(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "Time 59", unix: 59, want: "287082"},
+		{name: "Time 1111111109", unix: 1111111109, want: "081804"},
+		{name: "Time 1234567890", unix: 1234567890, want: "005924"},
+		{name: "Time 20000000000", unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := totpCode(secret, tt.unix/totpPeriod); got != tt.want {
+				t.Errorf("totpCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_matchTOTP runs unit tests on the function matchTOTP
+func Test_matchTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "Current step", code: totpCode(secret, step), wantStep: step, wantOK: true},
+		{name: "Previous step for clock drift", code: totpCode(secret, step-1), wantStep: step - 1, wantOK: true},
+		{name: "Step outside the window", code: totpCode(secret, step-2), wantOK: false},
+		{name: "Malformed code", code: "12345", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := matchTOTP(secret, tt.code, now)
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("matchTOTP() = %v, %v, want %v, %v", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Test_sealSecret runs unit tests on the functions sealSecret and openSecret
+func Test_sealSecret(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-key")
+
+	secret := []byte("12345678901234567890")
+
+	sealed, err := sealSecret(secret)
+	if err != nil {
+		t.Fatalf("sealSecret() error = %v", err)
+	}
+
+	if strings.Contains(sealed, string(secret)) {
+		t.Errorf("sealSecret() must not keep the secret in plain text")
+	}
+
+	opened, err := openSecret(sealed)
+	if err != nil || !bytes.Equal(opened, secret) {
+		t.Errorf("openSecret() = %v, %v, want %v", opened, err, secret)
+	}
+
+	t.Setenv("MFA_ENCRYPTION_KEY", "other-key")
+	if _, err := openSecret(sealed); err == nil {
+		t.Errorf("openSecret() must fail with another key")
+	}
+
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+	if _, err := sealSecret(secret); err != errMFAKeyNotConfigured {
+		t.Errorf("sealSecret() error = %v, want %v", err, errMFAKeyNotConfigured)
+	}
+}
+
+// Test_mfaController_Enroll runs unit tests on the method Enroll
+func Test_mfaController_Enroll(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-key")
+
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	confirmedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name: "Success case",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().SaveTOTP(gomock.Any()).DoAndReturn(func(factor *models.TOTPFactor) error {
+					if factor.UserID != 1 || factor.Secret == "" {
+						t.Errorf("unexpected totp factor: %v", factor)
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name: "Success case replacing a pending enrollment",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1}, nil)
+				mfaModel.EXPECT().SaveTOTP(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name: "Failure case due to MFA already enabled",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, ConfirmedAt: &confirmedAt}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().SaveTOTP(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			mH := NewMFAController(userModel, mfaModel, oneTimeTokenModel, refreshTokenModel, loginAttemptModel, auditEventModel, testKeys)
+
+			mH.Enroll(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("mfaController.Enroll() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode == http.StatusCreated && !strings.Contains(w.Body.String(), "otpauth://totp/") {
+				t.Errorf("mfaController.Enroll() body = %v, want an otpauth URI", w.Body.String())
+			}
+		})
+	}
+}
+
+// Test_mfaController_Confirm runs unit tests on the method Confirm
+func Test_mfaController_Confirm(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-key")
+
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	secret := []byte("12345678901234567890")
+	sealed, _ := sealSecret(secret)
+	code := totpCode(secret, time.Now().Unix()/totpPeriod)
+	confirmedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:    "Success case",
+			reqBody: `{"code": "` + code + `"}`,
+			expMock: func() {
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, Secret: sealed}, nil)
+				mfaModel.EXPECT().ConfirmTOTP(1, gomock.Any(), gomock.Any()).DoAndReturn(func(userID int, step int64, codeHashes []string) error {
+					if len(codeHashes) != backupCodeCount {
+						t.Errorf("unexpected number of backup codes: %d", len(codeHashes))
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Failure case due to wrong code",
+			reqBody: `{"code": "000000"}`,
+			expMock: func() {
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, Secret: sealed}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to request body",
+			reqBody:  `{}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to missing enrollment",
+			reqBody: `{"code": "` + code + `"}`,
+			expMock: func() {
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "Failure case due to MFA already enabled",
+			reqBody: `{"code": "` + code + `"}`,
+			expMock: func() {
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, Secret: sealed, ConfirmedAt: &confirmedAt}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			mH := NewMFAController(userModel, mfaModel, oneTimeTokenModel, refreshTokenModel, loginAttemptModel, auditEventModel, testKeys)
+
+			mH.Confirm(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("mfaController.Confirm() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_mfaController_Login runs unit tests on the method Login
+func Test_mfaController_Login(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-key")
+
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	secret := []byte("12345678901234567890")
+	sealed, _ := sealSecret(secret)
+	code := totpCode(secret, time.Now().Unix()/totpPeriod)
+	confirmedAt := time.Now()
+	factor := &models.TOTPFactor{UserID: 1, Secret: sealed, ConfirmedAt: &confirmedAt}
+	challenge := func() *models.OneTimeToken {
+		return &models.OneTimeToken{ID: 7, UserID: 1, Purpose: models.PurposeMFAChallenge, ExpiresAt: time.Now().Add(time.Minute)}
+	}
+	validChallenge := func() {
+		oneTimeTokenModel.EXPECT().GetByHash(models.PurposeMFAChallenge, hashToken("challenge")).Return(challenge(), nil)
+		userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+		loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+		loginAttemptModel.EXPECT().Get("ip:").Return(nil, gorm.ErrRecordNotFound)
+	}
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:    "Success case with a TOTP code",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				validChallenge()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseTOTPStep(1, gomock.Any()).Return(true, nil)
+				oneTimeTokenModel.EXPECT().Consume(7).Return(true, nil)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "mfa"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case with a backup code",
+			reqBody: `{"mfaToken": "challenge", "code": "ABCDE-fghij"}`,
+			expMock: func() {
+				validChallenge()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseBackupCode(1, hashToken("abcdefghij")).Return(true, nil)
+				oneTimeTokenModel.EXPECT().Consume(7).Return(true, nil)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "mfa"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Success case restoring a user deleted within the grace period",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeMFAChallenge, hashToken("challenge")).Return(challenge(), nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(&models.User{
+					ID:        1,
+					Email:     "test@gmail.com",
+					DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true},
+				}, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Get("ip:").Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseTOTPStep(1, gomock.Any()).Return(true, nil)
+				oneTimeTokenModel.EXPECT().Consume(7).Return(true, nil)
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(nil)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "mfa"}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "Failure case due to a wrong code of a deleted user, which stays deleted",
+			reqBody: `{"mfaToken": "challenge", "code": "WRONG-CODE0"}`,
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeMFAChallenge, hashToken("challenge")).Return(challenge(), nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByID(1, gomock.Any()).Return(&models.User{
+					ID:        1,
+					Email:     "test@gmail.com",
+					DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true},
+				}, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Get("ip:").Return(nil, gorm.ErrRecordNotFound)
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseBackupCode(1, gomock.Any()).Return(false, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "Failure case due to a replayed TOTP code",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				validChallenge()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseTOTPStep(1, gomock.Any()).Return(false, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "Failure case due to unknown challenge",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeMFAChallenge, hashToken("challenge")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "Failure case due to expired challenge",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				expired := challenge()
+				expired.ExpiresAt = time.Now().Add(-time.Second)
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposeMFAChallenge, hashToken("challenge")).Return(expired, nil)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "Failure case due to MFA disabled since the challenge",
+			reqBody: `{"mfaToken": "challenge", "code": "` + code + `"}`,
+			expMock: func() {
+				validChallenge()
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Failure case due to request body",
+			reqBody:  `{"mfaToken": "challenge"}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			mH := NewMFAController(userModel, mfaModel, oneTimeTokenModel, refreshTokenModel, loginAttemptModel, auditEventModel, testKeys)
+
+			mH.Login(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("mfaController.Login() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_mfaController_Disable runs unit tests on the method Disable
+func Test_mfaController_Disable(t *testing.T) {
+	t.Setenv("MFA_ENCRYPTION_KEY", "test-key")
+
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("xasf2415g46"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Email: "test@gmail.com", Password: string(hash)}
+	secret := []byte("12345678901234567890")
+	sealed, _ := sealSecret(secret)
+	code := totpCode(secret, time.Now().Unix()/totpPeriod)
+	confirmedAt := time.Now()
+	factor := &models.TOTPFactor{UserID: 1, Secret: sealed, ConfirmedAt: &confirmedAt}
+
+	noAttempts := func() {
+		loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+	}
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:    "Success case",
+			reqBody: `{"password": "xasf2415g46", "code": "` + code + `"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseTOTPStep(1, gomock.Any()).Return(true, nil)
+				mfaModel.EXPECT().Disable(1).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Success case cancelling a pending enrollment",
+			reqBody: `{"password": "xasf2415g46"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, Secret: sealed}, nil)
+				mfaModel.EXPECT().Disable(1).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "Failure case due to wrong password",
+			reqBody: `{"password": "wrongpassword", "code": "` + code + `"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "Failure case due to wrong code",
+			reqBody: `{"password": "xasf2415g46", "code": "unknown"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseBackupCode(1, hashToken("unknown")).Return(false, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "Failure case due to a wrong code locking the account at the threshold",
+			reqBody: `{"password": "xasf2415g46", "code": "unknown"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				mfaModel.EXPECT().GetTOTP(1).Return(factor, nil)
+				mfaModel.EXPECT().UseBackupCode(1, hashToken("unknown")).Return(false, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 5}, nil)
+				loginAttemptModel.EXPECT().Lock("account:test@gmail.com", gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(event *models.AuditEvent) error {
+					if event.UserID != 1 || event.Type != models.AuditAccountLocked {
+						t.Errorf("mfaController.Disable() recorded %v, want the lock of user 1", event)
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusLocked,
+		},
+		{
+			name:    "Failure case due to a locked account blocking further codes",
+			reqBody: `{"password": "xasf2415g46", "code": "` + code + `"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(&models.LoginAttempt{Failures: 5, LastFailedAt: time.Now(), LockedUntil: &lockedUntil}, nil)
+			},
+			wantCode: http.StatusLocked,
+		},
+		{
+			name:    "Failure case due to missing enrollment",
+			reqBody: `{"password": "xasf2415g46", "code": "` + code + `"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Failure case due to request body",
+			reqBody:  `{"code": "` + code + `"}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "DELETE"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			mH := NewMFAController(userModel, mfaModel, oneTimeTokenModel, refreshTokenModel, loginAttemptModel, auditEventModel, testKeys)
+
+			mH.Disable(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("mfaController.Disable() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
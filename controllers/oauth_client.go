@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// oauthClientInput is the request body of the admin API registering an OpenID Connect client
+type oauthClientInput struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectURIs"`
+	Confidential bool     `json:"confidential"`
+}
+
+type oauthClientController struct {
+	oauthClientStore models.OAuthClients
+}
+
+func NewOAuthClientController(oc models.OAuthClients) *oauthClientController {
+	return &oauthClientController{
+		oauthClientStore: oc,
+	}
+}
+
+// validRedirectURI function takes a redirect URI and
+// returns whether it is an absolute URL without fragment using https,
+// or http on a loopback address for apps running on the user's device
+func validRedirectURI(redirectURI string) bool {
+	uri, err := url.Parse(redirectURI)
+	if err != nil || !uri.IsAbs() || uri.Host == "" || uri.Fragment != "" {
+		return false
+	}
+
+	switch uri.Scheme {
+	case "https":
+		return true
+	case "http":
+		if uri.Hostname() == "localhost" {
+			return true
+		}
+
+		ip := net.ParseIP(uri.Hostname())
+		return ip != nil && ip.IsLoopback()
+	}
+
+	return false
+}
+
+// validateOAuthClient function takes the request body registering a client and
+// returns an error for a missing name or a missing or invalid redirect URI
+func validateOAuthClient(input *oauthClientInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return errClientName
+	}
+
+	if len(input.RedirectURIs) == 0 {
+		return errRedirectURIs
+	}
+
+	for _, redirectURI := range input.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			return errRedirectURIs
+		}
+	}
+
+	return nil
+}
+
+// Create method takes a gin context, validates the request body
+// registers the client with a generated ID, and a secret for confidential clients, using model
+// and writes back the client to the API response, the only time its secret is returned
+func (o *oauthClientController) Create(ctx *gin.Context) {
+	var input oauthClientInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	if err := validateOAuthClient(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientID, err := generateRandomToken(16)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         input.Name,
+		RedirectURIs: input.RedirectURIs,
+		Confidential: input.Confidential,
+	}
+
+	if client.Confidential {
+		client.ClientSecret, err = generateRandomToken(32)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		client.SecretHash = hashToken(client.ClientSecret)
+	}
+
+	if err := o.oauthClientStore.Create(client); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, client)
+}
+
+// List method takes a gin context
+// fetches every registered client using model
+// and writes back to the API response
+func (o *oauthClientController) List(ctx *gin.Context) {
+	clients, err := o.oauthClientStore.List()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, clients)
+}
+
+// Delete method takes a gin context, reads the client ID path parameter
+// deletes the client along with the consents granted to it using model
+// and writes back to the API response
+func (o *oauthClientController) Delete(ctx *gin.Context) {
+	err := o.oauthClientStore.Delete(ctx.Param("clientID"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errClientNotFound.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_validRedirectURI runs unit tests on the function validRedirectURI
+func Test_validRedirectURI(t *testing.T) {
+	tests := []struct {
+		name        string
+		redirectURI string
+		want        bool
+	}{
+		{name: "https URI", redirectURI: "https://app.example.com/callback", want: true},
+		{name: "http URI on localhost", redirectURI: "http://localhost:3000/callback", want: true},
+		{name: "http URI on a loopback IP", redirectURI: "http://127.0.0.1:8080/callback", want: true},
+		{name: "http URI on a public host", redirectURI: "http://app.example.com/callback", want: false},
+		{name: "URI with a fragment", redirectURI: "https://app.example.com/callback#token", want: false},
+		{name: "relative URI", redirectURI: "/callback", want: false},
+		{name: "custom scheme", redirectURI: "javascript://alert(1)", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validRedirectURI(tt.redirectURI); got != tt.want {
+				t.Errorf("validRedirectURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_oauthClientController_Create runs unit tests on the method Create
+func Test_oauthClientController_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		expMock    func()
+		wantCode   int
+		wantSecret bool
+	}{
+		{
+			name:    "Success case with a confidential client",
+			reqBody: `{"name": "Billing", "redirectURIs": ["https://billing.example.com/callback"], "confidential": true}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(client *models.OAuthClient) error {
+					if client.SecretHash != hashToken(client.ClientSecret) {
+						t.Errorf("oauthClientController.Create() stored secret hash does not match the secret")
+					}
+					return nil
+				})
+			},
+			wantCode:   http.StatusCreated,
+			wantSecret: true,
+		},
+		{
+			name:    "Success case with a public client",
+			reqBody: `{"name": "Mobile", "redirectURIs": ["http://127.0.0.1:8080/callback"]}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Failure case due to missing name",
+			reqBody:  `{"name": " ", "redirectURIs": ["https://billing.example.com/callback"]}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to invalid redirect URI",
+			reqBody:  `{"name": "Billing", "redirectURIs": ["http://billing.example.com/callback"]}`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to model",
+			reqBody: `{"name": "Billing", "redirectURIs": ["https://billing.example.com/callback"]}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			oH := NewOAuthClientController(oauthClientModel)
+
+			oH.Create(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("oauthClientController.Create() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusCreated {
+				return
+			}
+
+			var client models.OAuthClient
+			if err := json.Unmarshal(w.Body.Bytes(), &client); err != nil {
+				t.Fatal(err)
+			}
+
+			if client.ClientID == "" {
+				t.Errorf("oauthClientController.Create() returned no client ID")
+			}
+
+			if gotSecret := client.ClientSecret != ""; gotSecret != tt.wantSecret {
+				t.Errorf("oauthClientController.Create() returned secret = %v, want %v", gotSecret, tt.wantSecret)
+			}
+		})
+	}
+}
+
+// Test_oauthClientController_Delete runs unit tests on the method Delete
+func Test_oauthClientController_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+
+	tests := []struct {
+		name     string
+		clientID string
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:     "Success case",
+			clientID: "abc",
+			expMock: func() {
+				oauthClientModel.EXPECT().Delete("abc").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Failure case due to client not found",
+			clientID: "abc",
+			expMock: func() {
+				oauthClientModel.EXPECT().Delete("abc").Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Failure case due to model",
+			clientID: "abc",
+			expMock: func() {
+				oauthClientModel.EXPECT().Delete("abc").Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "DELETE"
+			ctx.Params = []gin.Param{{Key: "clientID", Value: tt.clientID}}
+
+			oH := NewOAuthClientController(oauthClientModel)
+
+			oH.Delete(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, ctx.Writer.Status()) {
+				t.Errorf("oauthClientController.Delete() = %v, want %v", ctx.Writer.Status(), tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,655 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"gorm.io/gorm"
+)
+
+// defaultAuthorizationCodeTTL is how long an authorization code can be exchanged at the token endpoint
+const defaultAuthorizationCodeTTL = 5 * time.Minute
+
+// Scopes OpenID Connect clients may request, any other scope is ignored
+const (
+	scopeOpenID  = "openid"
+	scopeProfile = "profile"
+	scopeEmail   = "email"
+	scopePhone   = "phone"
+)
+
+var supportedScopes = []string{scopeOpenID, scopeProfile, scopeEmail, scopePhone}
+
+// supportedClaims are the claims of ID tokens and userinfo responses
+var supportedClaims = []string{
+	"sub", "iss", "aud", "exp", "iat", "nonce", "azp",
+	"name", "locale", "zoneinfo", "birthdate", "picture", "updated_at",
+	"email", "email_verified", "phone_number", "phone_number_verified",
+}
+
+var (
+	// codeChallengePattern matches an S256 code challenge, the unpadded base64url SHA-256 of the code verifier
+	codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	// codeVerifierPattern matches an RFC 7636 code verifier
+	codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+)
+
+// authorizeRequest is the OpenID Connect authentication request the login frontend forwards
+// once the user is logged in
+type authorizeRequest struct {
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	ResponseType        string `form:"response_type" json:"response_type"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// consentInput is the request body of the consent screen approving or denying an authentication request
+type consentInput struct {
+	authorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// consentClient describes the client asking for consent on the consent screen
+type consentClient struct {
+	ClientID string `json:"clientID"`
+	Name     string `json:"name"`
+}
+
+// authorizeOutput is the response of the authorize and consent APIs, either
+// the client redirect URI carrying the code or an error for the frontend to follow, or
+// the client and scopes to show on the consent screen
+type authorizeOutput struct {
+	RedirectTo      string         `json:"redirectTo,omitempty"`
+	ConsentRequired bool           `json:"consentRequired"`
+	Client          *consentClient `json:"client,omitempty"`
+	Scopes          []string       `json:"scopes,omitempty"`
+}
+
+// tokenRequest is the form posted to the token endpoint
+type tokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// tokenOutput is the response of the token endpoint
+type tokenOutput struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// discoveryDocument is the OpenID Connect provider metadata
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	IntrospectionAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+}
+
+type oidcController struct {
+	userStore              models.Users
+	oauthClientStore       models.OAuthClients
+	authorizationCodeStore models.AuthorizationCodes
+	consentStore           models.Consents
+	keys                   *signing.KeySet
+}
+
+func NewOIDCController(us models.Users, oc models.OAuthClients, ac models.AuthorizationCodes, cs models.Consents, ks *signing.KeySet) *oidcController {
+	return &oidcController{
+		userStore:              us,
+		oauthClientStore:       oc,
+		authorizationCodeStore: ac,
+		consentStore:           cs,
+		keys:                   ks,
+	}
+}
+
+// UserInfoAudience function returns the aud claim of the access tokens issued to OpenID Connect clients
+// which only grant access to the userinfo endpoint and not to the user APIs
+func UserInfoAudience() string {
+	return issuerURL("/userinfo")
+}
+
+// issuerURL function takes a path and
+// returns the URL of the path under the issuer
+func issuerURL(path string) string {
+	return strings.TrimSuffix(TokenIssuer(), "/") + path
+}
+
+// authorizationEndpoint function reads OIDC_AUTHORIZATION_URL and
+// returns the page of the login frontend clients send users to, the authorize API by default
+func authorizationEndpoint() string {
+	if endpoint := os.Getenv("OIDC_AUTHORIZATION_URL"); endpoint != "" {
+		return endpoint
+	}
+
+	return issuerURL("/authorize")
+}
+
+// requestedScopes function takes a space separated scope and
+// returns the supported scopes it holds without duplicates
+func requestedScopes(scope string) []string {
+	scopes := make([]string, 0, len(supportedScopes))
+	for _, value := range strings.Fields(scope) {
+		if contains(supportedScopes, value) && !contains(scopes, value) {
+			scopes = append(scopes, value)
+		}
+	}
+
+	return scopes
+}
+
+// contains function takes a slice of strings and a value and
+// reports whether the value is in the slice
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// covers function takes a space separated granted scope and the requested scopes and
+// reports whether every requested scope was granted
+func covers(granted string, scopes []string) bool {
+	grantedScopes := strings.Fields(granted)
+	for _, scope := range scopes {
+		if !contains(grantedScopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// redirectWith function takes a validated redirect URI and parameters and
+// returns the redirect URI with the parameters added to its query
+func redirectWith(redirectURI string, params url.Values) string {
+	uri, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := uri.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	uri.RawQuery = query.Encode()
+
+	return uri.String()
+}
+
+// redirectError function takes an authentication request, an OAuth error code and its description and
+// returns the redirect URI carrying the error and the state back to the client
+func redirectError(req *authorizeRequest, code string, err error) string {
+	params := url.Values{"error": {code}, "error_description": {err.Error()}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	return redirectWith(req.RedirectURI, params)
+}
+
+// writeOAuthError function takes a gin context, a status, an OAuth error code and its description
+// and writes back the error in the format of RFC 6749
+func writeOAuthError(ctx *gin.Context, status int, code string, err error) {
+	ctx.JSON(status, gin.H{"error": code, "error_description": err.Error()})
+}
+
+// verifyCodeChallenge function takes a PKCE code verifier and the S256 code challenge and
+// reports whether the verifier hashes to the challenge
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if !codeVerifierPattern.MatchString(verifier) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(codeChallengeOf(verifier)), []byte(challenge)) == 1
+}
+
+// codeChallengeOf function takes a PKCE code verifier and
+// returns its S256 code challenge, the unpadded base64url SHA-256 of the verifier
+func codeChallengeOf(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// userInfoClaims function takes a User object and the granted scopes and
+// returns the standard claims about the user the scopes give access to
+func userInfoClaims(user *models.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{"sub": strconv.Itoa(user.ID)}
+
+	if contains(scopes, scopeProfile) {
+		claims["name"] = user.Name
+		claims["updated_at"] = user.UpdatedAt.Unix()
+
+		optional := map[string]string{"locale": user.Locale, "zoneinfo": user.Timezone, "picture": user.AvatarURL}
+		for claim, value := range optional {
+			if value != "" {
+				claims[claim] = value
+			}
+		}
+
+		if user.DateOfBirth != nil {
+			claims["birthdate"] = user.DateOfBirth.Format(models.DateLayout)
+		}
+	}
+
+	if contains(scopes, scopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerifiedAt != nil
+	}
+
+	// Phone numbers are normalized but never verified by the service
+	if contains(scopes, scopePhone) && user.Phone != "" {
+		claims["phone_number"] = user.Phone
+		claims["phone_number_verified"] = false
+	}
+
+	return claims
+}
+
+// createClientAccessToken function takes the key set, the userID, the client ID, the granted scope and a lifetime
+// generates an access token for the client whose audience is the userinfo endpoint and
+// returns the token along with any error
+func createClientAccessToken(ks *signing.KeySet, userID int, clientID string, scope string, ttl time.Duration) (string, error) {
+	claims, err := registeredClaims(strconv.Itoa(userID), UserInfoAudience(), ttl)
+	if err != nil {
+		return "", err
+	}
+
+	claims["client_id"] = clientID
+	claims["scope"] = scope
+
+	return ks.Sign(claims)
+}
+
+// createIDToken function takes the key set, the User object, the client ID, the nonce of the request,
+// the granted scopes and a lifetime, generates an ID token for the client holding the claims of the scopes and
+// returns the token along with any error
+func createIDToken(ks *signing.KeySet, user *models.User, clientID string, nonce string, scopes []string, ttl time.Duration) (string, error) {
+	claims, err := registeredClaims(strconv.Itoa(user.ID), clientID, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	claims["azp"] = clientID
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	for claim, value := range userInfoClaims(user, scopes) {
+		claims[claim] = value
+	}
+
+	return ks.Sign(claims)
+}
+
+// Discovery method takes a gin context and
+// writes back the OpenID Connect provider metadata
+func (o *oidcController) Discovery(ctx *gin.Context) {
+	// ID tokens signed with the shared secret could not be verified by the clients
+	algorithms := make([]string, 0, 1)
+	if o.keys.SigningKey().Asymmetric() {
+		algorithms = append(algorithms, o.keys.SigningKey().Method.Alg())
+	}
+
+	ctx.JSON(http.StatusOK, discoveryDocument{
+		Issuer:                            TokenIssuer(),
+		AuthorizationEndpoint:             authorizationEndpoint(),
+		TokenEndpoint:                     issuerURL("/token"),
+		UserInfoEndpoint:                  UserInfoAudience(),
+		JWKSURI:                           issuerURL("/.well-known/jwks.json"),
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algorithms,
+		ScopesSupported:                   supportedScopes,
+		ClaimsSupported:                   supportedClaims,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		IntrospectionEndpoint:             issuerURL("/oauth/introspect"),
+		IntrospectionAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+	})
+}
+
+// validateAuthorize method takes a gin context and an authentication request
+// validates the client and its redirect URI, writing back a bad request as the client cannot be trusted with errors,
+// then the response type, scopes and PKCE challenge, writing back the redirect carrying the error to the client
+// returns the client and the requested scopes and whether the request is valid
+func (o *oidcController) validateAuthorize(ctx *gin.Context, req *authorizeRequest) (*models.OAuthClient, []string, bool) {
+	client, err := o.oauthClientStore.Get(req.ClientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errUnknownClient.Error()})
+		return nil, nil, false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	if !client.AllowsRedirect(req.RedirectURI) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errClientRedirect.Error()})
+		return nil, nil, false
+	}
+
+	if req.ResponseType != "code" {
+		ctx.JSON(http.StatusOK, authorizeOutput{RedirectTo: redirectError(req, "unsupported_response_type", errResponseType)})
+		return nil, nil, false
+	}
+
+	scopes := requestedScopes(req.Scope)
+	if !contains(scopes, scopeOpenID) {
+		ctx.JSON(http.StatusOK, authorizeOutput{RedirectTo: redirectError(req, "invalid_scope", errOpenIDScope)})
+		return nil, nil, false
+	}
+
+	// PKCE is required from every client, confidential ones included
+	if req.CodeChallengeMethod != "S256" || !codeChallengePattern.MatchString(req.CodeChallenge) {
+		ctx.JSON(http.StatusOK, authorizeOutput{RedirectTo: redirectError(req, "invalid_request", errPKCERequired)})
+		return nil, nil, false
+	}
+
+	return client, scopes, true
+}
+
+// redirectWithCode method takes a gin context, the user ID, the client, the authentication request and the granted scopes
+// creates a single use authorization code bound to the client, the redirect URI and the PKCE challenge using model
+// and writes back the redirect URI carrying the code and the state
+func (o *oidcController) redirectWithCode(ctx *gin.Context, userID int, client *models.OAuthClient, req *authorizeRequest, scopes []string) {
+	code, err := generateRandomToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := o.authorizationCodeStore.Create(&models.AuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(durationFromEnv("OIDC_CODE_TTL", defaultAuthorizationCodeTTL)),
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	ctx.JSON(http.StatusOK, authorizeOutput{RedirectTo: redirectWith(req.RedirectURI, params)})
+}
+
+// Authorize method takes a gin context with verified JWT claims and the authentication request in the query
+// validates the request and writes back the redirect carrying an authorization code
+// when the user already consented to the scopes, otherwise what to show on the consent screen
+func (o *oidcController) Authorize(ctx *gin.Context) {
+	var req authorizeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	client, scopes, ok := o.validateAuthorize(ctx, &req)
+	if !ok {
+		return
+	}
+
+	userID, _ := UserIDFromClaims(claimsFromContext(ctx))
+
+	consent, err := o.consentStore.Get(userID, client.ClientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err == nil && covers(consent.Scope, scopes) {
+		o.redirectWithCode(ctx, userID, client, &req, scopes)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authorizeOutput{
+		ConsentRequired: true,
+		Client:          &consentClient{ClientID: client.ClientID, Name: client.Name},
+		Scopes:          scopes,
+	})
+}
+
+// Consent method takes a gin context with verified JWT claims and the authentication request in the request body
+// along with the decision of the user on the consent screen, records the consent using model and
+// writes back the redirect carrying an authorization code, or the access_denied error when the user declined
+func (o *oidcController) Consent(ctx *gin.Context) {
+	var input consentInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	req := &input.authorizeRequest
+
+	client, scopes, ok := o.validateAuthorize(ctx, req)
+	if !ok {
+		return
+	}
+
+	if !input.Approve {
+		ctx.JSON(http.StatusOK, authorizeOutput{RedirectTo: redirectError(req, "access_denied", errConsentDenied)})
+		return
+	}
+
+	userID, _ := UserIDFromClaims(claimsFromContext(ctx))
+
+	// Scopes consented to earlier remain granted along with the new ones
+	granted := scopes
+	consent, err := o.consentStore.Get(userID, client.ClientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if err == nil {
+		granted = requestedScopes(consent.Scope + " " + strings.Join(scopes, " "))
+	}
+
+	if err := o.consentStore.Grant(&models.Consent{
+		UserID:   userID,
+		ClientID: client.ClientID,
+		Scope:    strings.Join(granted, " "),
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	o.redirectWithCode(ctx, userID, client, req, scopes)
+}
+
+// authenticateClient method takes a gin context and the token request
+// authenticates the client by HTTP Basic or the form, where public clients send their ID alone, and
+// returns the client, writing back the error response when it fails
+func (o *oidcController) authenticateClient(ctx *gin.Context, req *tokenRequest) (*models.OAuthClient, bool) {
+	return authenticateOAuthClient(ctx, o.oauthClientStore, req.ClientID, req.ClientSecret)
+}
+
+// authenticateOAuthClient function takes a gin context, the client model and the credentials posted in the form
+// authenticates the client by HTTP Basic, falling back to the form credentials, and
+// returns the client, writing back the error response when it fails
+func authenticateOAuthClient(ctx *gin.Context, oc models.OAuthClients, formClientID, formSecret string) (*models.OAuthClient, bool) {
+	clientID, secret, basic := ctx.Request.BasicAuth()
+	if basic {
+		// RFC 6749 form encodes the credentials before they are put in the header
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = formClientID, formSecret
+	}
+
+	client, err := oc.Get(clientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return nil, false
+	}
+
+	authenticated := err == nil
+	if authenticated && client.Confidential {
+		authenticated = secret != "" && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) == 1
+	} else if authenticated {
+		authenticated = secret == ""
+	}
+
+	if !authenticated {
+		if basic {
+			ctx.Header("WWW-Authenticate", `Basic realm="token"`)
+		}
+
+		writeOAuthError(ctx, http.StatusUnauthorized, "invalid_client", errInvalidClient)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// Token method takes a gin context, authenticates the client and
+// exchanges the authorization code, after checking it was issued for the client and redirect URI and
+// that the PKCE code verifier matches its challenge, for an ID token and an access token to the userinfo endpoint
+// and writes back to the API response
+func (o *oidcController) Token(ctx *gin.Context) {
+	var req tokenRequest
+	if err := ctx.ShouldBindWith(&req, binding.FormPost); err != nil {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_request", errPayload)
+		return
+	}
+
+	if req.GrantType != "authorization_code" {
+		writeOAuthError(ctx, http.StatusBadRequest, "unsupported_grant_type", errGrantType)
+		return
+	}
+
+	client, ok := o.authenticateClient(ctx, &req)
+	if !ok {
+		return
+	}
+
+	if !o.keys.SigningKey().Asymmetric() {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", errIDTokenKey)
+		return
+	}
+
+	code, err := o.authorizationCodeStore.GetByHash(hashToken(req.Code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_grant", errInvalidGrant)
+		return
+	} else if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI || code.UsedAt != nil || !code.ExpiresAt.After(time.Now()) {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_grant", errInvalidGrant)
+		return
+	}
+
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_grant", errCodeVerifier)
+		return
+	}
+
+	// Consuming is atomic so that a code exchanged concurrently only yields tokens once
+	consumed, err := o.authorizationCodeStore.Consume(code.ID)
+	if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	} else if !consumed {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_grant", errInvalidGrant)
+		return
+	}
+
+	user, err := o.userStore.GetByID(code.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeOAuthError(ctx, http.StatusBadRequest, "invalid_grant", errInvalidGrant)
+		return
+	} else if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	}
+
+	ttl := durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+
+	accessToken, err := createClientAccessToken(o.keys, user.ID, client.ClientID, code.Scope, ttl)
+	if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	}
+
+	idToken, err := createIDToken(o.keys, user, client.ClientID, code.Nonce, strings.Fields(code.Scope), ttl)
+	if err != nil {
+		writeOAuthError(ctx, http.StatusInternalServerError, "server_error", err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	ctx.JSON(http.StatusOK, tokenOutput{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		IDToken:     idToken,
+		Scope:       code.Scope,
+	})
+}
+
+// UserInfo method takes a gin context with the verified JWT claims of an access token issued to a client
+// fetches the user using model and
+// writes back the claims about the user its scopes give access to
+func (o *oidcController) UserInfo(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	userID, _ := UserIDFromClaims(claims)
+	scope, _ := claims["scope"].(string)
+
+	user, err := o.userStore.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeOAuthError(ctx, http.StatusUnauthorized, "invalid_token", err)
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userInfoClaims(user, strings.Fields(scope)))
+}
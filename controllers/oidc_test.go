@@ -0,0 +1,556 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"gorm.io/gorm"
+)
+
+// testCodeChallenge is the unpadded base64url SHA-256 of testCodeVerifier
+const (
+	testCodeVerifier  = "M25iVXpKU3puUjFaYWg3T1NDTDQtcW1ROUY5YXlwalNoc0hhakxifmZHag"
+	testCodeChallenge = "qjrzSW9gMiUgpUvqgEPE4_-8swvyCtfOVvg55o5S_es"
+)
+
+// testClient is the public client the authentication requests under test are sent by
+var testClient = &models.OAuthClient{
+	ClientID:     "client",
+	Name:         "Billing",
+	RedirectURIs: []string{"https://billing.example.com/callback"},
+}
+
+// Test_verifyCodeChallenge runs unit tests on the function verifyCodeChallenge
+func Test_verifyCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		want     bool
+	}{
+		{name: "Matching verifier", verifier: testCodeVerifier, want: true},
+		{name: "Other verifier", verifier: strings.Repeat("a", 43), want: false},
+		{name: "Verifier too short", verifier: "dBjftJeZ4CVP", want: false},
+		{name: "Missing verifier", verifier: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyCodeChallenge(tt.verifier, testCodeChallenge); got != tt.want {
+				t.Errorf("verifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_oidcController_Authorize runs unit tests on the method Authorize
+func Test_oidcController_Authorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+	authorizationCodeModel := models.NewMockAuthorizationCodes(ctrl)
+	consentModel := models.NewMockConsents(ctrl)
+
+	validQuery := url.Values{
+		"client_id":             {"client"},
+		"redirect_uri":          {"https://billing.example.com/callback"},
+		"response_type":         {"code"},
+		"scope":                 {"openid email"},
+		"state":                 {"xyz"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	withQuery := func(key, value string) string {
+		query := url.Values{}
+		for k, v := range validQuery {
+			query[k] = v
+		}
+		query.Set(key, value)
+		return query.Encode()
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		expMock         func()
+		wantCode        int
+		wantConsent     bool
+		wantRedirectHas string
+	}{
+		{
+			name:  "Success case asking for consent",
+			query: validQuery.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode:    http.StatusOK,
+			wantConsent: true,
+		},
+		{
+			name:  "Success case asking for consent to scopes not granted before",
+			query: validQuery.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(&models.Consent{Scope: "openid"}, nil)
+			},
+			wantCode:    http.StatusOK,
+			wantConsent: true,
+		},
+		{
+			name:  "Success case redirecting with a code when consent was granted",
+			query: validQuery.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(&models.Consent{Scope: "openid profile email"}, nil)
+				authorizationCodeModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "code=",
+		},
+		{
+			name:  "Failure case redirecting without a PKCE challenge",
+			query: withQuery("code_challenge_method", "plain"),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "error=invalid_request",
+		},
+		{
+			name:  "Failure case redirecting without the openid scope",
+			query: withQuery("scope", "email"),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "error=invalid_scope",
+		},
+		{
+			name:  "Failure case due to unregistered redirect URI",
+			query: withQuery("redirect_uri", "https://attacker.example.com/callback"),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Failure case due to unknown client",
+			query: validQuery.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Failure case due to model",
+			query: validQuery.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/authorize?"+tt.query, nil)
+			ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1", "role": models.RoleUser})
+
+			oH := NewOIDCController(nil, oauthClientModel, authorizationCodeModel, consentModel, testKeys)
+
+			oH.Authorize(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("oidcController.Authorize() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var output authorizeOutput
+			if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+				t.Fatal(err)
+			}
+
+			if output.ConsentRequired != tt.wantConsent {
+				t.Errorf("oidcController.Authorize() consentRequired = %v, want %v", output.ConsentRequired, tt.wantConsent)
+			}
+
+			if !strings.Contains(output.RedirectTo, tt.wantRedirectHas) {
+				t.Errorf("oidcController.Authorize() redirectTo = %v, want it to contain %v", output.RedirectTo, tt.wantRedirectHas)
+			}
+		})
+	}
+}
+
+// Test_oidcController_Consent runs unit tests on the method Consent
+func Test_oidcController_Consent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+	authorizationCodeModel := models.NewMockAuthorizationCodes(ctrl)
+	consentModel := models.NewMockConsents(ctrl)
+
+	request := `"client_id": "client", "redirect_uri": "https://billing.example.com/callback", "response_type": "code",
+		"scope": "openid email", "state": "xyz", "code_challenge": "` + testCodeChallenge + `", "code_challenge_method": "S256"`
+
+	tests := []struct {
+		name            string
+		reqBody         string
+		expMock         func()
+		wantCode        int
+		wantRedirectHas string
+	}{
+		{
+			name:    "Success case granting the scopes",
+			reqBody: `{` + request + `, "approve": true}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(nil, gorm.ErrRecordNotFound)
+				consentModel.EXPECT().Grant(&models.Consent{UserID: 1, ClientID: "client", Scope: "openid email"}).Return(nil)
+				authorizationCodeModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "code=",
+		},
+		{
+			name:    "Success case keeping the scopes granted before",
+			reqBody: `{` + request + `, "approve": true}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(&models.Consent{Scope: "openid phone"}, nil)
+				consentModel.EXPECT().Grant(&models.Consent{UserID: 1, ClientID: "client", Scope: "openid phone email"}).Return(nil)
+				authorizationCodeModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "code=",
+		},
+		{
+			name:    "Success case redirecting with access_denied when the user declined",
+			reqBody: `{` + request + `, "approve": false}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode:        http.StatusOK,
+			wantRedirectHas: "error=access_denied",
+		},
+		{
+			name:    "Failure case due to model",
+			reqBody: `{` + request + `, "approve": true}`,
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				consentModel.EXPECT().Get(1, "client").Return(nil, gorm.ErrRecordNotFound)
+				consentModel.EXPECT().Grant(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "Failure case due to invalid payload",
+			reqBody:  `{`,
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+			ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1", "role": models.RoleUser})
+
+			oH := NewOIDCController(nil, oauthClientModel, authorizationCodeModel, consentModel, testKeys)
+
+			oH.Consent(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("oidcController.Consent() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var output authorizeOutput
+			if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(output.RedirectTo, tt.wantRedirectHas) {
+				t.Errorf("oidcController.Consent() redirectTo = %v, want it to contain %v", output.RedirectTo, tt.wantRedirectHas)
+			}
+		})
+	}
+}
+
+// Test_oidcController_Token runs unit tests on the method Token
+func Test_oidcController_Token(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oauthClientModel := models.NewMockOAuthClients(ctrl)
+	authorizationCodeModel := models.NewMockAuthorizationCodes(ctrl)
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingKey, err := signing.NewPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := signing.NewKeySet(signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confidentialClient := &models.OAuthClient{
+		ClientID:     "confidential",
+		RedirectURIs: []string{"https://billing.example.com/callback"},
+		Confidential: true,
+		SecretHash:   hashToken("secret"),
+	}
+
+	validCode := func() *models.AuthorizationCode {
+		return &models.AuthorizationCode{
+			ID:            7,
+			ClientID:      "client",
+			UserID:        1,
+			RedirectURI:   "https://billing.example.com/callback",
+			Scope:         "openid email",
+			Nonce:         "n-0S6_WzA2Mj",
+			CodeChallenge: testCodeChallenge,
+			ExpiresAt:     time.Now().Add(time.Minute),
+		}
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"code"},
+		"redirect_uri":  {"https://billing.example.com/callback"},
+		"client_id":     {"client"},
+		"code_verifier": {testCodeVerifier},
+	}
+
+	withForm := func(key, value string) string {
+		values := url.Values{}
+		for k, v := range form {
+			values[k] = v
+		}
+		values.Set(key, value)
+		return values.Encode()
+	}
+
+	usedAt := time.Now()
+
+	tests := []struct {
+		name         string
+		keys         *signing.KeySet
+		reqBody      string
+		basicAuth    []string
+		expMock      func()
+		wantCode     int
+		wantError    string
+		wantClientID string
+	}{
+		{
+			name:    "Success case with a public client",
+			keys:    keys,
+			reqBody: form.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(validCode(), nil)
+				authorizationCodeModel.EXPECT().Consume(7).Return(true, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "jane@example.com"}, nil)
+			},
+			wantCode:     http.StatusOK,
+			wantClientID: "client",
+		},
+		{
+			name:      "Success case with a confidential client using HTTP Basic",
+			keys:      keys,
+			reqBody:   withForm("client_id", ""),
+			basicAuth: []string{"confidential", "secret"},
+			expMock: func() {
+				code := validCode()
+				code.ClientID = "confidential"
+
+				oauthClientModel.EXPECT().Get("confidential").Return(confidentialClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(code, nil)
+				authorizationCodeModel.EXPECT().Consume(7).Return(true, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "jane@example.com"}, nil)
+			},
+			wantCode:     http.StatusOK,
+			wantClientID: "confidential",
+		},
+		{
+			name:      "Failure case due to wrong client secret",
+			keys:      keys,
+			reqBody:   withForm("client_id", ""),
+			basicAuth: []string{"confidential", "wrong"},
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("confidential").Return(confidentialClient, nil)
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "invalid_client",
+		},
+		{
+			name:    "Failure case due to code verifier not matching the challenge",
+			keys:    keys,
+			reqBody: withForm("code_verifier", strings.Repeat("a", 43)),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(validCode(), nil)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_grant",
+		},
+		{
+			name:    "Failure case due to redirect URI differing from the authentication request",
+			keys:    keys,
+			reqBody: withForm("redirect_uri", "https://billing.example.com/other"),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(validCode(), nil)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_grant",
+		},
+		{
+			name:    "Failure case due to code already used",
+			keys:    keys,
+			reqBody: form.Encode(),
+			expMock: func() {
+				code := validCode()
+				code.UsedAt = &usedAt
+
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(code, nil)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_grant",
+		},
+		{
+			name:    "Failure case due to code consumed concurrently",
+			keys:    keys,
+			reqBody: form.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(validCode(), nil)
+				authorizationCodeModel.EXPECT().Consume(7).Return(false, nil)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_grant",
+		},
+		{
+			name:    "Failure case due to unknown code",
+			keys:    keys,
+			reqBody: form.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+				authorizationCodeModel.EXPECT().GetByHash(hashToken("code")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode:  http.StatusBadRequest,
+			wantError: "invalid_grant",
+		},
+		{
+			name:    "Failure case due to ID tokens signed with the shared secret",
+			keys:    testKeys,
+			reqBody: form.Encode(),
+			expMock: func() {
+				oauthClientModel.EXPECT().Get("client").Return(testClient, nil)
+			},
+			wantCode:  http.StatusInternalServerError,
+			wantError: "server_error",
+		},
+		{
+			name:      "Failure case due to unsupported grant type",
+			keys:      keys,
+			reqBody:   withForm("grant_type", "password"),
+			expMock:   func() {},
+			wantCode:  http.StatusBadRequest,
+			wantError: "unsupported_grant_type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(tt.reqBody))
+			ctx.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuth != nil {
+				ctx.Request.SetBasicAuth(tt.basicAuth[0], tt.basicAuth[1])
+			}
+
+			oH := NewOIDCController(userModel, oauthClientModel, authorizationCodeModel, nil, tt.keys)
+
+			oH.Token(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("oidcController.Token() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				var output map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+					t.Fatal(err)
+				}
+
+				if output["error"] != tt.wantError {
+					t.Errorf("oidcController.Token() error = %v, want %v", output["error"], tt.wantError)
+				}
+				return
+			}
+
+			var output tokenOutput
+			if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+				t.Fatal(err)
+			}
+
+			idToken, err := jwt.Parse(output.IDToken, keys.Keyfunc, jwt.WithAudience(tt.wantClientID), jwt.WithIssuer(TokenIssuer()))
+			if err != nil {
+				t.Fatalf("oidcController.Token() ID token = %v", err)
+			}
+
+			claims := idToken.Claims.(jwt.MapClaims)
+			if claims["nonce"] != "n-0S6_WzA2Mj" || claims["email"] != "jane@example.com" || claims["sub"] != "1" {
+				t.Errorf("oidcController.Token() ID token claims = %v", claims)
+			}
+
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("oidcController.Token() Cache-Control = %v, want no-store", got)
+			}
+		})
+	}
+}
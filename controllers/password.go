@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const defaultPasswordResetTTL = time.Hour
+
+// forgotPasswordInput is the request body accepted by the forgot password API
+type forgotPasswordInput struct {
+	Email string `json:"email"`
+}
+
+// resetPasswordInput is the request body accepted by the reset password API
+type resetPasswordInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// changePasswordInput is the request body accepted by the change password API
+type changePasswordInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type passwordController struct {
+	userStore         models.Users
+	oneTimeTokenStore models.OneTimeTokens
+	refreshTokenStore models.RefreshTokens
+	revocationStore   models.Revocations
+	loginAttemptStore models.LoginAttempts
+	auditEventStore   models.AuditEvents
+	notifier          notifier.Notifier
+	keys              *signing.KeySet
+}
+
+func NewPasswordController(us models.Users, ot models.OneTimeTokens, rt models.RefreshTokens, rs models.Revocations, la models.LoginAttempts, ae models.AuditEvents, n notifier.Notifier, ks *signing.KeySet) *passwordController {
+	return &passwordController{
+		userStore:         us,
+		oneTimeTokenStore: ot,
+		refreshTokenStore: rt,
+		revocationStore:   rs,
+		loginAttemptStore: la,
+		auditEventStore:   ae,
+		notifier:          n,
+		keys:              ks,
+	}
+}
+
+// Forgot method takes a gin context, validates the request body
+// creates a single use reset token for the matching user and notifies them
+// It always writes back an accepted response so that registered emails cannot be enumerated
+func (p *passwordController) Forgot(ctx *gin.Context) {
+	var input forgotPasswordInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Email == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "if the email is registered, a password reset link has been sent"}
+
+	userData, err := p.userStore.GetByEmail(input.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusAccepted, accepted)
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resetToken, err := generateRandomToken(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.oneTimeTokenStore.Create(&models.OneTimeToken{
+		UserID:    userData.ID,
+		Purpose:   models.PurposePasswordReset,
+		TokenHash: hashToken(resetToken),
+		ExpiresAt: time.Now().Add(durationFromEnv("PASSWORD_RESET_TTL", defaultPasswordResetTTL)),
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Delivery failures are only logged as the response must not differ for registered emails
+	if err := p.notifier.Notify(notifier.Message{
+		To:      userData.Email,
+		Subject: "Reset your password",
+		Body:    "Use the following link to reset your password: " + os.Getenv("PASSWORD_RESET_URL") + "?token=" + resetToken,
+	}); err != nil {
+		log.Printf("failed to send password reset notification to user %d: %v", userData.ID, err)
+	}
+
+	ctx.JSON(http.StatusAccepted, accepted)
+}
+
+// Reset method takes a gin context, validates the request body
+// consumes the reset token, updates the password hash using model
+// revokes every existing session of the user and writes back to the API response
+func (p *passwordController) Reset(ctx *gin.Context) {
+	var input resetPasswordInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	if err := validatePassword(input.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resetToken, err := p.oneTimeTokenStore.GetByHash(models.PurposePasswordReset, hashToken(input.Token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidResetToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if resetToken.UsedAt != nil || resetToken.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidResetToken.Error()})
+		return
+	}
+
+	consumed, err := p.oneTimeTokenStore.Consume(resetToken.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !consumed {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidResetToken.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.userStore.UpdatePassword(resetToken.UserID, string(hash)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Other reset links that were requested are no longer valid once the password changed
+	if err := p.oneTimeTokenStore.InvalidateByUser(resetToken.UserID, models.PurposePasswordReset); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.revocationStore.RevokeAll(resetToken.UserID, time.Now(), ""); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.refreshTokenStore.RevokeByUser(resetToken.UserID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// Change method takes a gin context, validates the path parameter and request body
+// verifies the current password, updates the password hash using model
+// revokes every session of the user including the refresh tokens of the current one and
+// writes back fresh tokens continuing the current session in a new family to the API response
+func (p *passwordController) Change(ctx *gin.Context) {
+	var input changePasswordInput
+
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.CurrentPassword == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	if err := validatePassword(input.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errSamePassword.Error()})
+		return
+	}
+
+	userData, err := p.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	keys := accountKeys(userData.Email)
+
+	block, err := checkLogin(p.loginAttemptStore, keys, now)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if block != nil {
+		writeLoginBlock(ctx, block)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(userData.Password), []byte(input.CurrentPassword)); err != nil {
+		if block := recordLoginFailure(p.loginAttemptStore, keys, now); block != nil {
+			recordLock(p.auditEventStore, userData.ID, block, ctx.ClientIP(), now)
+			writeLoginBlock(ctx, block)
+			return
+		}
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": errCurrentPassword.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.userStore.UpdatePassword(id, string(hash)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The current session goes on in a new family so that the refresh token sent before the change,
+	// which may be a stolen copy, is revoked along with every other session
+	sessionID, err := generateRandomToken(16)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.revocationStore.RevokeAll(id, now, sessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.refreshTokenStore.RevokeByUser(id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := issueTokens(p.refreshTokenStore, p.keys, id, userData.Role, sessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": id, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
+}
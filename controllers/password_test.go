@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Test_passwordController_Forgot runs unit tests on the method Forgot
+func Test_passwordController_Forgot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		reqBody  forgotPasswordInput
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to missing email",
+			expMock:  func() {},
+			reqBody:  forgotPasswordInput{},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Accepted case for unknown email",
+			expMock: func() {
+				userModel.EXPECT().GetByEmail("unknown@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody:  forgotPasswordInput{Email: "unknown@gmail.com"},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			reqBody:  forgotPasswordInput{Email: "test@gmail.com"},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Accepted case when notification fails",
+			expMock: func() {
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				notifierMock.EXPECT().Notify(gomock.Any()).Return(errors.New("smtp down"))
+			},
+			reqBody:  forgotPasswordInput{Email: "test@gmail.com"},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{ID: 1, Email: "test@gmail.com"}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *models.OneTimeToken) error {
+					if token.UserID != 1 || token.Purpose != models.PurposePasswordReset || token.TokenHash == "" {
+						t.Errorf("unexpected reset token persisted: %v", token)
+					}
+					return nil
+				})
+				notifierMock.EXPECT().Notify(gomock.Any()).Return(nil)
+			},
+			reqBody:  forgotPasswordInput{Email: "test@gmail.com"},
+			wantCode: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			jsonbytes, _ := json.Marshal(tt.reqBody)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+
+			pH := NewPasswordController(userModel, oneTimeTokenModel, refreshTokenModel, revocationModel, loginAttemptModel, auditEventModel, notifierMock, testKeys)
+
+			pH.Forgot(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("passwordController.Forgot() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_passwordController_Reset runs unit tests on the method Reset
+func Test_passwordController_Reset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		reqBody  resetPasswordInput
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to missing token",
+			expMock:  func() {},
+			reqBody:  resetPasswordInput{Password: "xasf2415g46"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to invalid password",
+			expMock:  func() {},
+			reqBody:  resetPasswordInput{Token: "token", Password: "a"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to unknown token",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposePasswordReset, hashToken("token")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody:  resetPasswordInput{Token: "token", Password: "xasf2415g46"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to used token",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposePasswordReset, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					ExpiresAt: time.Now().Add(time.Hour),
+					UsedAt:    &usedAt,
+				}, nil)
+			},
+			reqBody:  resetPasswordInput{Token: "token", Password: "xasf2415g46"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to expired token",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposePasswordReset, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					ExpiresAt: time.Now().Add(-time.Hour),
+				}, nil)
+			},
+			reqBody:  resetPasswordInput{Token: "token", Password: "xasf2415g46"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				oneTimeTokenModel.EXPECT().GetByHash(models.PurposePasswordReset, hashToken("token")).Return(&models.OneTimeToken{
+					ID:        1,
+					UserID:    1,
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				oneTimeTokenModel.EXPECT().Consume(1).Return(true, nil)
+				userModel.EXPECT().UpdatePassword(1, gomock.Any()).Return(nil)
+				oneTimeTokenModel.EXPECT().InvalidateByUser(1, models.PurposePasswordReset).Return(nil)
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
+			},
+			reqBody:  resetPasswordInput{Token: "token", Password: "xasf2415g46"},
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			jsonbytes, _ := json.Marshal(tt.reqBody)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+
+			pH := NewPasswordController(userModel, oneTimeTokenModel, refreshTokenModel, revocationModel, loginAttemptModel, auditEventModel, notifierMock, testKeys)
+
+			pH.Reset(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("passwordController.Reset() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_passwordController_Change runs unit tests on the method Change
+func Test_passwordController_Change(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("xasf2415g46"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Email: "test@gmail.com", Password: string(hash)}
+
+	noAttempts := func() {
+		loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+	}
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		reqBody  changePasswordInput
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to missing current password",
+			expMock:  func() {},
+			reqBody:  changePasswordInput{NewPassword: "newpassword"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to invalid new password",
+			expMock:  func() {},
+			reqBody:  changePasswordInput{CurrentPassword: "xasf2415g46", NewPassword: "a"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to unchanged password",
+			expMock:  func() {},
+			reqBody:  changePasswordInput{CurrentPassword: "xasf2415g46", NewPassword: "xasf2415g46"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to wrong current password",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			reqBody:  changePasswordInput{CurrentPassword: "wrongpassword", NewPassword: "newpassword"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "Failure case due to wrong current password locking the account",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 5}, nil)
+				loginAttemptModel.EXPECT().Lock("account:test@gmail.com", gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(event *models.AuditEvent) error {
+					if event.UserID != 1 || event.Type != models.AuditAccountLocked {
+						t.Errorf("passwordController.Change() recorded %v, want the lock of user 1", event)
+					}
+					return nil
+				})
+			},
+			reqBody:  changePasswordInput{CurrentPassword: "wrongpassword", NewPassword: "newpassword"},
+			wantCode: http.StatusLocked,
+		},
+		{
+			name: "Failure case due to locked account",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(&models.LoginAttempt{Failures: 5, LastFailedAt: time.Now(), LockedUntil: &lockedUntil}, nil)
+			},
+			reqBody:  changePasswordInput{CurrentPassword: "xasf2415g46", NewPassword: "newpassword"},
+			wantCode: http.StatusLocked,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				userModel.EXPECT().UpdatePassword(1, gomock.Any()).Return(nil)
+				var keptSession string
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), gomock.Any()).DoAndReturn(func(userID int, before time.Time, sessionID string) error {
+					keptSession = sessionID
+					return nil
+				})
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *models.RefreshToken) error {
+					if token.FamilyID == "current" || token.FamilyID != keptSession {
+						t.Errorf("fresh tokens must start the session kept by RevokeAll, got family %v kept %v", token.FamilyID, keptSession)
+					}
+					return nil
+				})
+			},
+			reqBody:  changePasswordInput{CurrentPassword: "xasf2415g46", NewPassword: "newpassword"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Failure case due to refresh token model",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(user, nil)
+				noAttempts()
+				userModel.EXPECT().UpdatePassword(1, gomock.Any()).Return(nil)
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), gomock.Any()).Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(sql.ErrConnDone)
+			},
+			reqBody:  changePasswordInput{CurrentPassword: "xasf2415g46", NewPassword: "newpassword"},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "PUT"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+			ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1", "sid": "current"})
+
+			jsonbytes, _ := json.Marshal(tt.reqBody)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+
+			pH := NewPasswordController(userModel, oneTimeTokenModel, refreshTokenModel, revocationModel, loginAttemptModel, auditEventModel, notifierMock, testKeys)
+
+			pH.Change(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("passwordController.Change() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_passwordController_Change_oldRefreshToken runs a unit test on the method Change
+// checking that the refresh token of the current session sent before the change can no longer be redeemed
+func Test_passwordController_Change_oldRefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("xasf2415g46"), bcrypt.MinCost)
+	oldToken := &models.RefreshToken{ID: 1, UserID: 1, FamilyID: "current", ExpiresAt: time.Now().Add(time.Hour)}
+
+	userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Email: "test@gmail.com", Password: string(hash)}, nil)
+	loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+	userModel.EXPECT().UpdatePassword(1, gomock.Any()).Return(nil)
+	revocationModel.EXPECT().RevokeAll(1, gomock.Any(), gomock.Any()).Return(nil)
+	refreshTokenModel.EXPECT().RevokeByUser(1).DoAndReturn(func(userID int) error {
+		revokedAt := time.Now()
+		oldToken.RevokedAt = &revokedAt
+		return nil
+	})
+	refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPut, "/users/1/password", strings.NewReader(`{"currentPassword": "xasf2415g46", "newPassword": "newpassword"}`))
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1", "sid": "current"})
+
+	NewPasswordController(userModel, oneTimeTokenModel, refreshTokenModel, revocationModel, loginAttemptModel, auditEventModel, notifierMock, testKeys).Change(ctx)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("passwordController.Change() = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	// Redeeming the old refresh token is now a replay which revokes its family
+	refreshTokenModel.EXPECT().GetByHash(hashToken("old")).Return(oldToken, nil)
+	revocationModel.EXPECT().Revoke(gomock.Any()).Return(nil)
+	refreshTokenModel.EXPECT().RevokeFamily("current").Return(nil)
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(`{"refreshToken": "old"}`))
+
+	NewTokenController(userModel, refreshTokenModel, revocationModel, testKeys).Refresh(ctx)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("tokenController.Refresh() with the refresh token sent before the change = %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchableColumns maps the JSON name of every attribute a patch may change to its column
+var patchableColumns = map[string]string{
+	"name":        "name",
+	"countryID":   "country_id",
+	"country":     "country_id",
+	"email":       "email",
+	"phone":       "phone",
+	"locale":      "locale",
+	"timezone":    "timezone",
+	"dateOfBirth": "date_of_birth",
+	"avatarURL":   "avatar_url",
+}
+
+// patchOperation is a single operation of an RFC 6902 JSON Patch document
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from"`
+	Value interface{} `json:"value"`
+}
+
+// mergePatch function takes a target and an RFC 7396 merge patch document
+// applies the patch where null removes a member and objects are merged recursively and
+// returns the patched target
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// pointerMember function takes a JSON pointer and
+// returns the top level member it refers to, as user documents are flat
+func pointerMember(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", fmt.Errorf("unsupported json pointer %q", pointer)
+	}
+
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:]), nil
+}
+
+// applyJSONPatch function takes a document and RFC 6902 operations
+// applies the operations in order to the document and
+// returns the members that were changed along with an error if any
+func applyJSONPatch(doc map[string]interface{}, operations []patchOperation) (map[string]bool, error) {
+	touched := make(map[string]bool)
+
+	for _, operation := range operations {
+		member, err := pointerMember(operation.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch operation.Op {
+		case "add", "replace":
+			if _, ok := doc[member]; !ok && operation.Op == "replace" {
+				return nil, fmt.Errorf("cannot replace missing member %q", member)
+			}
+
+			doc[member] = operation.Value
+			touched[member] = true
+		case "remove":
+			delete(doc, member)
+			touched[member] = true
+		case "test":
+			if !reflect.DeepEqual(doc[member], operation.Value) {
+				return nil, errPatchTestFailed
+			}
+		case "move", "copy":
+			from, err := pointerMember(operation.From)
+			if err != nil {
+				return nil, err
+			}
+
+			value, ok := doc[from]
+			if !ok {
+				return nil, fmt.Errorf("cannot %s missing member %q", operation.Op, from)
+			}
+
+			if operation.Op == "move" {
+				delete(doc, from)
+				touched[from] = true
+			}
+
+			doc[member] = value
+			touched[member] = true
+		default:
+			return nil, fmt.Errorf("unsupported patch operation %q", operation.Op)
+		}
+	}
+
+	return touched, nil
+}
+
+// toDocument function takes a User object and
+// returns its JSON representation as a generic document
+func toDocument(user *models.User) (map[string]interface{}, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// patchUser function takes the existing User, the patch body and its content type
+// applies the merge patch or JSON patch to the user's document and
+// returns the patched User with the touched attributes along with an error if any
+func patchUser(existingUser *models.User, body []byte, contentType string) (*models.User, map[string]bool, error) {
+	doc, err := toDocument(existingUser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var touched map[string]bool
+
+	if contentType == jsonPatchContentType {
+		var operations []patchOperation
+		if err := json.Unmarshal(body, &operations); err != nil {
+			return nil, nil, errPayload
+		}
+
+		touched, err = applyJSONPatch(doc, operations)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var patch map[string]interface{}
+		if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+			return nil, nil, errPayload
+		}
+
+		touched = make(map[string]bool)
+		for member := range patch {
+			touched[member] = true
+		}
+
+		doc = mergePatch(doc, patch).(map[string]interface{})
+	}
+
+	for member := range touched {
+		if _, ok := patchableColumns[member]; !ok {
+			return nil, nil, fmt.Errorf("user attribute %q cannot be patched", member)
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Decoding into an empty User lets removed members fall back to their zero value
+	var patched models.User
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return nil, nil, errPayload
+	}
+
+	// A country given by code or name replaces the existing countryID unless both are patched
+	if touched["country"] && !touched["countryID"] {
+		patched.CountryID = 0
+	}
+
+	patched.ID = existingUser.ID
+	patched.Password = ""
+	patched.CreatedAt = existingUser.CreatedAt
+	patched.UpdatedAt = existingUser.UpdatedAt
+	patched.EmailVerifiedAt = existingUser.EmailVerifiedAt
+	patched.Version = existingUser.Version
+
+	return &patched, touched, nil
+}
+
+// Patch method takes a gin context, validates the path parameter and
+// the RFC 7396 merge patch or RFC 6902 JSON patch in the request body
+// validates only the touched attributes, interacts with the model
+// to update only the changed columns and writes back to the API response
+func (u *userController) Patch(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	contentType := ctx.ContentType()
+	if contentType != "" && contentType != gin.MIMEJSON && contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errPatchContentType.Error()})
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	existingUser, err := u.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !ifMatch(ctx, existingUser) {
+		writePreconditionFailed(ctx, existingUser)
+		return
+	}
+
+	existingUser.Password = ""
+
+	patched, touched, err := patchUser(existingUser, body, contentType)
+	if errors.Is(err, errPatchTestFailed) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateFields(patched, touched); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (touched["phone"] || touched["countryID"] || touched["country"]) && !u.checkCountry(ctx, patched) {
+		return
+	}
+
+	before, err := toDocument(existingUser)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	after, err := toDocument(patched)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns := make([]string, 0, len(touched))
+	for member := range touched {
+		// The country is resolved into countryID so only a different countryID is a change
+		if member == "country" {
+			if touched["countryID"] {
+				continue
+			}
+			member = "countryID"
+		}
+
+		if !reflect.DeepEqual(before[member], after[member]) {
+			columns = append(columns, patchableColumns[member])
+		}
+	}
+
+	if len(columns) == 0 {
+		if err := u.withCountry(existingUser, make(map[int]*models.Country)); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.Header("ETag", etag(existingUser))
+		ctx.JSON(http.StatusOK, existingUser)
+		return
+	}
+
+	emailChanged := patched.Email != existingUser.Email
+	if emailChanged {
+		patched.EmailVerifiedAt = nil
+		columns = append(columns, "email_verified_at")
+	}
+
+	if err := u.userStore.UpdateColumns(patched, columns...); writeVersionConflict(ctx, err) || writeUnknownCountry(ctx, err) || writeEmailConflict(ctx, err) {
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if emailChanged {
+		if err := sendEmailVerification(u.oneTimeTokenStore, u.notifier, patched); err != nil {
+			log.Printf("failed to send email verification to user %d: %v", id, err)
+		}
+	}
+
+	if err := u.withCountry(patched, make(map[int]*models.Country)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("ETag", etag(patched))
+	ctx.JSON(http.StatusOK, patched)
+}
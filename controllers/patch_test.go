@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"gorm.io/gorm"
+)
+
+// Test_mergePatch runs unit tests on the function mergePatch
+func Test_mergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+		patch  interface{}
+		want   interface{}
+	}{
+		{
+			name:   "Replace a member",
+			target: map[string]interface{}{"a": "b"},
+			patch:  map[string]interface{}{"a": "c"},
+			want:   map[string]interface{}{"a": "c"},
+		},
+		{
+			name:   "Remove a member with null",
+			target: map[string]interface{}{"a": "b", "b": "c"},
+			patch:  map[string]interface{}{"a": nil},
+			want:   map[string]interface{}{"b": "c"},
+		},
+		{
+			name:   "Merge nested objects",
+			target: map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}},
+			patch:  map[string]interface{}{"a": map[string]interface{}{"d": nil, "f": "g"}},
+			want:   map[string]interface{}{"a": map[string]interface{}{"b": "c", "f": "g"}},
+		},
+		{
+			name:   "Replace a non object target",
+			target: "a",
+			patch:  map[string]interface{}{"b": "c"},
+			want:   map[string]interface{}{"b": "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePatch(tt.target, tt.patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergePatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_userController_Patch runs unit tests on the method Patch
+func Test_userController_Patch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	existingUser := func() *models.User {
+		return &models.User{
+			ID:        1,
+			Name:      "Test User",
+			CountryID: 1,
+			Email:     "test@gmail.com",
+			Password:  "xasf2415g46",
+			Version:   2,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		reqBody     string
+		expMock     func()
+		wantCode    int
+	}{
+		{
+			name:        "Failure case due to content type",
+			contentType: "text/plain",
+			reqBody:     `name=New`,
+			expMock:     func() {},
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Failure case due to user not found",
+			contentType: mergePatchContentType,
+			reqBody:     `{"name": "New Name"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:        "Failure case due to immutable attribute",
+			contentType: mergePatchContentType,
+			reqBody:     `{"password": "newpassword"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Failure case due to removing a required attribute",
+			contentType: mergePatchContentType,
+			reqBody:     `{"name": null}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Failure case due to phone of another country",
+			contentType: mergePatchContentType,
+			reqBody:     `{"phone": "+1 415 555 2671"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CallingCodes: []string{"+91"}}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Failure case due to unknown country",
+			contentType: mergePatchContentType,
+			reqBody:     `{"countryID": 9}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				countryModel.EXPECT().GetByID(9).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Success case with merge patch",
+			contentType: mergePatchContentType,
+			reqBody:     `{"name": "New Name", "countryID": 1}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "name").DoAndReturn(func(user *models.User, columns ...string) error {
+					if user.Name != "New Name" || user.Email != "test@gmail.com" || user.Password != "" {
+						t.Errorf("unexpected patched user: %v", user)
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "Success case with a country code",
+			contentType: mergePatchContentType,
+			reqBody:     `{"country": "deu"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				countryModel.EXPECT().GetByCode("DEU").Return(&models.Country{ID: 5, CountryCode: "DE", Alpha3Code: "DEU"}, nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "country_id").DoAndReturn(func(user *models.User, columns ...string) error {
+					if user.CountryID != 5 {
+						t.Errorf("unexpected patched country: %v", user.CountryID)
+					}
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "Success case with unchanged attributes",
+			contentType: gin.MIMEJSON,
+			reqBody:     `{"name": "Test User"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "Failure case due to json patch test operation",
+			contentType: jsonPatchContentType,
+			reqBody:     `[{"op": "test", "path": "/name", "value": "Other User"}, {"op": "replace", "path": "/name", "value": "New Name"}]`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:        "Success case with json patch changing the email",
+			contentType: jsonPatchContentType,
+			reqBody:     `[{"op": "test", "path": "/name", "value": "Test User"}, {"op": "replace", "path": "/email", "value": "new@gmail.com"}]`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "email", "email_verified_at").Return(nil)
+				oneTimeTokenModel.EXPECT().InvalidateByUser(1, models.PurposeEmailVerification).Return(nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				notifierMock.EXPECT().Notify(gomock.Any()).Return(nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "Failure case due to json patch changing the email to the one of a deleted user",
+			contentType: jsonPatchContentType,
+			reqBody:     `[{"op": "replace", "path": "/email", "value": "deleted@gmail.com"}]`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "email", "email_verified_at").Return(models.ErrEmailOfDeletedUser)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:        "Failure case due to model",
+			contentType: mergePatchContentType,
+			reqBody:     `{"email": "new@gmail.com"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "email", "email_verified_at").Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "Failure case due to a stale If-Match",
+			contentType: mergePatchContentType,
+			ifMatch:     `"1"`,
+			reqBody:     `{"name": "New Name"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:        "Failure case due to a concurrent update",
+			contentType: mergePatchContentType,
+			ifMatch:     `"2"`,
+			reqBody:     `{"name": "New Name"}`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(existingUser(), nil)
+				userModel.EXPECT().UpdateColumns(gomock.Any(), "name").Return(&models.VersionConflictError{UserID: 1, ExpectedVersion: 2, CurrentVersion: 3})
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "PATCH"
+			ctx.Request.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				ctx.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
+
+			uH.Patch(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("userController.Patch() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_applyJSONPatch runs unit tests on the function applyJSONPatch
+func Test_applyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		operations  []patchOperation
+		wantDoc     map[string]interface{}
+		wantTouched []string
+		wantErr     bool
+	}{
+		{
+			name: "Add, remove and move members",
+			operations: []patchOperation{
+				{Op: "add", Path: "/c", Value: "d"},
+				{Op: "remove", Path: "/a"},
+				{Op: "move", From: "/c", Path: "/e"},
+			},
+			wantDoc:     map[string]interface{}{"e": "d"},
+			wantTouched: []string{"a", "c", "e"},
+		},
+		{
+			name:       "Replace a missing member",
+			operations: []patchOperation{{Op: "replace", Path: "/missing", Value: "d"}},
+			wantErr:    true,
+		},
+		{
+			name:       "Nested pointer",
+			operations: []patchOperation{{Op: "add", Path: "/a/b", Value: "d"}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]interface{}{"a": "b"}
+
+			touched, err := applyJSONPatch(doc, tt.operations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("applyJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotTouched := make([]string, 0, len(touched))
+			for member := range touched {
+				gotTouched = append(gotTouched, member)
+			}
+			sort.Strings(gotTouched)
+
+			if !reflect.DeepEqual(doc, tt.wantDoc) || !reflect.DeepEqual(gotTouched, tt.wantTouched) {
+				t.Errorf("applyJSONPatch() = %v, %v, want %v, %v", doc, gotTouched, tt.wantDoc, tt.wantTouched)
+			}
+		})
+	}
+}
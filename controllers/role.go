@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// roleInput is the request body of the API changing a user's role
+type roleInput struct {
+	Role string `json:"role"`
+}
+
+type roleController struct {
+	userStore       models.Users
+	revocationStore models.Revocations
+	auditEventStore models.AuditEvents
+}
+
+func NewRoleController(us models.Users, rs models.Revocations, ae models.AuditEvents) *roleController {
+	return &roleController{
+		userStore:       us,
+		revocationStore: rs,
+		auditEventStore: ae,
+	}
+}
+
+// Update method takes a gin context, validates the path parameter and request body
+// changes the role of the user using model, revokes the access tokens carrying the old role
+// and writes back to the API response
+func (r *roleController) Update(ctx *gin.Context) {
+	var input roleInput
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPathParam.Error()})
+		return
+	}
+
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	if !models.ValidRole(input.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errInvalidRole.Error()})
+		return
+	}
+
+	// An admin demoting themselves could leave nobody able to manage roles
+	if jwtID, ok := UserIDFromClaims(claimsFromContext(ctx)); ok && jwtID == id && input.Role != models.RoleAdmin {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errOwnRoleChange.Error()})
+		return
+	}
+
+	err1 := r.userStore.UpdateRole(id, input.Role)
+	if errors.Is(err1, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err1.Error()})
+		return
+	} else if err1 != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err1.Error()})
+		return
+	}
+
+	// Sessions survive as refreshing reads the new role, only the access tokens are revoked
+	if err := r.revocationStore.RevokeAll(id, time.Now(), ""); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(r.auditEventStore, id, models.AuditRoleChanged, input.Role, ctx.ClientIP())
+
+	ctx.JSON(http.StatusOK, gin.H{"id": id, "role": input.Role})
+}
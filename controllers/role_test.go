@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_roleController_Update runs unit tests on the method Update
+func Test_roleController_Update(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+
+	tests := []struct {
+		name      string
+		pathParam string
+		reqBody   string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Success case",
+			pathParam: "2",
+			reqBody:   `{"role": "support"}`,
+			expMock: func() {
+				userModel.EXPECT().UpdateRole(2, models.RoleSupport).Return(nil)
+				revocationModel.EXPECT().RevokeAll(2, gomock.Any(), "").Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 2, Type: models.AuditRoleChanged, Detail: models.RoleSupport}).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Failure case due to unknown role",
+			pathParam: "2",
+			reqBody:   `{"role": "owner"}`,
+			expMock:   func() {},
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Failure case due to invalid path parameter",
+			pathParam: "a",
+			reqBody:   `{"role": "support"}`,
+			expMock:   func() {},
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Failure case due to admin demoting themselves",
+			pathParam: "1",
+			reqBody:   `{"role": "user"}`,
+			expMock:   func() {},
+			wantCode:  http.StatusConflict,
+		},
+		{
+			name:      "Failure case due to user not found",
+			pathParam: "2",
+			reqBody:   `{"role": "admin"}`,
+			expMock: func() {
+				userModel.EXPECT().UpdateRole(2, models.RoleAdmin).Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:      "Failure case due to model",
+			pathParam: "2",
+			reqBody:   `{"role": "admin"}`,
+			expMock: func() {
+				userModel.EXPECT().UpdateRole(2, models.RoleAdmin).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "PUT"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
+			ctx.Set(ClaimsKey, jwt.MapClaims{"sub": "1", "role": models.RoleAdmin})
+
+			ctx.Request.Body = io.NopCloser(bytes.NewBufferString(tt.reqBody))
+
+			rH := NewRoleController(userModel, revocationModel, auditEventModel)
+
+			rH.Update(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("roleController.Update() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
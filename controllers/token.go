@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultTokenClockSkew  = 30 * time.Second
+	defaultTokenIssuer     = "gigawrks-user-service"
+	defaultTokenAudience   = "gigawrks"
+)
+
+// ClaimsKey is the gin context key under which the verified JWT claims are stored by the middleware
+const ClaimsKey = "claims"
+
+// tokenPair holds the access token and the refresh token issued together for a session
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// refreshInput is the request body accepted by the refresh API
+type refreshInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type tokenController struct {
+	userStore         models.Users
+	refreshTokenStore models.RefreshTokens
+	revocationStore   models.Revocations
+	keys              *signing.KeySet
+}
+
+func NewTokenController(us models.Users, rt models.RefreshTokens, rs models.Revocations, ks *signing.KeySet) *tokenController {
+	return &tokenController{
+		userStore:         us,
+		refreshTokenStore: rt,
+		revocationStore:   rs,
+		keys:              ks,
+	}
+}
+
+// durationFromEnv function takes an environment variable name and a fallback
+// parses the variable as a duration and
+// returns the fallback when it is missing or invalid
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// TokenIssuer function reads JWT_ISSUER and
+// returns the iss claim of the access tokens issued and accepted by the service
+func TokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+
+	return defaultTokenIssuer
+}
+
+// TokenAudience function reads JWT_AUDIENCE and
+// returns the aud claim of the access tokens issued and accepted by the service
+func TokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+
+	return defaultTokenAudience
+}
+
+// TokenClockSkew function reads JWT_CLOCK_SKEW and
+// returns the leeway applied to the exp, nbf and iat claims of access tokens
+func TokenClockSkew() time.Duration {
+	return durationFromEnv("JWT_CLOCK_SKEW", defaultTokenClockSkew)
+}
+
+// LegacyClaimsAccepted function takes the current time, reads JWT_LEGACY_CLAIMS_UNTIL and
+// reports whether access tokens with the former id and expiry claims are still accepted
+// They are rejected once the RFC 3339 time has passed or when it is not set
+func LegacyClaimsAccepted(now time.Time) bool {
+	until, err := time.Parse(time.RFC3339, os.Getenv("JWT_LEGACY_CLAIMS_UNTIL"))
+	return err == nil && now.Before(until)
+}
+
+// UserIDFromClaims function takes verified JWT claims and
+// returns the user ID held by their sub claim and whether it is valid
+func UserIDFromClaims(claims jwt.MapClaims) (int, bool) {
+	subject, _ := claims["sub"].(string)
+
+	userID, err := strconv.Atoi(subject)
+	if err != nil || userID <= 0 {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// generateRandomBytes function takes a size in bytes and
+// returns that many cryptographically secure random bytes along with any error
+func generateRandomBytes(size int) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// generateRandomToken function takes a size in bytes
+// reads that many random bytes and
+// returns them encoded as a URL safe string along with any error
+func generateRandomToken(size int) (string, error) {
+	buf, err := generateRandomBytes(size)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken function takes a plain token and
+// returns its SHA-256 hex digest which is the only form persisted
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// registeredClaims function takes the subject, the audience and the lifetime of a token and
+// returns its RFC 7519 registered claims with a unique jti along with any error
+func registeredClaims(subject string, audience string, ttl time.Duration) (jwt.MapClaims, error) {
+	jti, err := generateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": subject,
+		"iss": TokenIssuer(),
+		"aud": audience,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"jti": jti,
+	}, nil
+}
+
+// createJWTToken function takes the key set, the userID, the user's role and the session ID of its refresh token family
+// uses the JWT to generate a short lived access token with the RFC 7519 registered claims
+// and an expiration period of ACCESS_TOKEN_TTL (15 minutes by default)
+// signed with the current signing key of the set and
+// returns the token along with any error
+func createJWTToken(ks *signing.KeySet, userID int, role string, sessionID string) (string, error) {
+	claims, err := registeredClaims(strconv.Itoa(userID), TokenAudience(), durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL))
+	if err != nil {
+		return "", err
+	}
+
+	claims["sid"] = sessionID
+	claims["role"] = role
+
+	return ks.Sign(claims)
+}
+
+// issueTokens function takes the refresh token model, the key set, userID, the user's role and a token family
+// creates an access token bound to the family as its session and
+// persists a new refresh token in the family
+// a new family is started when familyID is empty
+// returns the token pair along with any error
+func issueTokens(rt models.RefreshTokens, ks *signing.KeySet, userID int, role string, familyID string) (*tokenPair, error) {
+	var err error
+	if familyID == "" {
+		familyID, err = generateRandomToken(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accessToken, err := createJWTToken(ks, userID, role, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rt.Create(&models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Refresh method takes a gin context, validates the refresh token in the request body
+// rotates it for a new refresh token within the same family and
+// writes back a new access token to the API response
+// Replaying an already rotated token revokes the whole family
+func (t *tokenController) Refresh(ctx *gin.Context) {
+	var input refreshInput
+	if err := ctx.ShouldBindBodyWithJSON(&input); err != nil || input.RefreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPayload.Error()})
+		return
+	}
+
+	stored, err := t.refreshTokenStore.GetByHash(hashToken(input.RefreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidRefreshToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if stored.RevokedAt != nil {
+		t.revokeReusedFamily(ctx, stored)
+		return
+	}
+
+	if stored.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errExpiredRefreshToken.Error()})
+		return
+	}
+
+	// A concurrent rotation of the same token is treated as a replay
+	revoked, err := t.refreshTokenStore.Revoke(stored.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !revoked {
+		t.revokeReusedFamily(ctx, stored)
+		return
+	}
+
+	// The role is read again so that a changed role applies from the next refresh
+	user, err := t.userStore.GetByID(stored.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidRefreshToken.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := issueTokens(t.refreshTokenStore, t.keys, stored.UserID, user.Role, stored.FamilyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": stored.UserID, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
+}
+
+// revokeReusedFamily method takes a gin context and the replayed refresh token
+// revokes the family as a session, so that its access tokens stop working too, along with every token in the family
+// and writes back the error to the API response
+func (t *tokenController) revokeReusedFamily(ctx *gin.Context, replayed *models.RefreshToken) {
+	// Access tokens of the family are issued with the family ID as their sid and live no longer than the access token TTL
+	if err := t.revocationStore.Revoke(&models.RevokedToken{
+		TokenID:   replayed.FamilyID,
+		UserID:    replayed.UserID,
+		ExpiresAt: time.Now().Add(durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)),
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := t.refreshTokenStore.RevokeFamily(replayed.FamilyID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": errRefreshTokenReused.Error()})
+}
+
+// claimsFromContext function takes a gin context and
+// returns the JWT claims stored by the middleware, nil if absent
+func claimsFromContext(ctx *gin.Context) jwt.MapClaims {
+	value, ok := ctx.Get(ClaimsKey)
+	if !ok {
+		return nil
+	}
+
+	claims, _ := value.(jwt.MapClaims)
+	return claims
+}
+
+// Logout method takes a gin context with verified JWT claims
+// revokes the access token by its jti along with its session
+// so that the refresh token family can no longer be used
+// and writes back to the API response
+func (t *tokenController) Logout(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errTokenNotRevocable.Error()})
+		return
+	}
+
+	userID, _ := UserIDFromClaims(claims)
+
+	// The middleware only admits tokens with an exp claim, the access token TTL is merely a safe bound otherwise
+	expiresAt := time.Now().Add(durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL))
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
+
+	if err := t.revocationStore.Revoke(&models.RevokedToken{
+		TokenID:   jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		if err := t.revocationStore.Revoke(&models.RevokedToken{
+			TokenID:   sessionID,
+			UserID:    userID,
+			ExpiresAt: time.Now().Add(durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)),
+		}); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := t.refreshTokenStore.RevokeFamily(sessionID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// LogoutAll method takes a gin context, validates the path parameter
+// revokes every access token issued to the user so far along with all the refresh tokens
+// and writes back to the API response
+func (t *tokenController) LogoutAll(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	if err := t.revocationStore.RevokeAll(id, time.Now(), ""); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := t.refreshTokenStore.RevokeByUser(id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
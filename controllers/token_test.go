@@ -0,0 +1,418 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"gorm.io/gorm"
+)
+
+// Test_tokenController_Refresh runs unit tests on the method Refresh
+func Test_tokenController_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		expMock  func()
+		reqBody  refreshInput
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to missing refresh token",
+			expMock:  func() {},
+			reqBody:  refreshInput{},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "Failure case due to unknown refresh token",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody:  refreshInput{RefreshToken: "unknown"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(nil, sql.ErrConnDone)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Failure case due to reused refresh token",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(time.Hour),
+					RevokedAt: &revokedAt,
+				}, nil)
+				revocationModel.EXPECT().Revoke(gomock.Any()).DoAndReturn(func(token *models.RevokedToken) error {
+					if token.TokenID != "family" || token.UserID != 1 {
+						t.Errorf("the session of the reused family must be revoked, got %+v", token)
+					}
+					return nil
+				})
+				refreshTokenModel.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure case due to revocation model on reused refresh token",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(time.Hour),
+					RevokedAt: &revokedAt,
+				}, nil)
+				revocationModel.EXPECT().Revoke(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Failure case due to expired refresh token",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(-time.Hour),
+				}, nil)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure case due to concurrent rotation",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				refreshTokenModel.EXPECT().Revoke(1).Return(false, nil)
+				revocationModel.EXPECT().Revoke(gomock.Any()).Return(nil)
+				refreshTokenModel.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				refreshTokenModel.EXPECT().Revoke(1).Return(true, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Role: models.RoleSupport}, nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *models.RefreshToken) error {
+					if token.UserID != 1 || token.FamilyID != "family" {
+						t.Errorf("refresh token rotated outside of its family: %v", token)
+					}
+					return nil
+				})
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Failure case due to deleted user",
+			expMock: func() {
+				refreshTokenModel.EXPECT().GetByHash(hashToken("token")).Return(&models.RefreshToken{
+					ID:        1,
+					UserID:    1,
+					FamilyID:  "family",
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				refreshTokenModel.EXPECT().Revoke(1).Return(true, nil)
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody:  refreshInput{RefreshToken: "token"},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			jsonbytes, _ := json.Marshal(tt.reqBody)
+			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+
+			tH := NewTokenController(userModel, refreshTokenModel, revocationModel, testKeys)
+
+			tH.Refresh(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("tokenController.Refresh() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_tokenController_Logout runs unit tests on the method Logout
+func Test_tokenController_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expMock  func()
+		wantCode int
+	}{
+		{
+			name:     "Failure case due to token without jti",
+			claims:   jwt.MapClaims{"sub": "1"},
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Failure case due to model",
+			claims: jwt.MapClaims{"sub": "1", "jti": "jti", "sid": "family"},
+			expMock: func() {
+				revocationModel.EXPECT().Revoke(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "Success case",
+			claims: jwt.MapClaims{"sub": "1", "jti": "jti", "sid": "family", "exp": float64(time.Now().Unix())},
+			expMock: func() {
+				revocationModel.EXPECT().Revoke(gomock.Any()).Return(nil).Times(2)
+				refreshTokenModel.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Set(ClaimsKey, tt.claims)
+
+			tH := NewTokenController(userModel, refreshTokenModel, revocationModel, testKeys)
+
+			tH.Logout(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("tokenController.Logout() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_tokenController_LogoutAll runs unit tests on the method LogoutAll
+func Test_tokenController_LogoutAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+
+	tests := []struct {
+		name      string
+		pathParam string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Success case",
+			pathParam: "1",
+			expMock: func() {
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:      "Failure case due to revocation model",
+			pathParam: "1",
+			expMock: func() {
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:      "Failure case due to refresh token model",
+			pathParam: "1",
+			expMock: func() {
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
+
+			tH := NewTokenController(userModel, refreshTokenModel, revocationModel, testKeys)
+
+			tH.LogoutAll(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("tokenController.LogoutAll() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// Test_createJWTToken runs unit tests on the function createJWTToken
+func Test_createJWTToken(t *testing.T) {
+	t.Setenv("JWT_ISSUER", "https://users.example.com")
+	t.Setenv("JWT_AUDIENCE", "")
+	t.Setenv("ACCESS_TOKEN_TTL", "10m")
+
+	jwtToken, err := createJWTToken(testKeys, 7, models.RoleSupport, "family")
+	if err != nil {
+		t.Fatalf("createJWTToken() error = %v", err)
+	}
+
+	token, err := jwt.Parse(jwtToken, testKeys.Keyfunc,
+		jwt.WithValidMethods(testKeys.Methods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer("https://users.example.com"),
+		jwt.WithAudience(defaultTokenAudience),
+	)
+	if err != nil {
+		t.Fatalf("createJWTToken() token does not verify: %v", err)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	if userID, ok := UserIDFromClaims(claims); !ok || userID != 7 {
+		t.Errorf("createJWTToken() sub = %v, want 7", claims["sub"])
+	}
+
+	for _, claim := range []string{"iat", "nbf", "jti"} {
+		if _, ok := claims[claim]; !ok {
+			t.Errorf("createJWTToken() is missing the %s claim", claim)
+		}
+	}
+
+	for _, claim := range []string{"id", "expiry"} {
+		if _, ok := claims[claim]; ok {
+			t.Errorf("createJWTToken() still has the former %s claim", claim)
+		}
+	}
+
+	if claims["role"] != models.RoleSupport || claims["sid"] != "family" {
+		t.Errorf("createJWTToken() role = %v, sid = %v", claims["role"], claims["sid"])
+	}
+
+	exp, _ := claims.GetExpirationTime()
+	iat, _ := claims.GetIssuedAt()
+	if exp == nil || iat == nil || exp.Sub(iat.Time) != 10*time.Minute {
+		t.Errorf("createJWTToken() exp = %v, iat = %v, want 10 minutes apart", exp, iat)
+	}
+}
+
+// TestUserIDFromClaims runs unit tests on the function UserIDFromClaims
+func TestUserIDFromClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+		wantOK bool
+	}{
+		{name: "Success case", claims: jwt.MapClaims{"sub": "42"}, want: 42, wantOK: true},
+		{name: "Failure case due to missing sub", claims: jwt.MapClaims{"id": float64(42)}},
+		{name: "Failure case due to numeric sub", claims: jwt.MapClaims{"sub": float64(42)}},
+		{name: "Failure case due to non numeric sub", claims: jwt.MapClaims{"sub": "client"}},
+		{name: "Failure case due to zero sub", claims: jwt.MapClaims{"sub": "0"}},
+		{name: "Failure case due to nil claims", claims: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := UserIDFromClaims(tt.claims)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("UserIDFromClaims() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestLegacyClaimsAccepted runs unit tests on the function LegacyClaimsAccepted
+func TestLegacyClaimsAccepted(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until string
+		want  bool
+	}{
+		{name: "Success case within the migration window", until: "2024-03-02T00:00:00Z", want: true},
+		{name: "Failure case after the migration window", until: "2024-03-01T11:59:59Z", want: false},
+		{name: "Failure case without a migration window", until: "", want: false},
+		{name: "Failure case due to an invalid time", until: "tomorrow", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_LEGACY_CLAIMS_UNTIL", tt.until)
+
+			if got := LegacyClaimsAccepted(now); got != tt.want {
+				t.Errorf("LegacyClaimsAccepted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
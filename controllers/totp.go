@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod       = 30
+	totpDigits       = 6
+	totpSecretSize   = 20
+	backupCodeCount  = 10
+	backupCodeLength = 10
+	defaultMFAIssuer = "gigawrks"
+)
+
+// totpEncoding is the unpadded base32 encoding authenticator apps expect for secrets
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode function takes a secret and a time step and
+// returns the RFC 6238 code using HMAC-SHA1 with dynamic truncation
+func totpCode(secret []byte, step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP function takes a secret, a code and the current time
+// accepts the code of the current time step or of an adjacent one for clock drift and
+// returns the matched time step
+func matchTOTP(secret []byte, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - 1; step <= current+1; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// otpauthURI function takes a base32 secret and the account name and
+// returns the otpauth URI to be rendered as a QR code by authenticator apps
+func otpauthURI(secret, account string) string {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + query.Encode()
+}
+
+// mfaCipher function reads MFA_ENCRYPTION_KEY and
+// returns the AES-256-GCM cipher sealing TOTP secrets at rest
+func mfaCipher() (cipher.AEAD, error) {
+	key := os.Getenv("MFA_ENCRYPTION_KEY")
+	if key == "" {
+		return nil, errMFAKeyNotConfigured
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealSecret function takes a TOTP secret
+// encrypts it with a random nonce and
+// returns the nonce and the ciphertext encoded as a string along with any error
+func sealSecret(secret []byte) (string, error) {
+	aead, err := mfaCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := generateRandomBytes(aead.NonceSize())
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, secret, nil)), nil
+}
+
+// openSecret function takes a sealed TOTP secret and
+// returns the decrypted secret along with any error
+func openSecret(sealed string) ([]byte, error) {
+	aead, err := mfaCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed totp secret is too short")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+// normalizeBackupCode function takes a backup code as typed by the user and
+// returns it without separators or spaces in lower case
+func normalizeBackupCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// generateBackupCodes function creates random backup codes and
+// returns them formatted for the user along with their hashes to be stored
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+
+	for i := 0; i < backupCodeCount; i++ {
+		buf, err := generateRandomBytes(backupCodeLength)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))[:backupCodeLength]
+		codes = append(codes, code[:backupCodeLength/2]+"-"+code[backupCodeLength/2:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
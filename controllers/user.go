@@ -2,75 +2,323 @@ package controllers
 
 import (
 	"errors"
+	"log"
 	"net/http"
-	"os"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/language"
 	"gorm.io/gorm"
 )
 
+// defaultDeletionGracePeriod is how long a deleted user can still be restored
+const defaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// DeletionGracePeriod function reads ACCOUNT_DELETION_GRACE_PERIOD and
+// returns how long a deleted user can be restored before it is purged
+func DeletionGracePeriod() time.Duration {
+	return durationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", defaultDeletionGracePeriod)
+}
+
 type userController struct {
-	userStore models.Users
+	userStore         models.Users
+	countryStore      models.Countries
+	refreshTokenStore models.RefreshTokens
+	revocationStore   models.Revocations
+	oneTimeTokenStore models.OneTimeTokens
+	loginAttemptStore models.LoginAttempts
+	mfaStore          models.MFAFactors
+	auditEventStore   models.AuditEvents
+	notifier          notifier.Notifier
+	keys              *signing.KeySet
 }
 
-func NewUserController(us models.Users) *userController {
+func NewUserController(us models.Users, cs models.Countries, rt models.RefreshTokens, rs models.Revocations, ot models.OneTimeTokens, la models.LoginAttempts, mf models.MFAFactors, ae models.AuditEvents, n notifier.Notifier, ks *signing.KeySet) *userController {
 	return &userController{
-		userStore: us,
+		userStore:         us,
+		countryStore:      cs,
+		refreshTokenStore: rt,
+		revocationStore:   rs,
+		oneTimeTokenStore: ot,
+		loginAttemptStore: la,
+		mfaStore:          mf,
+		auditEventStore:   ae,
+		notifier:          n,
+		keys:              ks,
 	}
 }
 
 // validate function takes a User object and
-// validates all the attributes and
+// validates all the attributes including the password and
 // returns an error for any missing or invalid values
 func validate(user *models.User) error {
-	if user.Name == "" {
-		return errors.New("user name cannot be empty")
+	if err := validateProfile(user); err != nil {
+		return err
 	}
 
-	if user.CountryID <= 0 {
-		return errors.New("user's country cannot be empty")
+	return validatePassword(user.Password)
+}
+
+var phoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// phoneSeparators removes the characters phone numbers are commonly grouped with
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// profileRule validates a single profile attribute identified by its JSON name
+type profileRule struct {
+	field string
+	check func(user *models.User) error
+}
+
+// profileRules holds the validation rule of every profile attribute in the order they are reported
+var profileRules = []profileRule{
+	{field: "name", check: func(user *models.User) error {
+		if user.Name == "" {
+			return errors.New("user name cannot be empty")
+		}
+
+		return nil
+	}},
+	{field: "countryID", check: func(user *models.User) error {
+		if user.CountryID <= 0 && user.Country == nil {
+			return errors.New("user's country cannot be empty")
+		}
+
+		return nil
+	}},
+	{field: "email", check: func(user *models.User) error {
+		if user.Email == "" || !regexp.MustCompile(`^[a-zA-Z0-9._]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`).MatchString(user.Email) {
+			return errors.New("user email is empty or invalid")
+		}
+
+		return nil
+	}},
+	{field: "phone", check: func(user *models.User) error {
+		if user.Phone == "" {
+			return nil
+		}
+
+		// Numbers are stored in E.164 format, the 00 international prefix is the same as +
+		phone := phoneSeparators.Replace(user.Phone)
+		if strings.HasPrefix(phone, "00") {
+			phone = "+" + phone[2:]
+		}
+
+		if !phoneRegex.MatchString(phone) {
+			return errors.New("user phone must be an international number with its calling code such as +1 415 555 2671")
+		}
+
+		user.Phone = phone
+		return nil
+	}},
+	{field: "locale", check: func(user *models.User) error {
+		if user.Locale == "" {
+			return nil
+		}
+
+		tag, err := language.Parse(user.Locale)
+		if err != nil {
+			return errors.New("user locale must be a BCP 47 language tag such as en-US")
+		}
+
+		user.Locale = tag.String()
+		return nil
+	}},
+	{field: "timezone", check: func(user *models.User) error {
+		if user.Timezone == "" {
+			return nil
+		}
+
+		// An empty name and Local would load the server's zone rather than a user's one
+		if _, err := time.LoadLocation(user.Timezone); err != nil || user.Timezone == "Local" {
+			return errors.New("user timezone must be an IANA time zone such as Europe/Berlin")
+		}
+
+		return nil
+	}},
+	{field: "dateOfBirth", check: func(user *models.User) error {
+		if user.DateOfBirth == nil {
+			return nil
+		}
+
+		if user.DateOfBirth.Year() < 1900 || user.DateOfBirth.After(time.Now()) {
+			return errors.New("user date of birth must be between 1900-01-01 and today")
+		}
+
+		return nil
+	}},
+	{field: "avatarURL", check: func(user *models.User) error {
+		if user.AvatarURL == "" {
+			return nil
+		}
+
+		avatarURL, err := url.Parse(user.AvatarURL)
+		if err != nil || (avatarURL.Scheme != "https" && avatarURL.Scheme != "http") || avatarURL.Host == "" || len(user.AvatarURL) > 255 {
+			return errors.New("user avatar URL must be an absolute http or https URL of at most 255 characters")
+		}
+
+		return nil
+	}},
+}
+
+// validateProfile function takes a User object and
+// validates all the attributes except the password and
+// returns an error for any missing or invalid values
+func validateProfile(user *models.User) error {
+	return validateFields(user, nil)
+}
+
+// validateFields function takes a User object and the JSON names of the attributes to check
+// validates only those attributes, or every profile attribute when fields is nil, and
+// returns an error for any missing or invalid values
+func validateFields(user *models.User, fields map[string]bool) error {
+	for _, rule := range profileRules {
+		if fields != nil && !fields[rule.field] {
+			continue
+		}
+
+		if err := rule.check(user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// countryOf function takes the country model and a User object and
+// returns the country the user refers to along with an error if any
+// The country is looked up by its alpha-2 or alpha-3 code or common name when given as country,
+// otherwise by countryID
+func countryOf(cs models.Countries, user *models.User) (*models.Country, error) {
+	if user.Country == nil {
+		return cs.GetByID(user.CountryID)
 	}
 
-	if user.Email == "" || !regexp.MustCompile(`^[a-zA-Z0-9._]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`).MatchString(user.Email) {
-		return errors.New("user email is empty or invalid")
+	reference := user.Country.Reference()
+	if reference == "" {
+		return cs.GetByID(user.Country.ID)
 	}
 
-	if user.Password == "" || len(user.Password) < 8 {
-		return errors.New("password should contain a minimum of 8 characters")
+	// References as short as a code are looked up as codes first and as names otherwise
+	if len(reference) <= 3 {
+		country, err := cs.GetByCode(strings.ToUpper(reference))
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return country, err
+		}
+	}
+
+	return cs.GetByName(reference)
+}
+
+// checkCountry method takes a gin context and a validated User object
+// checks that the user's country exists, resolving country into countryID and embedding it,
+// and that the phone starts with one of its calling codes
+// and writes back the error to the API response otherwise
+func (u *userController) checkCountry(ctx *gin.Context, user *models.User) bool {
+	field := "countryID"
+	if user.Country != nil {
+		field = "country"
+	}
+
+	country, err := countryOf(u.countryStore, user)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": errUnknownCountry.Error(), "field": field})
+		return false
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if user.Country != nil && user.CountryID > 0 && user.CountryID != country.ID {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": errCountryConflict.Error(), "field": field})
+		return false
+	}
+
+	user.CountryID = country.ID
+	user.Country = country
+
+	if user.Phone != "" && !country.MatchesCallingCode(user.Phone) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errPhoneCountry.Error()})
+		return false
+	}
+
+	return true
+}
+
+// withCountry method takes a User object and the countries already fetched by ID
+// embeds the user's country unless already resolved, fetching it when it is not among them, and returns an error if any
+// A user whose country no longer exists is left without one
+func (u *userController) withCountry(user *models.User, countries map[int]*models.Country) error {
+	if user.Country != nil && user.Country.ID == user.CountryID {
+		return nil
+	}
+
+	country, ok := countries[user.CountryID]
+	if !ok {
+		var err error
+		country, err = u.countryStore.GetByID(user.CountryID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		countries[user.CountryID] = country
 	}
 
+	user.Country = country
+
 	return nil
 }
 
-// createJWTToken function takes the userID
-// uses the JWT to generate a token
-// with an expiration period of 12 hours and
-// returns the token along with any error
-func createJWTToken(userID int) (string, error) {
-	secretKey := os.Getenv("SECRET_KEY")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"id":     userID,
-			"expiry": time.Now().Add(time.Hour * 12).Unix(), // Keeping an expiration period of 12 hours
-		})
+// writeUnknownCountry function takes a gin context and an error returned while saving a user
+// writes an unprocessable entity naming the countryID field back to the API response
+// when the error is ErrUnknownCountry and returns whether it did
+func writeUnknownCountry(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, models.ErrUnknownCountry) {
+		return false
+	}
 
-	jwtToken, err := token.SignedString([]byte(secretKey))
-	if err != nil {
-		return "", err
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": errUnknownCountry.Error(), "field": "countryID"})
+	return true
+}
+
+// writeEmailConflict function takes a gin context and an error returned while saving a user
+// writes back a conflict on the email, with a hint to restore the account when it belongs to a deleted user,
+// and returns whether it did
+func writeEmailConflict(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrEmailOfDeletedUser):
+		ctx.JSON(http.StatusConflict, gin.H{"error": errEmailOfDeletedUser.Error(), "field": "email"})
+		return true
+	case errors.Is(err, models.ErrEmailTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"error": errEmailTaken.Error(), "field": "email"})
+		return true
 	}
 
-	return jwtToken, nil
+	return false
+}
+
+// validatePassword function takes a plain password and
+// returns an error if it does not satisfy the password policy
+func validatePassword(password string) error {
+	if password == "" || len(password) < 8 {
+		return errors.New("password should contain a minimum of 8 characters")
+	}
+
+	return nil
 }
 
 // Signup method takes a gin context, validates the request body
 // creates a hash of the password and interacts with the model
-// creates a JWT token and writes back to the API response
+// sends an email verification link to the new user
+// creates a JWT token with a refresh token and writes back to the API response
 func (u *userController) Signup(ctx *gin.Context) {
 	var user models.User
 	if err := ctx.ShouldBindBodyWithJSON(&user); err != nil {
@@ -83,6 +331,10 @@ func (u *userController) Signup(ctx *gin.Context) {
 		return
 	}
 
+	if !u.checkCountry(ctx, &user) {
+		return
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -90,25 +342,41 @@ func (u *userController) Signup(ctx *gin.Context) {
 	}
 
 	user.Password = string(hash)
+	user.EmailVerifiedAt = nil
+	user.Role = models.RoleUser
 
 	id, err1 := u.userStore.Create(&user)
-	if err1 != nil {
+	if writeUnknownCountry(ctx, err1) {
+		// The country was deleted after it was checked
+		return
+	} else if writeEmailConflict(ctx, err1) {
+		return
+	} else if err1 != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err1.Error()})
 		return
 	}
 
-	jwtToken, err2 := createJWTToken(id)
+	// The account is usable right away so a failed delivery only needs a resend
+	if err := sendEmailVerification(u.oneTimeTokenStore, u.notifier, &user); err != nil {
+		log.Printf("failed to send email verification to user %d: %v", id, err)
+	}
+
+	tokens, err2 := issueTokens(u.refreshTokenStore, u.keys, id, user.Role, "")
 	if err2 != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"id": id, "jwtToken": jwtToken})
+	ctx.JSON(http.StatusCreated, gin.H{"id": id, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
 }
 
 // Login method takes a gin context, validates the request body
+// rejects the attempt while the account or the client IP is locked or backing off
 // validates the user credentials with existing information using model
-// creates a JWT token and writes back to the API response
+// counts failed attempts towards a lockout
+// restores the user when it was deleted within the grace period
+// returns an MFA challenge token when two-factor authentication is enabled,
+// otherwise creates a JWT token with a refresh token and writes back to the API response
 func (u *userController) Login(ctx *gin.Context) {
 	var user models.User
 	if err := ctx.ShouldBindBodyWithJSON(&user); err != nil {
@@ -121,24 +389,83 @@ func (u *userController) Login(ctx *gin.Context) {
 		return
 	}
 
-	userData, err := u.userStore.GetByEmail(user.Email)
+	now := time.Now()
+	keys := loginKeys(user.Email, ctx.ClientIP())
+
+	block, err := checkLogin(u.loginAttemptStore, keys, now)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
+	} else if block != nil {
+		writeLoginBlock(ctx, block)
+		return
+	}
+
+	userData, err := u.userStore.GetByEmail(user.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		userData, err = u.userStore.GetDeletedByEmail(user.Email, now.Add(-DeletionGracePeriod()))
+	}
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err1 := bcrypt.CompareHashAndPassword([]byte(userData.Password), []byte(user.Password)); err1 != nil {
+	// Unknown emails count as failures too so that they cannot be told apart from wrong passwords
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(userData.Password), []byte(user.Password)) != nil {
+		if block := recordLoginFailure(u.loginAttemptStore, keys, now); block != nil {
+			if err == nil {
+				recordLock(u.auditEventStore, userData.ID, block, ctx.ClientIP(), now)
+			}
+
+			writeLoginBlock(ctx, block)
+			return
+		}
+
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "credentials do not match. Please try again"})
 		return
 	}
 
-	jwtToken, err2 := createJWTToken(userData.ID)
+	mfaRequired, err := mfaEnabled(u.mfaStore, userData.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Failed logins are kept until the second step succeeds so that codes cannot be guessed by logging in again
+	// A deleted user is only restored by the second step so that the password alone cannot cancel the deletion
+	if mfaRequired {
+		challengeToken, err := createMFAChallenge(u.oneTimeTokenStore, userData.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaToken": challengeToken})
+		return
+	}
+
+	if userData.DeletedAt.Valid {
+		if err := u.userStore.Restore(userData.ID, now.Add(-DeletionGracePeriod())); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Only the account starts over as a valid login must not clear failures of other accounts from the same IP
+	if err := u.loginAttemptStore.Reset(accountLoginKey(user.Email)); err != nil {
+		log.Printf("failed to reset failed logins of user %d: %v", userData.ID, err)
+	}
+
+	tokens, err2 := issueTokens(u.refreshTokenStore, u.keys, userData.ID, userData.Role, "")
 	if err2 != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "issue while creating a jwt token"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"id": userData.ID, "jwtToken": jwtToken})
+	recordAudit(u.auditEventStore, userData.ID, models.AuditLogin, "password", ctx.ClientIP())
+
+	ctx.JSON(http.StatusOK, gin.H{"id": userData.ID, "jwtToken": tokens.AccessToken, "refreshToken": tokens.RefreshToken})
 }
 
 // Get method takes a gin context, validates the path parameter
@@ -159,12 +486,19 @@ func (u *userController) Get(ctx *gin.Context) {
 
 	userData.Password = ""
 
+	if err := u.withCountry(userData, make(map[int]*models.Country)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("ETag", etag(userData))
 	ctx.JSON(http.StatusOK, userData)
 }
 
 // Update method takes a gin context, validates the path parameter, request body
 // authorizes the user based on JWT headers, interacts with the model
-// to update the existing user information and writes back to the API response
+// to update the existing user information without touching the password,
+// asks to verify a changed email and writes back to the API response
 func (u *userController) Update(ctx *gin.Context) {
 	var user models.User
 
@@ -176,43 +510,162 @@ func (u *userController) Update(ctx *gin.Context) {
 		return
 	}
 
+	// Password is only changed through its dedicated API
 	user.ID = id
+	user.Password = ""
 
-	if err := validate(&user); err != nil {
+	if err := validateProfile(&user); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
+	if !u.checkCountry(ctx, &user) {
+		return
+	}
+
+	existingUser, err := u.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	user.Password = string(hash)
+	if !ifMatch(ctx, existingUser) {
+		writePreconditionFailed(ctx, existingUser)
+		return
+	}
+
+	user.Version = existingUser.Version
+
+	// The role is only changed through its dedicated API
+	user.Role = existingUser.Role
+
+	// The verification state can only be kept as long as the email is unchanged
+	emailChanged := existingUser.Email != user.Email
+	user.EmailVerifiedAt = existingUser.EmailVerifiedAt
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
 
 	err1 := u.userStore.Update(&user)
-	if err1 != nil {
+	if writeVersionConflict(ctx, err1) || writeUnknownCountry(ctx, err1) || writeEmailConflict(ctx, err1) {
+		return
+	} else if err1 != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err1.Error()})
 		return
 	}
 
-	user.Password = ""
+	if emailChanged {
+		if err := sendEmailVerification(u.oneTimeTokenStore, u.notifier, &user); err != nil {
+			log.Printf("failed to send email verification to user %d: %v", id, err)
+		}
+	}
 
+	ctx.Header("ETag", etag(&user))
 	ctx.JSON(http.StatusOK, user)
 }
 
 // Delete method takes a gin context, validates the path parameter
 // authorizes the user based on JWT headers, interacts with the model
-// to delete the user information and writes back to the API response
+// to soft delete the user information, only at the version given by If-Match if present,
+// signs the user out of every session and writes back to the API response
+// The user can be restored until the grace period ends
 func (u *userController) Delete(ctx *gin.Context) {
 	// Ignoring error as this is already validated in middleware
 	id, _ := strconv.Atoi(ctx.Param("id"))
 
+	if ctx.GetHeader("If-Match") != "" {
+		u.deleteIfMatch(ctx, id)
+		return
+	}
+
 	if err := u.userStore.Delete(id); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	u.revokeDeletedSessions(id)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// revokeDeletedSessions method takes the ID of a deleted user and
+// revokes its access tokens issued until now and its refresh tokens so that no session outlives the deletion
+func (u *userController) revokeDeletedSessions(id int) {
+	if err := u.revocationStore.RevokeAll(id, time.Now(), ""); err != nil {
+		log.Printf("failed to revoke the access tokens of deleted user %d: %v", id, err)
+	}
+
+	if err := u.refreshTokenStore.RevokeByUser(id); err != nil {
+		log.Printf("failed to revoke the sessions of deleted user %d: %v", id, err)
+	}
+}
+
+// Restore method takes a gin context, validates the path parameter
+// authorizes the user based on JWT headers, interacts with the model
+// to restore the user deleted within the grace period and writes back to the API response
+func (u *userController) Restore(ctx *gin.Context) {
+	// Ignoring error as this is already validated in middleware
+	id, _ := strconv.Atoi(ctx.Param("id"))
+
+	err := u.userStore.Restore(id, time.Now().Add(-DeletionGracePeriod()))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": errNotRestorable.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	userData, err := u.userStore.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	userData.Password = ""
+
+	if err := u.withCountry(userData, make(map[int]*models.Country)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("ETag", etag(userData))
+	ctx.JSON(http.StatusOK, userData)
+}
+
+// deleteIfMatch method takes a gin context and a user ID
+// deletes the user information only if it still matches the If-Match header
+// and writes back to the API response
+func (u *userController) deleteIfMatch(ctx *gin.Context, id int) {
+	existingUser, err := u.userStore.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !ifMatch(ctx, existingUser) {
+		writePreconditionFailed(ctx, existingUser)
+		return
+	}
+
+	err1 := u.userStore.DeleteIfMatch(id, existingUser.Version)
+	if writeVersionConflict(ctx, err1) {
+		return
+	} else if errors.Is(err1, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err1.Error()})
+		return
+	} else if err1 != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err1.Error()})
+		return
+	}
+
+	u.revokeDeletedSessions(id)
+
 	ctx.JSON(http.StatusNoContent, nil)
 }
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+var emailDomainRegex = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+$`)
+
+// parseListTime function takes a query value in RFC 3339 or as a plain date and
+// returns the time it refers to along with an error if any
+func parseListTime(value string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+
+	return nil, errors.New("dates must be in RFC 3339 or YYYY-MM-DD format")
+}
+
+// userFilterFromQuery function takes a gin context and
+// returns the UserFilter described by the query parameters along with an error if any
+func userFilterFromQuery(ctx *gin.Context) (models.UserFilter, error) {
+	filter := models.UserFilter{
+		Sort:   ctx.Query("sort"),
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if value := ctx.Query("countryID"); value != "" {
+		countryID, err := strconv.Atoi(value)
+		if err != nil || countryID <= 0 {
+			return filter, errors.New("countryID must be a positive integer")
+		}
+		filter.CountryID = countryID
+	}
+
+	if value := ctx.Query("emailDomain"); value != "" {
+		domain := strings.ToLower(strings.TrimPrefix(value, "@"))
+		if !emailDomainRegex.MatchString(domain) {
+			return filter, errors.New("emailDomain must be a domain such as example.com")
+		}
+		filter.EmailDomain = domain
+	}
+
+	if value := ctx.Query("createdAfter"); value != "" {
+		createdAfter, err := parseListTime(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.CreatedAfter = createdAfter
+	}
+
+	if value := ctx.Query("createdBefore"); value != "" {
+		createdBefore, err := parseListTime(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.CreatedBefore = createdBefore
+	}
+
+	if value := ctx.Query("verified"); value != "" {
+		verified, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("verified must be true or false")
+		}
+		filter.Verified = &verified
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > models.MaxUserPageSize {
+			return filter, errors.New("limit must be between 1 and " + strconv.Itoa(models.MaxUserPageSize))
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// List method takes a gin context, validates the filters, sort and cursor in the query
+// interacts with the model to fetch a page of the matching users
+// and writes back the page with the total count of matching users in the X-Total-Count header
+func (u *userController) List(ctx *gin.Context) {
+	filter, err := userFilterFromQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := u.userStore.List(ctx.Request.Context(), filter)
+	if errors.Is(err, models.ErrInvalidSort) || errors.Is(err, models.ErrInvalidCursor) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	countries := make(map[int]*models.Country)
+	for i := range page.Users {
+		page.Users[i].Password = ""
+
+		if err := u.withCountry(&page.Users[i], countries); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	ctx.JSON(http.StatusOK, gin.H{"users": page.Users, "nextCursor": page.NextCursor})
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+)
+
+// Test_userController_List runs unit tests on the method List
+func Test_userController_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	tests := []struct {
+		name      string
+		query     string
+		expMock   func()
+		wantCode  int
+		wantTotal string
+	}{
+		{
+			name:  "Success case",
+			query: "countryID=1&emailDomain=@Gmail.com&verified=true&createdAfter=2024-01-01&sort=-createdAt&limit=2",
+			expMock: func() {
+				userModel.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, filter models.UserFilter) (*models.UserPage, error) {
+					if filter.CountryID != 1 || filter.EmailDomain != "gmail.com" || filter.Verified == nil || !*filter.Verified ||
+						filter.CreatedAfter == nil || filter.Sort != "-createdAt" || filter.Limit != 2 {
+						t.Errorf("unexpected user filter: %+v", filter)
+					}
+					return &models.UserPage{
+						Users:      []models.User{{ID: 1, CountryID: 1, Password: "hash"}, {ID: 2, CountryID: 1, Password: "hash"}},
+						Total:      7,
+						NextCursor: "cursor",
+					}, nil
+				})
+				// Users of the same country share a single lookup
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+			},
+			wantCode:  http.StatusOK,
+			wantTotal: "7",
+		},
+		{
+			name:     "Failure case due to invalid date",
+			query:    "createdBefore=yesterday",
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Failure case due to invalid limit",
+			query:    "limit=1000",
+			expMock:  func() {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Failure case due to invalid cursor",
+			query: "cursor=abc",
+			expMock: func() {
+				userModel.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, models.ErrInvalidCursor)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Failure case due to model",
+			query: "",
+			expMock: func() {
+				userModel.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{RawQuery: tt.query},
+			}
+			ctx.Request.Method = "GET"
+
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
+
+			uH.List(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("userController.List() = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("userController.List() X-Total-Count = %q, want %q", got, tt.wantTotal)
+			}
+		})
+	}
+}
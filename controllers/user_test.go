@@ -10,16 +10,216 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Test_validateProfile runs unit tests on the function validateProfile
+func Test_validateProfile(t *testing.T) {
+	dateOfBirth := models.NewDate(1990, time.May, 17)
+	future := models.NewDate(time.Now().Year()+1, time.January, 1)
+
+	valid := func() models.User {
+		return models.User{
+			Name:        "Test User",
+			CountryID:   1,
+			Email:       "test@gmail.com",
+			Phone:       "+919876543210",
+			Locale:      "en-IN",
+			Timezone:    "Asia/Kolkata",
+			DateOfBirth: &dateOfBirth,
+			AvatarURL:   "https://cdn.example.com/avatar.png",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(user *models.User)
+		wantErr bool
+	}{
+		{name: "Valid profile", change: func(user *models.User) {}, wantErr: false},
+		{name: "Valid profile without optional fields", change: func(user *models.User) { *user = models.User{Name: "Test User", CountryID: 1, Email: "test@gmail.com"} }, wantErr: false},
+		{name: "Phone with separators and 00 prefix", change: func(user *models.User) { user.Phone = "0091 98765-43210" }, wantErr: false},
+		{name: "Phone without country code", change: func(user *models.User) { user.Phone = "9876543210" }, wantErr: true},
+		{name: "Phone too long", change: func(user *models.User) { user.Phone = "+1234567890123456" }, wantErr: true},
+		{name: "Invalid locale", change: func(user *models.User) { user.Locale = "not a locale" }, wantErr: true},
+		{name: "Unknown timezone", change: func(user *models.User) { user.Timezone = "Mars/Olympus_Mons" }, wantErr: true},
+		{name: "Local timezone", change: func(user *models.User) { user.Timezone = "Local" }, wantErr: true},
+		{name: "Date of birth in the future", change: func(user *models.User) { user.DateOfBirth = &future }, wantErr: true},
+		{name: "Relative avatar URL", change: func(user *models.User) { user.AvatarURL = "/avatar.png" }, wantErr: true},
+		{name: "Avatar URL with another scheme", change: func(user *models.User) { user.AvatarURL = "javascript:alert(1)" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := valid()
+			tt.change(&user)
+
+			if err := validateProfile(&user); (err != nil) != tt.wantErr {
+				t.Errorf("validateProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userController_checkCountry runs unit tests on the method checkCountry
+func Test_userController_checkCountry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	countryModel := models.NewMockCountries(ctrl)
+
+	germany := &models.Country{ID: 5, CommonName: "Germany", CountryCode: "DE", Alpha3Code: "DEU", CallingCodes: []string{"+49"}}
+
+	tests := []struct {
+		name          string
+		reqBody       string
+		expMock       func()
+		wantOK        bool
+		wantCode      int
+		wantCountryID int
+	}{
+		{
+			name:    "Country ID",
+			reqBody: `{"countryID": 5}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByID(5).Return(germany, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 5,
+		},
+		{
+			name:    "Alpha-2 code",
+			reqBody: `{"country": "de"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DE").Return(germany, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 5,
+		},
+		{
+			name:    "Alpha-3 code",
+			reqBody: `{"country": "DEU", "phone": "+4930901820"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DEU").Return(germany, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 5,
+		},
+		{
+			name:    "Common name",
+			reqBody: `{"country": "Germany"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByName("Germany").Return(germany, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 5,
+		},
+		{
+			name:    "Short common name",
+			reqBody: `{"country": "Chad"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByName("Chad").Return(&models.Country{ID: 7, CommonName: "Chad"}, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 7,
+		},
+		{
+			name:    "Country object returned by a previous response",
+			reqBody: `{"countryID": 5, "country": {"id": 5, "countryCode": "DE"}}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DE").Return(germany, nil)
+			},
+			wantOK:        true,
+			wantCountryID: 5,
+		},
+		{
+			name:    "Unknown country",
+			reqBody: `{"country": "Atlantis"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByName("Atlantis").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Unknown code",
+			reqBody: `{"country": "XX"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("XX").Return(nil, gorm.ErrRecordNotFound)
+				countryModel.EXPECT().GetByName("XX").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Country conflicting with countryID",
+			reqBody: `{"countryID": 1, "country": "DE"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DE").Return(germany, nil)
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Phone of another country",
+			reqBody: `{"country": "DE", "phone": "+14155552671"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DE").Return(germany, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "Failure case due to model",
+			reqBody: `{"country": "DE"}`,
+			expMock: func() {
+				countryModel.EXPECT().GetByCode("DE").Return(nil, sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+
+			var user models.User
+			if err := json.Unmarshal([]byte(tt.reqBody), &user); err != nil {
+				t.Fatalf("Unexpected error '%v' when decoding the user", err)
+			}
+
+			uH := NewUserController(nil, countryModel, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			if got := uH.checkCountry(ctx, &user); got != tt.wantOK {
+				t.Errorf("userController.checkCountry() = %v, want %v", got, tt.wantOK)
+				return
+			}
+
+			if tt.wantOK && (user.CountryID != tt.wantCountryID || user.Country == nil || user.Country.ID != tt.wantCountryID) {
+				t.Errorf("userController.checkCountry() resolved %d, %v, want %d", user.CountryID, user.Country, tt.wantCountryID)
+			}
+
+			if !tt.wantOK && w.Code != tt.wantCode {
+				t.Errorf("userController.checkCountry() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
 func Test_userController_Signup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
 
 	tests := []struct {
 		name     string
@@ -69,6 +269,98 @@ func Test_userController_Signup(t *testing.T) {
 			},
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name: "Failure case due to unknown country",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(9).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 9,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Failure case due to email of a user deleted within the grace period",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().Create(gomock.Any()).Return(0, models.ErrEmailOfDeletedUser)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "Failure case due to email of another user",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().Create(gomock.Any()).Return(0, models.ErrEmailTaken)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "Failure case due to country deleted before the user was created",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().Create(gomock.Any()).Return(0, models.ErrUnknownCountry)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Failure case due to model",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().Create(gomock.Any()).Return(0, sql.ErrConnDone)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().Create(gomock.Any()).Return(1, nil)
+				oneTimeTokenModel.EXPECT().InvalidateByUser(gomock.Any(), models.PurposeEmailVerification).Return(nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *models.OneTimeToken) error {
+					if token.Purpose != models.PurposeEmailVerification || token.Email != "test@gmail.com" {
+						t.Errorf("unexpected verification token persisted: %v", token)
+					}
+					return nil
+				})
+				notifierMock.EXPECT().Notify(gomock.Any()).Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusCreated,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -86,7 +378,7 @@ func Test_userController_Signup(t *testing.T) {
 			jsonbytes, _ := json.Marshal(tt.reqBody)
 			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
 
-			uH := NewUserController(userModel)
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
 
 			uH.Signup(ctx)
 
@@ -100,12 +392,30 @@ func Test_userController_Signup(t *testing.T) {
 func Test_userController_Login(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("xasf2415g46"), bcrypt.MinCost)
+
+	noAttempts := func() {
+		loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+		loginAttemptModel.EXPECT().Get("ip:").Return(nil, gorm.ErrRecordNotFound)
+	}
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	expiredLock := time.Now().Add(-time.Minute)
 
 	tests := []struct {
-		name     string
-		expMock  func()
-		reqBody  models.User
-		wantCode int
+		name           string
+		expMock        func()
+		reqBody        models.User
+		wantCode       int
+		wantRetryAfter bool
 	}{
 		{
 			name:    "Failure case due to missing data",
@@ -118,6 +428,7 @@ func Test_userController_Login(t *testing.T) {
 		{
 			name: "Failure case due to Get By Email model",
 			expMock: func() {
+				noAttempts()
 				userModel.EXPECT().GetByEmail("test@gmail.com").Return(nil, sql.ErrNoRows)
 			},
 			reqBody: models.User{
@@ -126,6 +437,250 @@ func Test_userController_Login(t *testing.T) {
 			},
 			wantCode: http.StatusInternalServerError,
 		},
+		{
+			name: "Failure case due to wrong password",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "wrongpassword",
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Success case",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "password"}).Return(nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case despite a failure recording the login",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(gomock.Any()).Return(sql.ErrConnDone)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case restoring a deleted user",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByEmail("test@gmail.com", gomock.Any()).Return(&models.User{
+					ID:        1,
+					Email:     "test@gmail.com",
+					Password:  string(hash),
+					DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true},
+				}, nil)
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "password"}).Return(nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case leaving a deleted MFA user deleted until the second step",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByEmail("test@gmail.com", gomock.Any()).Return(&models.User{
+					ID:        1,
+					Email:     "test@gmail.com",
+					Password:  string(hash),
+					DeletedAt: gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true},
+				}, nil)
+				confirmedAt := time.Now()
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, ConfirmedAt: &confirmedAt}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				// No Restore is expected, the mock fails the test if it is called
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case after the lock expired",
+			expMock: func() {
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(&models.LoginAttempt{Failures: 5, LastFailedAt: expiredLock.Add(-15 * time.Minute), LockedUntil: &expiredLock}, nil)
+				loginAttemptModel.EXPECT().Get("ip:").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Reset("account:test@gmail.com").Return(nil)
+				refreshTokenModel.EXPECT().Create(gomock.Any()).Return(nil)
+				auditEventModel.EXPECT().Create(&models.AuditEvent{UserID: 1, Type: models.AuditLogin, Detail: "password"}).Return(nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Success case requiring the second factor",
+			expMock: func() {
+				confirmedAt := time.Now()
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				mfaModel.EXPECT().GetTOTP(1).Return(&models.TOTPFactor{UserID: 1, ConfirmedAt: &confirmedAt}, nil)
+				oneTimeTokenModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *models.OneTimeToken) error {
+					if token.Purpose != models.PurposeMFAChallenge || token.UserID != 1 {
+						t.Errorf("unexpected mfa challenge: %v", token)
+					}
+					return nil
+				})
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Failure case due to unknown email",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				userModel.EXPECT().GetDeletedByEmail("test@gmail.com", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure case due to locked account",
+			expMock: func() {
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(&models.LoginAttempt{Failures: 5, LastFailedAt: time.Now(), LockedUntil: &lockedUntil}, nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode:       http.StatusLocked,
+			wantRetryAfter: true,
+		},
+		{
+			name: "Failure case due to wrong password from a client IP within its grace",
+			expMock: func() {
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Get("ip:").Return(&models.LoginAttempt{Failures: 3, LastFailedAt: time.Now()}, nil)
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 1}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 4}, nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "wrongpassword",
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Failure case due to pending backoff of the client IP",
+			expMock: func() {
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, gorm.ErrRecordNotFound)
+				loginAttemptModel.EXPECT().Get("ip:").Return(&models.LoginAttempt{Failures: 13, LastFailedAt: time.Now()}, nil)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: true,
+		},
+		{
+			name: "Failure case locking the account",
+			expMock: func() {
+				noAttempts()
+				userModel.EXPECT().GetByEmail("test@gmail.com").Return(&models.User{
+					ID:       1,
+					Email:    "test@gmail.com",
+					Password: string(hash),
+				}, nil)
+				loginAttemptModel.EXPECT().RecordFailure("account:test@gmail.com", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 5}, nil)
+				loginAttemptModel.EXPECT().Lock("account:test@gmail.com", gomock.Any()).Return(nil)
+				loginAttemptModel.EXPECT().RecordFailure("ip:", gomock.Any(), gomock.Any()).Return(&models.LoginAttempt{Failures: 5}, nil)
+				auditEventModel.EXPECT().Create(gomock.Any()).DoAndReturn(func(event *models.AuditEvent) error {
+					if event.UserID != 1 || event.Type != models.AuditAccountLocked {
+						t.Errorf("userController.Login() recorded %v, want the lock of user 1", event)
+					}
+					return nil
+				})
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "wrongpassword",
+			},
+			wantCode:       http.StatusLocked,
+			wantRetryAfter: true,
+		},
+		{
+			name: "Failure case due to login attempt model",
+			expMock: func() {
+				loginAttemptModel.EXPECT().Get("account:test@gmail.com").Return(nil, sql.ErrConnDone)
+			},
+			reqBody: models.User{
+				Email:    "test@gmail.com",
+				Password: "xasf2415g46",
+			},
+			wantCode: http.StatusInternalServerError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -143,13 +698,17 @@ func Test_userController_Login(t *testing.T) {
 			jsonbytes, _ := json.Marshal(tt.reqBody)
 			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
 
-			uH := NewUserController(userModel)
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
 
 			uH.Login(ctx)
 
 			if !reflect.DeepEqual(tt.wantCode, w.Code) {
 				t.Errorf("userController.Login() = %v, want %v", w.Code, tt.wantCode)
 			}
+
+			if got := w.Header().Get("Retry-After") != ""; got != tt.wantRetryAfter {
+				t.Errorf("userController.Login() Retry-After set = %v, want %v", got, tt.wantRetryAfter)
+			}
 		})
 	}
 }
@@ -157,6 +716,14 @@ func Test_userController_Login(t *testing.T) {
 func Test_userController_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
 
 	tests := []struct {
 		name      string
@@ -164,6 +731,7 @@ func Test_userController_Get(t *testing.T) {
 		pathParam string
 		expMock   func()
 		wantCode  int
+		wantETag  string
 	}{
 		{
 			name:      "Success case",
@@ -176,9 +744,12 @@ func Test_userController_Get(t *testing.T) {
 					CountryID: 1,
 					Email:     "test@gmail.com",
 					Password:  "xasf2415g46",
+					Version:   3,
 				}, nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CommonName: "United States", CountryCode: "US"}, nil)
 			},
 			wantCode: http.StatusOK,
+			wantETag: `"3"`,
 		},
 		{
 			name:      "Failure case due to model",
@@ -214,13 +785,17 @@ func Test_userController_Get(t *testing.T) {
 
 			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
 
-			uH := NewUserController(userModel)
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
 
 			uH.Get(ctx)
 
 			if !reflect.DeepEqual(tt.wantCode, w.Code) {
 				t.Errorf("userController.Get() = %v, want %v", w.Code, tt.wantCode)
 			}
+
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("userController.Get() ETag = %v, want %v", got, tt.wantETag)
+			}
 		})
 	}
 }
@@ -228,11 +803,22 @@ func Test_userController_Get(t *testing.T) {
 func Test_userController_Update(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	dateOfBirth := models.NewDate(1990, time.May, 17)
 
 	tests := []struct {
 		name      string
 		userID    int
 		pathParam string
+		ifMatch   string
 		expMock   func()
 		reqBody   models.User
 		wantCode  int
@@ -259,6 +845,209 @@ func Test_userController_Update(t *testing.T) {
 		// 	},
 		// 	wantCode: http.StatusOK,
 		// },
+		{
+			name:      "Success case ignoring the password",
+			userID:    1,
+			pathParam: "1",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+				}, nil)
+				userModel.EXPECT().Update(gomock.Any()).DoAndReturn(func(user *models.User) error {
+					if user.Password != "" {
+						t.Errorf("userController.Update() must not update the password")
+					}
+					return nil
+				})
+			},
+			reqBody: models.User{
+				ID:        1,
+				Name:      "New Name",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Success case with the extended profile",
+			userID:    1,
+			pathParam: "1",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CallingCodes: []string{"+1201", "+1415"}}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+				}, nil)
+				userModel.EXPECT().Update(gomock.Any()).DoAndReturn(func(user *models.User) error {
+					if user.Phone != "+14155552671" || user.Locale != "pt-BR" || user.Timezone != "America/Sao_Paulo" ||
+						user.DateOfBirth == nil || user.DateOfBirth.Format(models.DateLayout) != "1990-05-17" ||
+						user.AvatarURL != "https://cdn.example.com/avatar.png" {
+						t.Errorf("userController.Update() did not keep the extended profile: %+v", user)
+					}
+					return nil
+				})
+			},
+			reqBody: models.User{
+				ID:          1,
+				Name:        "Test User",
+				CountryID:   1,
+				Email:       "test@gmail.com",
+				Phone:       "+1 (415) 555-2671",
+				Locale:      "pt-br",
+				Timezone:    "America/Sao_Paulo",
+				DateOfBirth: &dateOfBirth,
+				AvatarURL:   "https://cdn.example.com/avatar.png",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Failure case due to phone of another country",
+			userID:    1,
+			pathParam: "1",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1, CallingCodes: []string{"+91"}}, nil)
+			},
+			reqBody: models.User{
+				ID:        1,
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Phone:     "+14155552671",
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "Failure case due to unknown country",
+			userID:    1,
+			pathParam: "1",
+			expMock: func() {
+				countryModel.EXPECT().GetByID(9).Return(nil, gorm.ErrRecordNotFound)
+			},
+			reqBody: models.User{
+				ID:        1,
+				Name:      "Test User",
+				CountryID: 9,
+				Email:     "test@gmail.com",
+			},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Failure case due to invalid phone",
+			userID:    1,
+			pathParam: "1",
+			expMock:   func() {},
+			reqBody: models.User{
+				ID:        1,
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Phone:     "415-555-2671",
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "Success case with a matching If-Match",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"2"`,
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+					Version:   2,
+				}, nil)
+				userModel.EXPECT().Update(gomock.Any()).DoAndReturn(func(user *models.User) error {
+					if user.Version != 2 {
+						t.Errorf("userController.Update() must update from the version that was read")
+					}
+					return nil
+				})
+			},
+			reqBody: models.User{
+				Name:      "New Name",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Failure case due to a stale If-Match",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"1"`,
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+					Version:   2,
+				}, nil)
+			},
+			reqBody: models.User{
+				Name:      "New Name",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:      "Failure case due to a concurrent update",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"2"`,
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+					Version:   2,
+				}, nil)
+				userModel.EXPECT().Update(gomock.Any()).Return(&models.VersionConflictError{UserID: 1, ExpectedVersion: 2, CurrentVersion: 3})
+			},
+			reqBody: models.User{
+				Name:      "New Name",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:      "Failure case due to email of a user deleted within the grace period",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"2"`,
+			expMock: func() {
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{
+					ID:        1,
+					Name:      "Test User",
+					CountryID: 1,
+					Email:     "test@gmail.com",
+					Version:   2,
+				}, nil)
+				userModel.EXPECT().Update(gomock.Any()).Return(models.ErrEmailOfDeletedUser)
+			},
+			reqBody: models.User{
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "deleted@gmail.com",
+			},
+			wantCode: http.StatusConflict,
+		},
 		{
 			name:      "Failure case due to request body",
 			userID:    1,
@@ -308,13 +1097,16 @@ func Test_userController_Update(t *testing.T) {
 				URL:    &url.URL{},
 			}
 			ctx.Request.Method = "PUT"
+			if tt.ifMatch != "" {
+				ctx.Request.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
 
 			jsonbytes, _ := json.Marshal(tt.reqBody)
 			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
 
-			uH := NewUserController(userModel)
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
 
 			uH.Update(ctx)
 
@@ -328,11 +1120,20 @@ func Test_userController_Update(t *testing.T) {
 func Test_userController_Delete(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
 
 	tests := []struct {
 		name      string
 		userID    int
 		pathParam string
+		ifMatch   string
 		expMock   func()
 		wantCode  int
 	}{
@@ -342,6 +1143,19 @@ func Test_userController_Delete(t *testing.T) {
 			pathParam: "1",
 			expMock: func() {
 				userModel.EXPECT().Delete(1).Return(nil)
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:      "Success case still revoking the sessions when the access tokens cannot be revoked",
+			userID:    1,
+			pathParam: "1",
+			expMock: func() {
+				userModel.EXPECT().Delete(1).Return(nil)
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(sql.ErrConnDone)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
 			},
 			wantCode: http.StatusNoContent,
 		},
@@ -354,6 +1168,50 @@ func Test_userController_Delete(t *testing.T) {
 			},
 			wantCode: http.StatusInternalServerError,
 		},
+		{
+			name:      "Success case with a matching If-Match",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `W/"4", "5"`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Version: 5}, nil)
+				userModel.EXPECT().DeleteIfMatch(1, 5).Return(nil)
+				revocationModel.EXPECT().RevokeAll(1, gomock.Any(), "").Return(nil)
+				refreshTokenModel.EXPECT().RevokeByUser(1).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:      "Failure case due to a stale If-Match",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"4"`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Version: 5}, nil)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:      "Failure case due to a concurrent update",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   "*",
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, Version: 5}, nil)
+				userModel.EXPECT().DeleteIfMatch(1, 5).Return(&models.VersionConflictError{UserID: 1, ExpectedVersion: 5, CurrentVersion: 6})
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:      "Failure case due to user not found with If-Match",
+			userID:    1,
+			pathParam: "1",
+			ifMatch:   `"5"`,
+			expMock: func() {
+				userModel.EXPECT().GetByID(1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -367,10 +1225,13 @@ func Test_userController_Delete(t *testing.T) {
 				URL:    &url.URL{},
 			}
 			ctx.Request.Method = "DELETE"
+			if tt.ifMatch != "" {
+				ctx.Request.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
 
-			uH := NewUserController(userModel)
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
 
 			uH.Delete(ctx)
 
@@ -380,3 +1241,75 @@ func Test_userController_Delete(t *testing.T) {
 		})
 	}
 }
+
+// Test_userController_Restore runs unit tests on the method Restore
+func Test_userController_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userModel := models.NewMockUsers(ctrl)
+	countryModel := models.NewMockCountries(ctrl)
+	refreshTokenModel := models.NewMockRefreshTokens(ctrl)
+	revocationModel := models.NewMockRevocations(ctrl)
+	oneTimeTokenModel := models.NewMockOneTimeTokens(ctrl)
+	loginAttemptModel := models.NewMockLoginAttempts(ctrl)
+	mfaModel := models.NewMockMFAFactors(ctrl)
+	auditEventModel := models.NewMockAuditEvents(ctrl)
+	notifierMock := notifier.NewMockNotifier(ctrl)
+
+	tests := []struct {
+		name      string
+		pathParam string
+		expMock   func()
+		wantCode  int
+	}{
+		{
+			name:      "Success case",
+			pathParam: "1",
+			expMock: func() {
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(nil)
+				userModel.EXPECT().GetByID(1).Return(&models.User{ID: 1, CountryID: 1, Password: "hash", Version: 3}, nil)
+				countryModel.EXPECT().GetByID(1).Return(&models.Country{ID: 1}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Failure case due to user not deleted or past the grace period",
+			pathParam: "1",
+			expMock: func() {
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:      "Failure case due to model",
+			pathParam: "1",
+			expMock: func() {
+				userModel.EXPECT().Restore(1, gomock.Any()).Return(sql.ErrConnDone)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+			w := httptest.NewRecorder()
+			gin.SetMode(gin.TestMode)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = &http.Request{
+				Header: make(http.Header),
+				URL:    &url.URL{},
+			}
+			ctx.Request.Method = "POST"
+
+			ctx.Params = []gin.Param{{Key: "id", Value: tt.pathParam}}
+
+			uH := NewUserController(userModel, countryModel, refreshTokenModel, revocationModel, oneTimeTokenModel, loginAttemptModel, mfaModel, auditEventModel, notifierMock, testKeys)
+
+			uH.Restore(ctx)
+
+			if !reflect.DeepEqual(tt.wantCode, w.Code) {
+				t.Errorf("userController.Restore() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
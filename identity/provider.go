@@ -0,0 +1,303 @@
+// Package identity signs users in with their accounts at external OAuth2 identity providers such as Google and GitHub
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps the responses read from identity providers
+const maxResponseSize = 1 << 20
+
+// ErrExchange is returned when the identity provider rejects the authorization code
+var ErrExchange = errors.New("identity provider rejected the authorization code")
+
+// Profile of the user at the identity provider
+type Profile struct {
+	// Subject is the stable ID of the user at the provider, emails may change
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type IdentityProvider interface {
+	// Name returns the name of the provider used in the API paths and the linked identities
+	Name() string
+	// AuthCodeURL takes the state and the S256 PKCE code challenge and
+	// returns the page of the provider to send the user to
+	AuthCodeURL(state, codeChallenge string) string
+	// Exchange takes the code the provider redirected back with and the PKCE code verifier
+	// and returns the profile of the user along with ErrExchange or any other error
+	Exchange(ctx context.Context, code, codeVerifier string) (*Profile, error)
+}
+
+// Config of an identity provider, the endpoints default to those of the provider and are only set to test against a fake one
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the page of the login frontend the provider sends the user back to
+	RedirectURL string
+	AuthURL     string
+	TokenURL    string
+	// APIURL is the base URL of the API the profile is fetched from
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+// oauth2Client implements the authorization code flow shared by the providers
+type oauth2Client struct {
+	name   string
+	scopes []string
+	config Config
+}
+
+// newOAuth2Client function takes the name, the scopes, the configuration and the default endpoints of a provider and
+// returns the client with the endpoints that were not configured set to their defaults
+func newOAuth2Client(name string, scopes []string, config Config, defaults Config) oauth2Client {
+	if config.AuthURL == "" {
+		config.AuthURL = defaults.AuthURL
+	}
+
+	if config.TokenURL == "" {
+		config.TokenURL = defaults.TokenURL
+	}
+
+	if config.APIURL == "" {
+		config.APIURL = defaults.APIURL
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return oauth2Client{name: name, scopes: scopes, config: config}
+}
+
+// Name method returns the name of the provider
+func (c *oauth2Client) Name() string {
+	return c.name
+}
+
+// AuthCodeURL method takes the state and the S256 PKCE code challenge and
+// returns the authorization endpoint of the provider with the request in its query
+func (c *oauth2Client) AuthCodeURL(state, codeChallenge string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(c.config.AuthURL, "?") {
+		separator = "&"
+	}
+
+	return c.config.AuthURL + separator + params.Encode()
+}
+
+// exchangeToken method takes a context, the authorization code and the PKCE code verifier
+// exchanges them at the token endpoint of the provider and
+// returns the access token along with ErrExchange or any other error
+func (c *oauth2Client) exchangeToken(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"client_id":     {c.config.ClientID},
+		"client_secret": {c.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub answers with a form encoded body unless JSON is asked for
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("%s token endpoint responded %d: %w", c.name, res.StatusCode, err)
+	}
+
+	// GitHub reports a rejected code with a 200 status
+	if token.Error != "" {
+		return "", fmt.Errorf("%w: %s %s", ErrExchange, token.Error, token.ErrorDescription)
+	}
+
+	if res.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("%s token endpoint responded %d without an access token", c.name, res.StatusCode)
+	}
+
+	return token.AccessToken, nil
+}
+
+// getJSON method takes a context, a path of the API of the provider, the access token and a value
+// fetches the path and decodes the response into the value and returns an error if any
+func (c *oauth2Client) getJSON(ctx context.Context, path, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.config.APIURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s API %s responded %d", c.name, path, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(v)
+}
+
+type googleProvider struct {
+	oauth2Client
+}
+
+// NewGoogleProvider takes the configuration of an OAuth client registered at Google and
+// returns an IdentityProvider signing users in with their Google accounts
+func NewGoogleProvider(config Config) IdentityProvider {
+	return &googleProvider{
+		oauth2Client: newOAuth2Client("google", []string{"openid", "email", "profile"}, config, Config{
+			AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+			APIURL:   "https://openidconnect.googleapis.com",
+		}),
+	}
+}
+
+// Exchange method takes a context, the authorization code and the PKCE code verifier
+// exchanges the code for an access token and fetches the OpenID Connect userinfo with it
+// and returns the profile of the user along with an error if any
+func (g *googleProvider) Exchange(ctx context.Context, code, codeVerifier string) (*Profile, error) {
+	accessToken, err := g.exchangeToken(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var userInfo struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+
+	if err := g.getJSON(ctx, "/v1/userinfo", accessToken, &userInfo); err != nil {
+		return nil, err
+	}
+
+	if userInfo.Subject == "" {
+		return nil, errors.New("google userinfo has no sub")
+	}
+
+	return &Profile{
+		Subject:       userInfo.Subject,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		Name:          userInfo.Name,
+	}, nil
+}
+
+type gitHubProvider struct {
+	oauth2Client
+}
+
+// NewGitHubProvider takes the configuration of an OAuth app registered at GitHub and
+// returns an IdentityProvider signing users in with their GitHub accounts
+func NewGitHubProvider(config Config) IdentityProvider {
+	return &gitHubProvider{
+		oauth2Client: newOAuth2Client("github", []string{"read:user", "user:email"}, config, Config{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+			APIURL:   "https://api.github.com",
+		}),
+	}
+}
+
+// Exchange method takes a context, the authorization code and the PKCE code verifier
+// exchanges the code for an access token and fetches the user and its primary email with it
+// and returns the profile of the user along with an error if any
+func (g *gitHubProvider) Exchange(ctx context.Context, code, codeVerifier string) (*Profile, error) {
+	accessToken, err := g.exchangeToken(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+
+	if err := g.getJSON(ctx, "/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+
+	if user.ID == 0 {
+		return nil, errors.New("github user has no id")
+	}
+
+	profile := &Profile{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    user.Name,
+	}
+
+	if profile.Name == "" {
+		profile.Name = user.Login
+	}
+
+	// The public email of the user profile may be missing or unverified, the primary email is always present
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+
+	if err := g.getJSON(ctx, "/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return profile, nil
+}
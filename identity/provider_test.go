@@ -0,0 +1,142 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// fakeProvider function starts a local identity provider which accepts the code "valid" with the code verifier "verifier"
+// and answers the Google userinfo and the GitHub user APIs for the access token it issues
+func fakeProvider(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("client_secret") != "secret" ||
+			r.PostForm.Get("code") != "valid" || r.PostForm.Get("code_verifier") != "verifier" {
+			// Rejected codes are answered like GitHub does, with a 200 status
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "token_type": "bearer"})
+	})
+
+	api := func(response interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+		}
+	}
+
+	mux.HandleFunc("/v1/userinfo", api(map[string]interface{}{
+		"sub": "108", "email": "jane@example.com", "email_verified": true, "name": "Jane Doe",
+	}))
+	mux.HandleFunc("/user", api(map[string]interface{}{"id": 42, "login": "jane", "name": ""}))
+	mux.HandleFunc("/user/emails", api([]map[string]interface{}{
+		{"email": "jane@users.noreply.github.com", "primary": false, "verified": true},
+		{"email": "jane@example.com", "primary": true, "verified": false},
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Test_IdentityProvider_Exchange runs unit tests on the Exchange method of the providers
+func Test_IdentityProvider_Exchange(t *testing.T) {
+	server := fakeProvider(t)
+	config := Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/auth/callback",
+		AuthURL:      server.URL + "/authorize",
+		TokenURL:     server.URL + "/token",
+		APIURL:       server.URL,
+	}
+
+	tests := []struct {
+		name     string
+		provider IdentityProvider
+		code     string
+		want     *Profile
+		wantErr  error
+	}{
+		{
+			name:     "Success case with Google",
+			provider: NewGoogleProvider(config),
+			code:     "valid",
+			want:     &Profile{Subject: "108", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"},
+		},
+		{
+			name:     "Success case with GitHub using the login as name and the unverified primary email",
+			provider: NewGitHubProvider(config),
+			code:     "valid",
+			want:     &Profile{Subject: "42", Email: "jane@example.com", EmailVerified: false, Name: "jane"},
+		},
+		{
+			name:     "Failure case due to code rejected by the provider",
+			provider: NewGoogleProvider(config),
+			code:     "reused",
+			wantErr:  ErrExchange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Exchange(context.Background(), tt.code, "verifier")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s Exchange() error = %v, wantErr %v", tt.provider.Name(), err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s Exchange() = %+v, want %+v", tt.provider.Name(), got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_oauth2Client_AuthCodeURL runs unit tests on the method AuthCodeURL
+func Test_oauth2Client_AuthCodeURL(t *testing.T) {
+	provider := NewGitHubProvider(Config{ClientID: "client", RedirectURL: "http://localhost:3000/auth/callback"})
+
+	uri, err := url.Parse(provider.AuthCodeURL("state", "challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := uri.Scheme + "://" + uri.Host + uri.Path; got != "https://github.com/login/oauth/authorize" {
+		t.Errorf("AuthCodeURL() endpoint = %v, want the GitHub authorization endpoint", got)
+	}
+
+	want := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"client"},
+		"redirect_uri":          {"http://localhost:3000/auth/callback"},
+		"scope":                 {"read:user user:email"},
+		"state":                 {"state"},
+		"code_challenge":        {"challenge"},
+		"code_challenge_method": {"S256"},
+	}
+
+	if got := uri.Query(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AuthCodeURL() query = %v, want %v", got, want)
+	}
+}
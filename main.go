@@ -1,19 +1,160 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/nehul-rangappa/gigawrks-user-service/controllers"
+	"github.com/nehul-rangappa/gigawrks-user-service/identity"
 	"github.com/nehul-rangappa/gigawrks-user-service/middleware"
 	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/notifier"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
+	"github.com/nehul-rangappa/gigawrks-user-service/storage"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// revocationCacheTTL reads REVOCATION_CACHE_TTL and
+// returns how long a token that is not revoked may be served from the in-memory cache
+func revocationCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("REVOCATION_CACHE_TTL"))
+	if err != nil || ttl < 0 {
+		return 30 * time.Second
+	}
+
+	return ttl
+}
+
+// trustedProxies reads TRUSTED_PROXIES and
+// returns the comma separated IPs or CIDRs of the proxies whose X-Forwarded-For header gives the client IP
+// None are trusted when it is not set, so that callers cannot spoof the IP failed logins are counted on
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+// accountPurgeInterval reads ACCOUNT_PURGE_INTERVAL and
+// returns how often the users past their deletion grace period are purged
+func accountPurgeInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ACCOUNT_PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+
+	return interval
+}
+
+// accountErasureMode reads ACCOUNT_ERASURE_MODE and
+// returns whether purged users are anonymized in place or deleted, the default
+func accountErasureMode() string {
+	if os.Getenv("ACCOUNT_ERASURE_MODE") == models.ErasureAnonymize {
+		return models.ErasureAnonymize
+	}
+
+	return models.ErasureDelete
+}
+
+// blobStoreDir reads BLOB_STORE_DIR and
+// returns the directory blobs such as avatars are kept in
+func blobStoreDir() string {
+	if dir := os.Getenv("BLOB_STORE_DIR"); dir != "" {
+		return dir
+	}
+
+	return "blobs"
+}
+
+// tokenKeys reads JWT_SIGNING_KEY_FILE, JWT_VERIFICATION_KEY_FILES, SECRET_KEY and JWT_LEGACY_HS256_UNTIL and
+// returns the key set access tokens are signed and verified with
+// Tokens are signed with SECRET_KEY (HS256) until a PEM encoded RSA or Ed25519 private key file is set,
+// after which SECRET_KEY only verifies tokens until the RFC 3339 time in JWT_LEGACY_HS256_UNTIL, and none when it is not set
+func tokenKeys() (*signing.KeySet, error) {
+	var verificationKeyFiles []string
+	if files := os.Getenv("JWT_VERIFICATION_KEY_FILES"); files != "" {
+		verificationKeyFiles = strings.Split(files, ",")
+	}
+
+	// An unset or invalid time leaves the zero time, so the secret verifies nothing
+	secretUntil, _ := time.Parse(time.RFC3339, os.Getenv("JWT_LEGACY_HS256_UNTIL"))
+
+	return signing.LoadKeySet(os.Getenv("JWT_SIGNING_KEY_FILE"), verificationKeyFiles, os.Getenv("SECRET_KEY"), secretUntil)
+}
+
+// identityProviders reads the client credentials of GOOGLE_CLIENT_ID and GITHUB_CLIENT_ID along with their secrets and
+// returns the identity providers users can sign in with, those without a client ID are disabled
+// Providers send users back to IDENTITY_REDIRECT_URL followed by the name of the provider
+func identityProviders() []identity.IdentityProvider {
+	redirectURL := strings.TrimSuffix(os.Getenv("IDENTITY_REDIRECT_URL"), "/")
+	constructors := map[string]func(identity.Config) identity.IdentityProvider{
+		"GOOGLE": identity.NewGoogleProvider,
+		"GITHUB": identity.NewGitHubProvider,
+	}
+
+	providers := make([]identity.IdentityProvider, 0, len(constructors))
+	for prefix, newProvider := range constructors {
+		clientID := os.Getenv(prefix + "_CLIENT_ID")
+		if clientID == "" {
+			continue
+		}
+
+		providers = append(providers, newProvider(identity.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv(prefix + "_CLIENT_SECRET"),
+			RedirectURL:  redirectURL + "/" + strings.ToLower(prefix),
+		}))
+	}
+
+	return providers
+}
+
+// purgeDeletedUsers erases, at start and then every interval,
+// the users deleted for longer than the deletion grace period along with their avatars
+// and deletes the data exports which can no longer be downloaded
+func purgeDeletedUsers(us models.Users, de models.DataExports, bs storage.BlobStore, interval time.Duration, mode string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		ctx := context.Background()
+
+		// Users erased before a failure are still returned so that their avatars are removed
+		purged, err := us.Purge(ctx, time.Now().Add(-controllers.DeletionGracePeriod()), mode)
+		if err != nil {
+			log.Printf("failed to purge deleted users: %v", err)
+		}
+
+		for _, userID := range purged {
+			if err := controllers.DeleteAvatar(ctx, bs, userID); err != nil {
+				log.Printf("failed to delete the avatar of erased user %d: %v", userID, err)
+			}
+		}
+
+		if len(purged) > 0 {
+			log.Printf("erased %d deleted users with mode %s", len(purged), mode)
+		}
+
+		expired, err := de.DeleteExpired(time.Now())
+		if err != nil {
+			log.Printf("failed to delete expired data exports: %v", err)
+		} else if expired > 0 {
+			log.Printf("deleted %d expired data exports", expired)
+		}
+	}
+}
+
 func main() {
 	// Load environment variables from config file
 	err := godotenv.Load()
@@ -41,24 +182,143 @@ func main() {
 
 	userStore := models.NewUserStore(db)
 	countryStore := models.NewCountryStore(db)
+	refreshTokenStore := models.NewRefreshTokenStore(db)
+	revocationStore := models.NewRevocationStore(db, revocationCacheTTL())
+	oneTimeTokenStore := models.NewOneTimeTokenStore(db)
+	loginAttemptStore := models.NewLoginAttemptStore(db)
+	mfaStore := models.NewMFAStore(db)
+	dataExportStore := models.NewDataExportStore(db)
+	addressStore := models.NewAddressStore(db)
+	oauthClientStore := models.NewOAuthClientStore(db)
+	authorizationCodeStore := models.NewAuthorizationCodeStore(db)
+	consentStore := models.NewConsentStore(db)
+	identityStore := models.NewIdentityStore(db)
+	auditEventStore := models.NewAuditEventStore(db)
+
+	// Notifications are written to NOTIFICATION_FILE, or logged when it is not set
+	notificationSender := notifier.NewFileNotifier(os.Getenv("NOTIFICATION_FILE"))
+
+	// Avatars are kept as files under BLOB_STORE_DIR, any S3-compatible BlobStore can replace it
+	blobStore := storage.NewLocalBlobStore(blobStoreDir())
+
+	// Access tokens are signed with the current key and verified with any key of the set during rotations
+	tokenKeySet, err := tokenKeys()
+	if err != nil {
+		log.Fatalf("Failed to load the token signing keys: %v", err)
+	}
 
-	userController := controllers.NewUserController(userStore)
+	userController := controllers.NewUserController(userStore, countryStore, refreshTokenStore, revocationStore, oneTimeTokenStore, loginAttemptStore, mfaStore, auditEventStore, notificationSender, tokenKeySet)
 	countryController := controllers.NewCountryController(countryStore)
+	tokenController := controllers.NewTokenController(userStore, refreshTokenStore, revocationStore, tokenKeySet)
+	passwordController := controllers.NewPasswordController(userStore, oneTimeTokenStore, refreshTokenStore, revocationStore, loginAttemptStore, auditEventStore, notificationSender, tokenKeySet)
+	emailController := controllers.NewEmailController(userStore, oneTimeTokenStore, notificationSender)
+	lockoutController := controllers.NewLockoutController(userStore, loginAttemptStore, auditEventStore)
+	roleController := controllers.NewRoleController(userStore, revocationStore, auditEventStore)
+	mfaController := controllers.NewMFAController(userStore, mfaStore, oneTimeTokenStore, refreshTokenStore, loginAttemptStore, auditEventStore, tokenKeySet)
+	exportController := controllers.NewExportController(userStore, countryStore, refreshTokenStore, loginAttemptStore, mfaStore, dataExportStore, addressStore, consentStore, identityStore, auditEventStore, blobStore)
+	avatarController := controllers.NewAvatarController(userStore, blobStore)
+	addressController := controllers.NewAddressController(userStore, countryStore, addressStore)
+	keyController := controllers.NewKeyController(tokenKeySet)
+	oidcController := controllers.NewOIDCController(userStore, oauthClientStore, authorizationCodeStore, consentStore, tokenKeySet)
+	oauthClientController := controllers.NewOAuthClientController(oauthClientStore)
+	introspectionController := controllers.NewIntrospectionController(userStore, oauthClientStore, refreshTokenStore, middleware.VerifyToken(tokenKeySet, revocationStore))
+	identityController := controllers.NewIdentityController(userStore, countryStore, identityStore, refreshTokenStore, oneTimeTokenStore, mfaStore, auditEventStore, identityProviders(), tokenKeySet)
+
+	// Deleted users are erased in the background once they can no longer be restored
+	go purgeDeletedUsers(userStore, dataExportStore, blobStore, accountPurgeInterval(), accountErasureMode())
 
 	// Initiate the app using GIN framework with default configuration
 	app := gin.Default()
+	if err := app.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Failed to set the trusted proxies: %v", err)
+	}
 
 	// User APIs
 	app.POST("/signup", userController.Signup)
 	app.POST("/login", userController.Login)
 
+	// Second login step exchanging the MFA challenge token and a TOTP or backup code for tokens
+	app.POST("/login/mfa", mfaController.Login)
+
+	// Social login APIs signing users in with their accounts at the identity providers
+	app.POST("/auth/:provider", identityController.Authorize)
+	app.POST("/auth/:provider/callback", identityController.Callback)
+
+	// Token API rotating the refresh token for a new access token
+	app.POST("/token/refresh", tokenController.Refresh)
+
+	// Public keys downstream services verify access tokens with
+	app.GET("/.well-known/jwks.json", keyController.JWKS)
+
+	// OpenID Connect APIs exchanging authorization codes for tokens issued to registered clients
+	app.GET("/.well-known/openid-configuration", oidcController.Discovery)
+	app.POST("/token", oidcController.Token)
+
+	// Token introspection API letting registered confidential clients validate tokens
+	app.POST("/oauth/introspect", introspectionController.Introspect)
+
+	// Password reset APIs
+	app.POST("/password/forgot", passwordController.Forgot)
+	app.POST("/password/reset", passwordController.Reset)
+
+	// Email verification API using the token sent to the user's email
+	app.POST("/email/verify", emailController.Verify)
+
+	// Routes listed in EMAIL_VERIFICATION_REQUIRED_ROUTES are blocked for users with an unverified email
+	verificationPolicy := middleware.NewVerificationPolicy(userStore, os.Getenv("EMAIL_VERIFICATION_REQUIRED_ROUTES"))
+
+	// auth only lets users reach their own :id while manage also admits roles permitted to read or manage other users
+	auth := middleware.Auth(tokenKeySet, revocationStore, verificationPolicy)
+	manage := middleware.AuthWithPermissions(tokenKeySet, revocationStore, verificationPolicy)
+	authenticate := middleware.Authenticate(tokenKeySet, revocationStore, verificationPolicy)
+
 	// Protected User APIs
-	app.GET("/users/:id", middleware.Auth(), userController.Get)
-	app.PUT("/users/:id", middleware.Auth(), userController.Update)
-	app.DELETE("/users/:id", middleware.Auth(), userController.Delete)
+	app.POST("/logout", authenticate, tokenController.Logout)
+	app.POST("/email/resend", authenticate, emailController.Resend)
+	app.GET("/users/:id", manage, userController.Get)
+	app.PUT("/users/:id", manage, userController.Update)
+	app.PATCH("/users/:id", manage, userController.Patch)
+	app.PUT("/users/:id/password", auth, passwordController.Change)
+	app.DELETE("/users/:id", manage, userController.Delete)
+	app.POST("/users/:id/restore", manage, userController.Restore)
+	app.POST("/users/:id/logout-all", manage, tokenController.LogoutAll)
+	app.POST("/users/:id/mfa/totp", auth, mfaController.Enroll)
+	app.POST("/users/:id/mfa/totp/confirm", auth, mfaController.Confirm)
+	app.DELETE("/users/:id/mfa/totp", auth, mfaController.Disable)
+	app.PUT("/users/:id/avatar", manage, avatarController.Upload)
+	app.GET("/users/:id/avatar", manage, avatarController.Get)
+	app.GET("/users/:id/addresses", manage, addressController.List)
+	app.POST("/users/:id/addresses", manage, addressController.Create)
+	app.GET("/users/:id/addresses/:addressID", manage, addressController.Get)
+	app.PUT("/users/:id/addresses/:addressID", manage, addressController.Update)
+	app.DELETE("/users/:id/addresses/:addressID", manage, addressController.Delete)
+	app.GET("/users/:id/export", manage, exportController.Start)
+	app.GET("/users/:id/exports/:exportID", manage, exportController.Status)
+	app.GET("/users/:id/exports/:exportID/archive", manage, exportController.Download)
+	app.GET("/users/:id/identities", manage, identityController.List)
+	app.POST("/users/:id/identities/:provider", auth, identityController.Link)
+	app.POST("/users/:id/identities/:provider/callback", auth, identityController.LinkCallback)
+	app.DELETE("/users/:id/identities/:identityID", auth, identityController.Unlink)
+
+	// OpenID Connect APIs the login page calls on behalf of the signed in user
+	app.GET("/authorize", authenticate, oidcController.Authorize)
+	app.POST("/authorize/consent", authenticate, oidcController.Consent)
+
+	// OpenID Connect userinfo API, only accepting access tokens issued to clients
+	authenticateClient := middleware.AuthenticateClient(tokenKeySet, revocationStore)
+	app.GET("/userinfo", authenticateClient, oidcController.UserInfo)
+	app.POST("/userinfo", authenticateClient, oidcController.UserInfo)
+
+	// Admin APIs
+	app.GET("/users", authenticate, middleware.RequireRole(models.RoleAdmin), userController.List)
+	app.PUT("/users/:id/role", authenticate, middleware.RequireRole(models.RoleAdmin), roleController.Update)
+	app.POST("/admin/login-lockouts/unlock", authenticate, middleware.RequireRole(models.RoleAdmin, models.RoleSupport), lockoutController.Unlock)
+	app.POST("/admin/oauth-clients", authenticate, middleware.RequireRole(models.RoleAdmin), oauthClientController.Create)
+	app.GET("/admin/oauth-clients", authenticate, middleware.RequireRole(models.RoleAdmin), oauthClientController.List)
+	app.DELETE("/admin/oauth-clients/:clientID", authenticate, middleware.RequireRole(models.RoleAdmin), oauthClientController.Delete)
 
-	// Rest Country API
-	app.GET("/rest-countries", countryController.GetMetaCountries)
+	// Rest Country API storing the countries, restricted to admins
+	app.GET("/rest-countries", authenticate, middleware.RequireRole(models.RoleAdmin), countryController.GetMetaCountries)
 
 	// Country API with filter support using query parameters id, code or name
 	app.GET("/countries", countryController.GetCountries)
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Test_trustedProxies runs unit tests on the function trustedProxies
+// checking the client IP failed logins are counted on with the engine configured as in main
+func Test_trustedProxies(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		wantIP         string
+	}{
+		{name: "Spoofed X-Forwarded-For ignored without trusted proxies", trustedProxies: "", wantIP: "203.0.113.7"},
+		{name: "Spoofed X-Forwarded-For ignored from an untrusted proxy", trustedProxies: "10.0.0.0/8", wantIP: "203.0.113.7"},
+		{name: "X-Forwarded-For used from a trusted proxy", trustedProxies: " 10.0.0.0/8, 203.0.113.7", wantIP: "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.trustedProxies)
+			gin.SetMode(gin.TestMode)
+
+			app := gin.New()
+			if err := app.SetTrustedProxies(trustedProxies()); err != nil {
+				t.Fatal(err)
+			}
+
+			app.GET("/ip", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.ClientIP())
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "203.0.113.7:4711"
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+			app.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.wantIP {
+				t.Errorf("client IP = %v, want %v", got, tt.wantIP)
+			}
+		})
+	}
+}
@@ -2,8 +2,8 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -11,52 +11,266 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/nehul-rangappa/gigawrks-user-service/controllers"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+	"github.com/nehul-rangappa/gigawrks-user-service/signing"
 )
 
-// verifyJWTToken takes a token and user ID
-// validates the authenticity of the token followed by
-// its validity based on expiration time and
-// returns an error in case of any encountered issues
-func verifyJWTToken(jwtToken string, id int) error {
-	secretKey := os.Getenv("SECRET_KEY")
-	token, err := jwt.Parse(jwtToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+// requiredClaims are the claims access tokens must carry besides exp, iss and aud which the validator requires
+var requiredClaims = []string{"sub", "iat", "nbf", "jti"}
+
+// verifyJWTToken takes the key set, a token and the audience it must be issued for
+// validates the authenticity of the token with the key named by its kid header,
+// whose algorithm is the only one accepted, followed by
+// its registered claims allowing a leeway of JWT_CLOCK_SKEW and
+// returns its claims along with an error in case of any encountered issues
+func verifyJWTToken(ks *signing.KeySet, jwtToken string, audience string) (jwt.MapClaims, error) {
+	// Claims are validated below as tokens issued before the registered claims were adopted lack them
+	token, err := jwt.Parse(jwtToken, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()), jwt.WithoutClaimsValidation())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return errors.New("invalid jwt token")
+		return nil, errors.New("invalid jwt token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return errors.New("invalid jwt token")
+		return nil, errors.New("invalid jwt token")
 	}
 
-	if jwtID, ok := claims["id"].(float64); ok {
-		if jwtID != float64(id) {
-			return errors.New("no authorization to this entity")
-		}
+	// Legacy tokens were only ever issued for the user APIs
+	now := time.Now()
+	if _, ok := claims["sub"]; !ok && audience == controllers.TokenAudience() && controllers.LegacyClaimsAccepted(now) {
+		return claims, verifyLegacyClaims(claims, now)
 	}
 
-	if expiry, ok := claims["expiry"].(float64); ok {
-		if expiry < float64(time.Now().Unix()) {
-			return errors.New("jwt token is expired")
+	return claims, verifyClaims(claims, audience)
+}
+
+// verifyClaims takes the claims of a token and the audience it must be issued for
+// validates its expiry, not before and issued at times, its issuer and audience and
+// returns an error when any of them or another required claim is missing or invalid
+func verifyClaims(claims jwt.MapClaims, audience string) error {
+	validator := jwt.NewValidator(
+		jwt.WithLeeway(controllers.TokenClockSkew()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(controllers.TokenIssuer()),
+		jwt.WithAudience(audience),
+	)
+	if err := validator.Validate(claims); err != nil {
+		return err
+	}
+
+	for _, claim := range requiredClaims {
+		if _, ok := claims[claim]; !ok {
+			return fmt.Errorf("jwt token is missing the %s claim", claim)
 		}
 	}
 
+	if _, ok := controllers.UserIDFromClaims(claims); !ok {
+		return errors.New("jwt token has an invalid sub claim")
+	}
+
+	if jti, ok := claims["jti"].(string); !ok || jti == "" {
+		return errors.New("jwt token has an invalid jti claim")
+	}
+
+	return nil
+}
+
+// verifyLegacyClaims takes the claims of a token issued before the registered claims were adopted and the current time
+// requires its former id and expiry claims, validates the expiry and
+// maps them to sub and exp so that the rest of the service only reads the registered claims
+func verifyLegacyClaims(claims jwt.MapClaims, now time.Time) error {
+	jwtID, ok := claims["id"].(float64)
+	if !ok || jwtID <= 0 {
+		return errors.New("jwt token is missing the id claim")
+	}
+
+	expiry, ok := claims["expiry"].(float64)
+	if !ok {
+		return errors.New("jwt token is missing the expiry claim")
+	}
+
+	if now.After(time.Unix(int64(expiry), 0).Add(controllers.TokenClockSkew())) {
+		return jwt.ErrTokenExpired
+	}
+
+	claims["sub"] = strconv.Itoa(int(jwtID))
+	claims["exp"] = expiry
+
 	return nil
 }
 
+// verifyNotRevoked takes the token claims and the revocation model
+// checks the token's jti, its session and the user wide cut-off and
+// returns an error if the token has been revoked
+func verifyNotRevoked(claims jwt.MapClaims, rs models.Revocations) error {
+	jti, _ := claims["jti"].(string)
+	sessionID, _ := claims["sid"].(string)
+
+	revoked, err := rs.IsRevoked(jti, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return controllers.ErrTokenRevoked
+	}
+
+	jwtID, ok := controllers.UserIDFromClaims(claims)
+	if !ok {
+		return nil
+	}
+
+	revokedBefore, keptSessionID, err := rs.RevokedBefore(jwtID)
+	if err != nil {
+		return err
+	}
+
+	// Tokens issued before the jti and iat claims existed can only be revoked by the cut-off
+	// iat is in whole seconds so the whole second of the cut-off is revoked, except the session started along with it
+	issuedAt, _ := claims["iat"].(float64)
+	kept := keptSessionID != "" && sessionID == keptSessionID
+	if !revokedBefore.IsZero() && int64(issuedAt) <= revokedBefore.Unix() && !kept {
+		return controllers.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// VerifyToken function takes the key set and the revocation model and
+// returns the verifier of the introspection endpoint, which checks the access tokens of the user APIs
+// and those issued to OpenID Connect clients like Authenticate and AuthenticateClient do
+func VerifyToken(ks *signing.KeySet, rs models.Revocations) controllers.TokenVerifier {
+	return func(jwtToken string) (jwt.MapClaims, error) {
+		var claims jwt.MapClaims
+		var err error
+		for _, audience := range []string{controllers.TokenAudience(), controllers.UserInfoAudience()} {
+			// A token issued for another audience is verified again with the next one
+			claims, err = verifyJWTToken(ks, jwtToken, audience)
+			if !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+				break
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", controllers.ErrTokenInvalid, err)
+		}
+
+		if err := verifyNotRevoked(claims, rs); err != nil {
+			return nil, err
+		}
+
+		return claims, nil
+	}
+}
+
+// authenticate takes a gin context, the key set, the revocation model, the verification policy and the token audience
+// reads the bearer token from the Authorization header, verifies it
+// and returns the claims, writing back the error response when it fails
+func authenticate(ctx *gin.Context, ks *signing.KeySet, rs models.Revocations, policy *VerificationPolicy, audience string) (jwt.MapClaims, bool) {
+	authHeaders := ctx.Request.Header["Authorization"]
+
+	if len(authHeaders) == 0 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("missing Authorization Headers").Error()})
+		return nil, false
+	}
+
+	authToken := strings.Split(authHeaders[0], " ")
+	if len(authToken) != 2 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("invalid Authorization Headers").Error()})
+		return nil, false
+	}
+
+	jwtToken := authToken[1]
+
+	claims, err := verifyJWTToken(ks, jwtToken, audience)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := verifyNotRevoked(claims, rs); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := policy.verify(ctx, claims); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	ctx.Set(controllers.ClaimsKey, claims)
+
+	return claims, true
+}
+
+// Authenticate function is a middleware to authorize users to
+// protected APIs which are not bound to a user in the path
+// It authorizes the user based on JWT token which must not be revoked
+// and applies the email verification policy of the route
+// returns the API Handler Function if no error else
+// writes back the response with the error message
+func Authenticate(ks *signing.KeySet, rs models.Revocations, policy *VerificationPolicy) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := authenticate(ctx, ks, rs, policy, controllers.TokenAudience()); !ok {
+			return
+		}
+
+		// Forwarding the request to API handler
+		ctx.Next()
+	}
+}
+
+// AuthenticateClient function is a middleware like Authenticate for the access tokens
+// issued to OpenID Connect clients, whose audience is the userinfo endpoint
+// so that they can never reach the user APIs, nor can user access tokens reach the userinfo endpoint
+func AuthenticateClient(ks *signing.KeySet, rs models.Revocations) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := authenticate(ctx, ks, rs, nil, controllers.UserInfoAudience()); !ok {
+			return
+		}
+
+		// Forwarding the request to API handler
+		ctx.Next()
+	}
+}
+
 // Auth function is a middleware to authorize users to
 // protected APIs before reaching the API handler
 // It validates the path parameter, authorizes the user
-// based on JWT token and verifies the ownership
+// based on JWT token, applies the email verification policy of the route
+// and verifies the ownership
 // returns the API Handler Function if no error else
 // writes back the response with the error message
-func Auth() gin.HandlerFunc {
+func Auth(ks *signing.KeySet, rs models.Revocations, policy *VerificationPolicy) gin.HandlerFunc {
+	return authorizeUser(ks, rs, policy, false)
+}
+
+// AuthWithPermissions function is a middleware like Auth which
+// additionally lets a user access other users when their role is granted
+// the permission to read (GET) or manage (any other method) users
+func AuthWithPermissions(ks *signing.KeySet, rs models.Revocations, policy *VerificationPolicy) gin.HandlerFunc {
+	return authorizeUser(ks, rs, policy, true)
+}
+
+// permissionFor function takes an HTTP method and
+// returns the permission needed to access another user's resources with it
+func permissionFor(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return models.PermissionReadUsers
+	}
+
+	return models.PermissionManageUsers
+}
+
+// authorizeUser function takes the key set, the revocation model, the verification policy and
+// whether role permissions may grant access to other users and
+// returns the middleware validating the path parameter and the ownership
+func authorizeUser(ks *signing.KeySet, rs models.Revocations, policy *VerificationPolicy, allowPermitted bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userID := ctx.Param("id")
 		if userID == "" {
@@ -70,24 +284,17 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		authHeaders := ctx.Request.Header["Authorization"]
-
-		if len(authHeaders) == 0 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("missing Authorization Headers").Error()})
-			return
-		}
-
-		authToken := strings.Split(authHeaders[0], " ")
-		if len(authToken) != 2 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("invalid Authorization Headers").Error()})
+		claims, ok := authenticate(ctx, ks, rs, policy, controllers.TokenAudience())
+		if !ok {
 			return
 		}
 
-		jwtToken := authToken[1]
-
-		if err := verifyJWTToken(jwtToken, id); err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
+		if jwtID, ok := controllers.UserIDFromClaims(claims); ok {
+			permitted := allowPermitted && models.HasPermission(roleFromClaims(claims), permissionFor(ctx.Request.Method))
+			if jwtID != id && !permitted {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("no authorization to this entity").Error()})
+				return
+			}
 		}
 
 		// Forwarding the request to API handler
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/nehul-rangappa/gigawrks-user-service/controllers"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+// Test_verifyNotRevoked runs unit tests on the function verifyNotRevoked
+func Test_verifyNotRevoked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	revocationModel := models.NewMockRevocations(ctrl)
+
+	// The cut-off of a logout of every session at 12:00:00.700, as RevokeAll stores it in whole seconds
+	revokedBefore := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	claims := func(issuedAt time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "1", "jti": "jti", "sid": "sid", "iat": float64(issuedAt.Unix())}
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		expMock func()
+		wantErr error
+	}{
+		{
+			name:   "Success case with a token issued after RevokeAll",
+			claims: claims(revokedBefore.Add(time.Minute)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(false, nil)
+				revocationModel.EXPECT().RevokedBefore(1).Return(revokedBefore, "", nil)
+			},
+		},
+		{
+			name:   "Success case with the session kept by RevokeAll issued in its second",
+			claims: claims(revokedBefore.Add(900 * time.Millisecond)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(false, nil)
+				revocationModel.EXPECT().RevokedBefore(1).Return(revokedBefore, "sid", nil)
+			},
+		},
+		{
+			name:   "Failure case due to a token issued in the same second before RevokeAll",
+			claims: claims(revokedBefore.Add(300 * time.Millisecond)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(false, nil)
+				revocationModel.EXPECT().RevokedBefore(1).Return(revokedBefore, "", nil)
+			},
+			wantErr: controllers.ErrTokenRevoked,
+		},
+		{
+			name:   "Failure case due to a token of another session issued in the same second as RevokeAll",
+			claims: claims(revokedBefore.Add(300 * time.Millisecond)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(false, nil)
+				revocationModel.EXPECT().RevokedBefore(1).Return(revokedBefore, "new", nil)
+			},
+			wantErr: controllers.ErrTokenRevoked,
+		},
+		{
+			name:   "Failure case due to a token issued before RevokeAll",
+			claims: claims(revokedBefore.Add(-time.Second)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(false, nil)
+				revocationModel.EXPECT().RevokedBefore(1).Return(revokedBefore, "", nil)
+			},
+			wantErr: controllers.ErrTokenRevoked,
+		},
+		{
+			name:   "Failure case due to a revoked session",
+			claims: claims(revokedBefore.Add(time.Minute)),
+			expMock: func() {
+				revocationModel.EXPECT().IsRevoked("jti", "sid").Return(true, nil)
+			},
+			wantErr: controllers.ErrTokenRevoked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expMock()
+
+			if err := verifyNotRevoked(tt.claims, revocationModel); err != tt.wantErr {
+				t.Errorf("verifyNotRevoked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nehul-rangappa/gigawrks-user-service/controllers"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+var errInsufficientRole = errors.New("your role has no access to this entity")
+
+// roleFromClaims takes the token claims and
+// returns the role of the user, tokens issued before roles existed belong to plain users
+func roleFromClaims(claims jwt.MapClaims) string {
+	role, _ := claims["role"].(string)
+	if role == "" {
+		return models.RoleUser
+	}
+
+	return role
+}
+
+// RequireRole function is a middleware to be chained after Authenticate or Auth
+// It allows the request only when the role in the JWT token is one of the given roles
+// returns the API Handler Function if no error else
+// writes back the response with the error message
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, ok := ctx.Get(controllers.ClaimsKey)
+		claims, isClaims := value.(jwt.MapClaims)
+		if !ok || !isClaims {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errors.New("missing Authorization Headers").Error()})
+			return
+		}
+
+		role := roleFromClaims(claims)
+		for _, allowed := range roles {
+			if role == allowed {
+				// Forwarding the request to API handler
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errInsufficientRole.Error()})
+	}
+}
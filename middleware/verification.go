@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nehul-rangappa/gigawrks-user-service/controllers"
+	"github.com/nehul-rangappa/gigawrks-user-service/models"
+)
+
+var errEmailNotVerified = errors.New("please verify your email to access this entity")
+
+// VerificationPolicy decides which routes are blocked for users
+// who have not verified their email yet
+type VerificationPolicy struct {
+	userStore models.Users
+	routes    map[string]bool
+}
+
+// NewVerificationPolicy takes the user model and a comma separated list of routes
+// in the form "METHOD /path/:param" as registered on the router and
+// returns a policy blocking unverified users from those routes
+func NewVerificationPolicy(us models.Users, routes string) *VerificationPolicy {
+	policy := &VerificationPolicy{
+		userStore: us,
+		routes:    make(map[string]bool),
+	}
+
+	for _, route := range strings.Split(routes, ",") {
+		fields := strings.Fields(route)
+		if len(fields) == 2 {
+			policy.routes[strings.ToUpper(fields[0])+" "+fields[1]] = true
+		}
+	}
+
+	return policy
+}
+
+// verify method takes a gin context and the token claims
+// looks up the user when the current route requires a verified email and
+// returns an error if the user's email is not verified
+func (p *VerificationPolicy) verify(ctx *gin.Context, claims jwt.MapClaims) error {
+	if p == nil || !p.routes[ctx.Request.Method+" "+ctx.FullPath()] {
+		return nil
+	}
+
+	userID, ok := controllers.UserIDFromClaims(claims)
+	if !ok {
+		return errEmailNotVerified
+	}
+
+	user, err := p.userStore.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return errEmailNotVerified
+	}
+
+	return nil
+}
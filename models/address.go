@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Types an address of a user can have
+const (
+	AddressHome     = "home"
+	AddressBilling  = "billing"
+	AddressShipping = "shipping"
+)
+
+// ValidAddressType function takes an address type and
+// returns whether it is one of the known types
+func ValidAddressType(addressType string) bool {
+	switch addressType {
+	case AddressHome, AddressBilling, AddressShipping:
+		return true
+	}
+
+	return false
+}
+
+// Address resource consisting of all the attributes defining a postal address of a user
+type Address struct {
+	ID          int       `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID      int       `json:"userID" gorm:"not null"`
+	Type        string    `json:"type" gorm:"not null"`
+	Recipient   string    `json:"recipient"`
+	Line1       string    `json:"line1" gorm:"not null"`
+	Line2       string    `json:"line2"`
+	City        string    `json:"city" gorm:"not null"`
+	Subdivision string    `json:"subdivision"`
+	PostalCode  string    `json:"postalCode"`
+	CountryID   int       `json:"countryID" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName overrides the table name of the Address resource
+func (Address) TableName() string {
+	return "user_addresses"
+}
+
+// addressColumns are the columns replaced by an update of an address
+var addressColumns = []string{"type", "recipient", "line1", "line2", "city", "subdivision", "postal_code", "country_id"}
+
+type addressStore struct {
+	DB *gorm.DB
+}
+
+func NewAddressStore(db *gorm.DB) Addresses {
+	return &addressStore{
+		DB: db,
+	}
+}
+
+// ListByUser method takes a user ID, fetches every address of the user
+// from the database, oldest first, and returns them along with an error if any
+func (a *addressStore) ListByUser(userID int) ([]Address, error) {
+	addresses := make([]Address, 0)
+	if err := a.DB.Where("user_id = ?", userID).Order("id").Find(&addresses); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return addresses, nil
+}
+
+// Get method takes a user ID and an address ID, fetches the address only if it belongs to the user
+// from the database and returns Address object along with an error if any
+func (a *addressStore) Get(userID int, addressID int) (*Address, error) {
+	var address Address
+	if err := a.DB.Where("id = ? AND user_id = ?", addressID, userID).First(&address); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &address, nil
+}
+
+// Create method takes an Address object
+// creates the address in the database
+// and returns an error if any
+func (a *addressStore) Create(address *Address) error {
+	if result := a.DB.Create(address); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Update method takes an Address object
+// replaces the attributes of the address of the same user in the database
+// and returns an error if any
+func (a *addressStore) Update(address *Address) error {
+	result := a.DB.Model(address).
+		Where("user_id = ?", address.UserID).
+		Select(addressColumns).
+		Updates(address)
+
+	return result.Error
+}
+
+// Delete method takes a user ID and an address ID
+// deletes the address of the user from the database and
+// returns gorm.ErrRecordNotFound when the user has no such address
+func (a *addressStore) Delete(userID int, addressID int) error {
+	result := a.DB.Where("user_id = ?", userID).Delete(&Address{}, addressID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_addressStore_ListByUser runs unit tests on the method ListByUser
+func Test_addressStore_ListByUser(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantLen int
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "type", "line1", "city", "postal_code", "country_id"}).
+					AddRow(1, 1, AddressHome, "1600 Amphitheatre Pkwy", "Mountain View", "94043", 1).
+					AddRow(2, 1, AddressShipping, "1 Infinite Loop", "Cupertino", "95014", 1)
+				mock.ExpectQuery("SELECT \\* FROM `user_addresses` WHERE user_id = \\? ORDER BY id").
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAddressStore(gormDB)
+
+			got, err := aS.ListByUser(1)
+			if err != tt.wantErr || len(got) != tt.wantLen {
+				t.Errorf("addressStore.ListByUser() = %v, %v, want %d addresses, %v", got, err, tt.wantLen, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_addressStore_Get runs unit tests on the method Get
+func Test_addressStore_Get(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "type", "line1", "city", "postal_code", "country_id"}).
+					AddRow(2, 1, AddressHome, "1600 Amphitheatre Pkwy", "Mountain View", "94043", 1)
+				mock.ExpectQuery("SELECT \\* FROM `user_addresses` WHERE id = \\? AND user_id = \\?").
+					WithArgs(2, 1, 1).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case due to address of another user",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT \\* FROM `user_addresses` WHERE id = \\? AND user_id = \\?").
+					WithArgs(2, 1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAddressStore(gormDB)
+
+			got, err := aS.Get(1, 2)
+			if err != tt.wantErr {
+				t.Errorf("addressStore.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && (got.ID != 2 || got.UserID != 1) {
+				t.Errorf("addressStore.Get() = %v", got)
+			}
+		})
+	}
+}
+
+// Test_addressStore_Update runs unit tests on the method Update
+func Test_addressStore_Update(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	address := &Address{
+		ID:          2,
+		UserID:      1,
+		Type:        AddressBilling,
+		Line1:       "1600 Amphitheatre Pkwy",
+		City:        "Mountain View",
+		Subdivision: "CA",
+		PostalCode:  "94043",
+		CountryID:   1,
+		CreatedAt:   time.Now(),
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `user_addresses` SET `type`=\\?,`recipient`=\\?,`line1`=\\?,`line2`=\\?,`city`=\\?,`subdivision`=\\?,`postal_code`=\\?,`country_id`=\\?,`updated_at`=\\? WHERE user_id = \\? AND `id` = \\?").
+					WithArgs(AddressBilling, "", "1600 Amphitheatre Pkwy", "", "Mountain View", "CA", "94043", 1, sqlmock.AnyArg(), 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `user_addresses`").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAddressStore(gormDB)
+
+			if err := aS.Update(address); err != tt.wantErr {
+				t.Errorf("addressStore.Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("addressStore.Update() %v", err)
+			}
+		})
+	}
+}
+
+// Test_addressStore_Delete runs unit tests on the method Delete
+func Test_addressStore_Delete(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `user_addresses` WHERE user_id = \\? AND `user_addresses`.`id` = \\?").
+					WithArgs(1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case due to address of another user",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `user_addresses`").
+					WithArgs(1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAddressStore(gormDB)
+
+			if err := aS.Delete(1, 2); err != tt.wantErr {
+				t.Errorf("addressStore.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("addressStore.Delete() %v", err)
+			}
+		})
+	}
+}
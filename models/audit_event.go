@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Types of the events recorded in the audit trail of a user
+const (
+	AuditLogin           = "login"
+	AuditRoleChanged     = "role_changed"
+	AuditAccountLocked   = "account_locked"
+	AuditAccountUnlocked = "account_unlocked"
+)
+
+// AuditEvent resource consisting of all the attributes recording something that happened to a user's account
+// Detail depends on the type, such as the login method, the new role or the end of a lock
+type AuditEvent struct {
+	ID        int       `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID    int       `json:"userID" gorm:"not null"`
+	Type      string    `json:"type" gorm:"not null"`
+	Detail    string    `json:"detail" gorm:"not null"`
+	IP        string    `json:"ip" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type auditEventStore struct {
+	DB *gorm.DB
+}
+
+func NewAuditEventStore(db *gorm.DB) AuditEvents {
+	return &auditEventStore{
+		DB: db,
+	}
+}
+
+// Create method takes an AuditEvent object
+// creates the event in the database and returns an error if any
+func (a *auditEventStore) Create(event *AuditEvent) error {
+	if result := a.DB.Create(event); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// ListByUser method takes a user ID, fetches every event recorded for the user
+// from the database, oldest first, and returns them along with an error if any
+func (a *auditEventStore) ListByUser(userID int) ([]AuditEvent, error) {
+	events := make([]AuditEvent, 0)
+	if err := a.DB.Where("user_id = ?", userID).Order("created_at, id").Find(&events); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return events, nil
+}
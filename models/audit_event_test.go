@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_auditEventStore_Create runs unit tests on the method Create
+func Test_auditEventStore_Create(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		event   *AuditEvent
+		mock    func()
+		wantErr error
+	}{
+		{
+			name:  "Success case",
+			event: &AuditEvent{UserID: 1, Type: AuditLogin, Detail: "password", IP: "10.0.0.1"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `audit_events`").
+					WithArgs(1, AuditLogin, "password", "10.0.0.1", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "Failure case",
+			event: &AuditEvent{UserID: 1, Type: AuditRoleChanged, Detail: RoleAdmin},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAuditEventStore(gormDB)
+
+			if err := aS.Create(tt.event); err != tt.wantErr {
+				t.Errorf("auditEventStore.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_auditEventStore_ListByUser runs unit tests on the method ListByUser
+func Test_auditEventStore_ListByUser(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantLen int
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "type", "detail", "ip"}).
+					AddRow(1, 1, AuditLogin, "password", "10.0.0.1").
+					AddRow(2, 1, AuditAccountLocked, "", "10.0.0.2")
+				mock.ExpectQuery("SELECT \\* FROM `audit_events` WHERE user_id = \\? ORDER BY created_at, id").
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+			wantLen: 2,
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAuditEventStore(gormDB)
+
+			got, err := aS.ListByUser(1)
+			if err != tt.wantErr || len(got) != tt.wantLen {
+				t.Errorf("auditEventStore.ListByUser() = %v, %v, want %d events, %v", got, err, tt.wantLen, tt.wantErr)
+			}
+		})
+	}
+}
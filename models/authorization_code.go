@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuthorizationCode resource consisting of all the attributes defining a single use OpenID Connect authorization code
+// Only the hash of the code is stored along with the PKCE challenge the code verifier must match
+type AuthorizationCode struct {
+	ID            int        `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	CodeHash      string     `json:"-" gorm:"unique, not null"`
+	ClientID      string     `json:"clientID" gorm:"not null"`
+	UserID        int        `json:"userID" gorm:"not null"`
+	RedirectURI   string     `json:"redirectURI" gorm:"not null"`
+	Scope         string     `json:"scope" gorm:"not null"`
+	Nonce         string     `json:"nonce"`
+	CodeChallenge string     `json:"-" gorm:"not null"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	UsedAt        *time.Time `json:"usedAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// TableName overrides the table name of the AuthorizationCode resource
+func (AuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+type authorizationCodeStore struct {
+	DB *gorm.DB
+}
+
+func NewAuthorizationCodeStore(db *gorm.DB) AuthorizationCodes {
+	return &authorizationCodeStore{
+		DB: db,
+	}
+}
+
+// GetByHash method takes a code hash, fetches the authorization code information
+// from the database and returns AuthorizationCode object along with an error if any
+func (a *authorizationCodeStore) GetByHash(codeHash string) (*AuthorizationCode, error) {
+	var code AuthorizationCode
+	if err := a.DB.Where("code_hash = ?", codeHash).First(&code); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &code, nil
+}
+
+// Create method takes an AuthorizationCode object
+// creates the authorization code information in the database
+// and returns an error if any
+func (a *authorizationCodeStore) Create(code *AuthorizationCode) error {
+	code.CreatedAt = time.Now()
+	if result := a.DB.Create(code); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Consume method takes an authorization code ID
+// marks the code as used if it was not used before and
+// returns whether this call consumed it along with an error if any
+func (a *authorizationCodeStore) Consume(codeID int) (bool, error) {
+	result := a.DB.Model(&AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", codeID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
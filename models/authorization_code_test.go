@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_authorizationCodeStore_Consume runs unit tests on the method Consume
+func Test_authorizationCodeStore_Consume(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		codeID  int
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name:   "Success case",
+			codeID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `oauth_authorization_codes` SET `used_at`=\\? WHERE id = \\? AND used_at IS NULL").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:   "Already used case",
+			codeID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name:   "Failure case",
+			codeID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			aS := NewAuthorizationCodeStore(gormDB)
+
+			got, err := aS.Consume(tt.codeID)
+			if err != tt.wantErr {
+				t.Errorf("authorizationCodeStore.Consume() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("authorizationCodeStore.Consume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
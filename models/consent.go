@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Consent resource consisting of all the attributes defining the scopes a user granted to an OpenID Connect client
+// Scope is space separated, a later consent to more scopes replaces it
+type Consent struct {
+	UserID    int       `json:"userID" gorm:"primaryKey, not null"`
+	ClientID  string    `json:"clientID" gorm:"primaryKey, not null"`
+	Scope     string    `json:"scope" gorm:"not null"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+// TableName overrides the table name of the Consent resource
+func (Consent) TableName() string {
+	return "oauth_consents"
+}
+
+type consentStore struct {
+	DB *gorm.DB
+}
+
+func NewConsentStore(db *gorm.DB) Consents {
+	return &consentStore{
+		DB: db,
+	}
+}
+
+// Get method takes a user ID and a client ID, fetches the consent
+// from the database and returns Consent object along with an error if any
+func (c *consentStore) Get(userID int, clientID string) (*Consent, error) {
+	var consent Consent
+	if err := c.DB.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &consent, nil
+}
+
+// ListByUser method takes a user ID, fetches every consent the user granted
+// from the database and returns them along with an error if any
+func (c *consentStore) ListByUser(userID int) ([]Consent, error) {
+	consents := make([]Consent, 0)
+	if err := c.DB.Where("user_id = ?", userID).Order("granted_at").Find(&consents); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return consents, nil
+}
+
+// Grant method takes a Consent object
+// creates the consent in the database, replacing the scope of an earlier one,
+// and returns an error if any
+func (c *consentStore) Grant(consent *Consent) error {
+	consent.GrantedAt = time.Now()
+
+	result := c.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "granted_at"}),
+	}).Create(consent)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_consentStore_Grant runs unit tests on the method Grant
+func Test_consentStore_Grant(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		consent *Consent
+		mock    func()
+		wantErr error
+	}{
+		{
+			name:    "Success case",
+			consent: &Consent{UserID: 1, ClientID: "client", Scope: "openid email"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `oauth_consents` .* ON DUPLICATE KEY UPDATE `scope`=VALUES\\(`scope`\\),`granted_at`=VALUES\\(`granted_at`\\)").
+					WithArgs(1, "client", "openid email", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "Failure case",
+			consent: &Consent{UserID: 1, ClientID: "client", Scope: "openid"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			cS := NewConsentStore(gormDB)
+
+			if err := cS.Grant(tt.consent); err != tt.wantErr {
+				t.Errorf("consentStore.Grant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
+
 	"gorm.io/gorm"
 )
 
@@ -10,9 +13,58 @@ type Country struct {
 	CommonName   string `json:"commonName" gorm:"not null"`
 	OfficialName string `json:"officialName"`
 	CountryCode  string `json:"countryCode" gorm:"unique, not null"`
+	Alpha3Code   string `json:"alpha3Code"`
 	Capital      string `json:"capital"`
 	Region       string `json:"region"`
 	SubRegion    string `json:"subregion"`
+	// CallingCodes are the international prefixes of the country's phone numbers such as +91
+	// or, where several countries share a code, the prefixes including the area code such as +1415
+	CallingCodes []string `json:"callingCodes" gorm:"serializer:json"`
+
+	// reference is the code or name a client identified the country with instead of its ID
+	reference string
+}
+
+// UnmarshalJSON method takes either a country object or a string holding
+// an ISO 3166-1 alpha-2 or alpha-3 code or a common name
+// which is kept as the reference to look the country up by
+func (c *Country) UnmarshalJSON(data []byte) error {
+	var reference string
+	if err := json.Unmarshal(data, &reference); err == nil {
+		*c = Country{reference: strings.TrimSpace(reference)}
+		return nil
+	}
+
+	// The alias drops this method so the object is decoded field by field
+	type country Country
+	return json.Unmarshal(data, (*country)(c))
+}
+
+// Reference method returns the code or name the country was identified with,
+// its country code when it was given as an object, or an empty string to look it up by ID
+func (c *Country) Reference() string {
+	if c.reference != "" {
+		return c.reference
+	}
+
+	return c.CountryCode
+}
+
+// MatchesCallingCode method takes a phone number in E.164 format and
+// reports whether it starts with one of the calling codes of the country
+// Countries ingested before their calling codes were known match any number
+func (c *Country) MatchesCallingCode(phone string) bool {
+	if len(c.CallingCodes) == 0 {
+		return true
+	}
+
+	for _, code := range c.CallingCodes {
+		if strings.HasPrefix(phone, code) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type countryStore struct {
@@ -48,18 +100,18 @@ func (c *countryStore) GetByID(id int) (*Country, error) {
 	return &country, nil
 }
 
-// GetByCode method takes a country code, fetches the country information
+// GetByCode method takes an ISO 3166-1 alpha-2 or alpha-3 country code, fetches the country information
 // from the database and returns Country object along with an error if any
 func (c *countryStore) GetByCode(countryCode string) (*Country, error) {
 	var country Country
-	if err := c.DB.Where("country_code", countryCode).First(&country); err.Error != nil {
+	if err := c.DB.Where("country_code = ? OR alpha3_code = ?", countryCode, countryCode).First(&country); err.Error != nil {
 		return nil, err.Error
 	}
 
 	return &country, nil
 }
 
-// GetByID method takes a id, fetches the country information
+// GetByName method takes a common name, fetches the country information
 // from the database and returns Country object along with an error if any
 func (c *countryStore) GetByName(name string) (*Country, error) {
 	var country Country
@@ -71,11 +123,13 @@ func (c *countryStore) GetByName(name string) (*Country, error) {
 }
 
 // Create method takes a slice of Country object
-// creates the user information in the database
-// and returns the user ID along with an error if any
+// creates the countries missing from the database, refreshes the alpha-3 and calling codes of the existing ones
+// and returns an error if any
 func (c *countryStore) Create(countries []Country) error {
 	for _, country := range countries {
-		result := c.DB.FirstOrCreate(&country, Country{CountryCode: country.CountryCode})
+		result := c.DB.Where(Country{CountryCode: country.CountryCode}).
+			Assign(Country{Alpha3Code: country.Alpha3Code, CallingCodes: country.CallingCodes}).
+			FirstOrCreate(&country)
 		if result.Error != nil {
 			return result.Error
 		}
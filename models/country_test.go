@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -114,8 +115,8 @@ func Test_countryStore_GetByID(t *testing.T) {
 			mock: func() {
 				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
 				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
-				rows := sqlmock.NewRows([]string{"id", "common_name", "official_name", "country_code", "capital", "region", "sub_region"}).
-					AddRow(1, "United States", "United States of America", "US", "DC", "America", "North America")
+				rows := sqlmock.NewRows([]string{"id", "common_name", "official_name", "country_code", "capital", "region", "sub_region", "calling_codes"}).
+					AddRow(1, "United States", "United States of America", "US", "DC", "America", "North America", `["+1201","+1415"]`)
 				mock.ExpectQuery("SELECT").WillReturnRows(rows)
 			},
 			want: &Country{
@@ -126,6 +127,7 @@ func Test_countryStore_GetByID(t *testing.T) {
 				Capital:      "DC",
 				Region:       "America",
 				SubRegion:    "North America",
+				CallingCodes: []string{"+1201", "+1415"},
 			},
 			wantErr: nil,
 		},
@@ -190,7 +192,9 @@ func Test_countryStore_GetByCode(t *testing.T) {
 				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
 				rows := sqlmock.NewRows([]string{"id", "common_name", "official_name", "country_code", "capital", "region", "sub_region"}).
 					AddRow(1, "United States", "United States of America", "US", "DC", "America", "North America")
-				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+				mock.ExpectQuery("SELECT \\* FROM `countries` WHERE country_code = \\? OR alpha3_code = \\?").
+					WithArgs("US", "US", 1).
+					WillReturnRows(rows)
 			},
 			want: &Country{
 				ID:           1,
@@ -314,3 +318,144 @@ func Test_countryStore_GetByName(t *testing.T) {
 		})
 	}
 }
+
+// Test_countryStore_Create runs unit tests on the method Create
+func Test_countryStore_Create(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	countryColumns := []string{"id", "common_name", "official_name", "country_code", "alpha3_code", "capital", "region", "sub_region", "calling_codes"}
+
+	tests := []struct {
+		name      string
+		countries []Country
+		mock      func()
+		wantErr   error
+	}{
+		{
+			name:      "Success case creating a country",
+			countries: []Country{{CommonName: "India", CountryCode: "IN", Alpha3Code: "IND", CallingCodes: []string{"+91"}}},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT \\* FROM `countries` WHERE `countries`.`country_code` = \\?").
+					WithArgs("IN", 1).
+					WillReturnRows(sqlmock.NewRows(countryColumns))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `countries`").
+					WithArgs("India", "", "IN", "IND", "", "", "", `["+91"]`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:      "Success case refreshing the codes of a country",
+			countries: []Country{{CommonName: "India", CountryCode: "IN", Alpha3Code: "IND", CallingCodes: []string{"+91"}}},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT \\* FROM `countries` WHERE `countries`.`country_code` = \\?").
+					WithArgs("IN", 1).
+					WillReturnRows(sqlmock.NewRows(countryColumns).AddRow(1, "India", "Republic of India", "IN", nil, "New Delhi", "Asia", "Southern Asia", nil))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `countries` SET `alpha3_code`=\\?,`calling_codes`=\\? WHERE `countries`.`country_code` = \\? AND `id` = \\?").
+					WithArgs("IND", `["+91"]`, "IN", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:      "Failure case",
+			countries: []Country{{CommonName: "India", CountryCode: "IN"}},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: sql.ErrConnDone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			cS := NewCountryStore(gormDB)
+
+			if err := cS.Create(tt.countries); err != tt.wantErr {
+				t.Errorf("countryStore.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("countryStore.Create() %v", err)
+			}
+		})
+	}
+}
+
+// TestCountry_UnmarshalJSON runs unit tests on the method UnmarshalJSON
+func TestCountry_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantReference string
+		wantID        int
+		wantErr       bool
+	}{
+		{name: "Alpha-2 code", data: `"de"`, wantReference: "de"},
+		{name: "Common name", data: `" Germany "`, wantReference: "Germany"},
+		{name: "Country object", data: `{"id": 5, "countryCode": "DE"}`, wantReference: "DE", wantID: 5},
+		{name: "Country object without code", data: `{"id": 5}`, wantID: 5},
+		{name: "Number", data: `5`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var country Country
+			err := json.Unmarshal([]byte(tt.data), &country)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Country.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && (country.Reference() != tt.wantReference || country.ID != tt.wantID) {
+				t.Errorf("Country.UnmarshalJSON() = %q, %d, want %q, %d", country.Reference(), country.ID, tt.wantReference, tt.wantID)
+			}
+		})
+	}
+}
+
+// TestCountry_MatchesCallingCode runs unit tests on the method MatchesCallingCode
+func TestCountry_MatchesCallingCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		country Country
+		phone   string
+		want    bool
+	}{
+		{name: "Matching calling code", country: Country{CallingCodes: []string{"+91"}}, phone: "+919876543210", want: true},
+		{name: "Matching area code", country: Country{CallingCodes: []string{"+1201", "+1415"}}, phone: "+14155552671", want: true},
+		{name: "Calling code of another country", country: Country{CallingCodes: []string{"+91"}}, phone: "+14155552671", want: false},
+		{name: "Area code of another country", country: Country{CallingCodes: []string{"+1204"}}, phone: "+14155552671", want: false},
+		{name: "Country without calling codes", country: Country{}, phone: "+14155552671", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.country.MatchesCallingCode(tt.phone); got != tt.want {
+				t.Errorf("Country.MatchesCallingCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// States a data export goes through
+const (
+	ExportPending   = "pending"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// DataExport resource consisting of all the attributes defining an export of a user's data
+// The archive is generated asynchronously and can be downloaded until it expires
+type DataExport struct {
+	ID          string     `json:"id" gorm:"primaryKey, not null"`
+	UserID      int        `json:"userID" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null"`
+	Error       string     `json:"error,omitempty"`
+	Archive     []byte     `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+}
+
+type dataExportStore struct {
+	DB *gorm.DB
+}
+
+func NewDataExportStore(db *gorm.DB) DataExports {
+	return &dataExportStore{
+		DB: db,
+	}
+}
+
+// Get method takes an export ID, fetches the export information without its archive
+// from the database and returns DataExport object along with an error if any
+func (d *dataExportStore) Get(exportID string) (*DataExport, error) {
+	var export DataExport
+	if err := d.DB.Omit("archive").Where("id = ?", exportID).First(&export); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &export, nil
+}
+
+// GetLatest method takes a user ID and the current time, fetches the latest export of the user
+// which is still pending or completed and not expired yet, without its archive, from the database
+// and returns DataExport object along with an error if any
+func (d *dataExportStore) GetLatest(userID int, now time.Time) (*DataExport, error) {
+	var export DataExport
+	if err := d.DB.Omit("archive").
+		Where("user_id = ? AND status IN ? AND expires_at > ?", userID, []string{ExportPending, ExportCompleted}, now).
+		Order("created_at DESC").
+		First(&export); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &export, nil
+}
+
+// GetArchive method takes an export ID, fetches the generated archive
+// from the database and returns it along with an error if any
+func (d *dataExportStore) GetArchive(exportID string) ([]byte, error) {
+	var export DataExport
+	if err := d.DB.Select("archive").Where("id = ? AND status = ?", exportID, ExportCompleted).First(&export); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return export.Archive, nil
+}
+
+// Create method takes a DataExport object
+// creates the export information in the database
+// and returns an error if any
+func (d *dataExportStore) Create(export *DataExport) error {
+	export.CreatedAt = time.Now()
+	if result := d.DB.Create(export); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Complete method takes an export ID and the generated archive
+// stores the archive of the pending export and marks it completed
+// and returns an error if any encountered
+func (d *dataExportStore) Complete(exportID string, archive []byte) error {
+	return d.finish(exportID, map[string]interface{}{
+		"status":       ExportCompleted,
+		"archive":      archive,
+		"completed_at": time.Now(),
+	})
+}
+
+// Fail method takes an export ID and the reason it failed
+// marks the pending export failed and
+// returns an error if any encountered
+func (d *dataExportStore) Fail(exportID string, reason string) error {
+	return d.finish(exportID, map[string]interface{}{
+		"status":       ExportFailed,
+		"error":        reason,
+		"completed_at": time.Now(),
+	})
+}
+
+// finish method takes an export ID and the columns to update
+// updates the export only while it is pending and
+// returns gorm.ErrRecordNotFound when there is no such pending export
+func (d *dataExportStore) finish(exportID string, columns map[string]interface{}) error {
+	result := d.DB.Model(&DataExport{}).
+		Where("id = ? AND status = ?", exportID, ExportPending).
+		Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired method takes the current time
+// deletes every export expired by then along with its archive from the database
+// and returns the number of deleted exports along with an error if any
+func (d *dataExportStore) DeleteExpired(now time.Time) (int64, error) {
+	result := d.DB.Where("expires_at <= ?", now).Delete(&DataExport{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_dataExportStore_Get runs unit tests on the method Get
+func Test_dataExportStore_Get(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "status", "created_at", "expires_at"}).
+					AddRow("export", 1, ExportPending, time.Now(), time.Now().Add(time.Hour))
+				mock.ExpectQuery("SELECT `data_exports`.`id`,`data_exports`.`user_id`,`data_exports`.`status`,`data_exports`.`error`,`data_exports`.`created_at`,`data_exports`.`completed_at`,`data_exports`.`expires_at` FROM `data_exports` WHERE id = \\?").
+					WithArgs("export", 1).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			dS := NewDataExportStore(gormDB)
+
+			got, err := dS.Get("export")
+			if err != tt.wantErr {
+				t.Errorf("dataExportStore.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && (got.ID != "export" || got.Status != ExportPending) {
+				t.Errorf("dataExportStore.Get() = %v", got)
+			}
+		})
+	}
+}
+
+// Test_dataExportStore_GetLatest runs unit tests on the method GetLatest
+func Test_dataExportStore_GetLatest(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "status", "created_at", "expires_at"}).
+					AddRow("export", 1, ExportPending, now, now.Add(time.Hour))
+				mock.ExpectQuery("SELECT `data_exports`.`id`,`data_exports`.`user_id`,`data_exports`.`status`,`data_exports`.`error`,`data_exports`.`created_at`,`data_exports`.`completed_at`,`data_exports`.`expires_at` FROM `data_exports` WHERE user_id = \\? AND status IN \\(\\?,\\?\\) AND expires_at > \\? ORDER BY created_at DESC").
+					WithArgs(1, ExportPending, ExportCompleted, now, 1).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			dS := NewDataExportStore(gormDB)
+
+			got, err := dS.GetLatest(1, now)
+			if err != tt.wantErr {
+				t.Errorf("dataExportStore.GetLatest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && (got.ID != "export" || got.Status != ExportPending) {
+				t.Errorf("dataExportStore.GetLatest() = %v", got)
+			}
+		})
+	}
+}
+
+// Test_dataExportStore_Complete runs unit tests on the method Complete
+func Test_dataExportStore_Complete(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `data_exports` SET `archive`=\\?,`completed_at`=\\?,`status`=\\? WHERE id = \\? AND status = \\?").
+					WithArgs([]byte("zip"), sqlmock.AnyArg(), ExportCompleted, "export", ExportPending).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not pending case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			dS := NewDataExportStore(gormDB)
+
+			if err := dS.Complete("export", []byte("zip")); err != tt.wantErr {
+				t.Errorf("dataExportStore.Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_dataExportStore_DeleteExpired runs unit tests on the method DeleteExpired
+func Test_dataExportStore_DeleteExpired(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `data_exports` WHERE expires_at <= \\?").
+					WithArgs(now).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    0,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			dS := NewDataExportStore(gormDB)
+
+			got, err := dS.DeleteExpired(now)
+			if err != tt.wantErr {
+				t.Errorf("dataExportStore.DeleteExpired() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("dataExportStore.DeleteExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
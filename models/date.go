@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of a Date in JSON
+const DateLayout = time.DateOnly
+
+// Date is a calendar date without a time of day
+// It is written as YYYY-MM-DD in JSON and stored in a DATE column
+type Date struct {
+	time.Time
+}
+
+// NewDate takes a year, month and day and returns the Date
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// MarshalJSON writes the date as a YYYY-MM-DD string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(DateLayout))
+}
+
+// UnmarshalJSON reads the date from a YYYY-MM-DD string
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	return d.parse(value)
+}
+
+// parse method reads the date from a YYYY-MM-DD string
+func (d *Date) parse(value string) error {
+	parsed, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return fmt.Errorf("date %q must be in YYYY-MM-DD format", value)
+	}
+
+	d.Time = parsed
+	return nil
+}
+
+// Value stores the date as a YYYY-MM-DD string
+func (d Date) Value() (driver.Value, error) {
+	return d.Format(DateLayout), nil
+}
+
+// Scan reads the date from a DATE column
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		d.Time = time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+	case []byte:
+		return d.parse(string(v))
+	case string:
+		return d.parse(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a date", value)
+	}
+
+	return nil
+}
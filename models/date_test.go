@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestDate_JSON runs unit tests on the JSON encoding of Date
+func TestDate_JSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Date
+		wantErr bool
+	}{
+		{name: "Success case", input: `"1990-05-17"`, want: NewDate(1990, time.May, 17), wantErr: false},
+		{name: "Failure case due to a time of day", input: `"1990-05-17T10:00:00Z"`, wantErr: true},
+		{name: "Failure case due to a number", input: `19900517`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Date
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Date.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !got.Equal(tt.want.Time) {
+				t.Errorf("Date.UnmarshalJSON() = %v, want %v", got, tt.want)
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil || string(encoded) != tt.input {
+				t.Errorf("Date.MarshalJSON() = %s, %v, want %s", encoded, err, tt.input)
+			}
+		})
+	}
+}
+
+// TestDate_Scan runs unit tests on the method Scan
+func TestDate_Scan(t *testing.T) {
+	want := NewDate(1990, time.May, 17)
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "Success case with a time", value: time.Date(1990, time.May, 17, 0, 0, 0, 0, time.Local), wantErr: false},
+		{name: "Success case with bytes", value: []byte("1990-05-17"), wantErr: false},
+		{name: "Success case with a string", value: "1990-05-17", wantErr: false},
+		{name: "Failure case due to an unsupported type", value: 19900517, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Date
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Date.Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && !got.Equal(want.Time) {
+				t.Errorf("Date.Scan() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Modes a user past its deletion grace period can be erased with
+const (
+	ErasureDelete    = "delete"
+	ErasureAnonymize = "anonymize"
+)
+
+// erasedName replaces the name of an anonymized user
+const erasedName = "Erased User"
+
+// anonymizedFields are the attributes of a user replaced or dropped by anonymization
+var anonymizedFields = []string{"name", "email", "password", "email_verified_at", "phone", "locale", "timezone", "date_of_birth", "avatar_url"}
+
+// ErasureCertificate resource consisting of all the attributes recording the erasure of a user
+// It holds no personal data so it is kept even when the user row is deleted
+type ErasureCertificate struct {
+	ID           int       `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID       int       `json:"userID" gorm:"not null"`
+	Mode         string    `json:"mode" gorm:"not null"`
+	ErasedFields string    `json:"erasedFields" gorm:"not null"`
+	DeletedAt    time.Time `json:"deletedAt"`
+	ErasedAt     time.Time `json:"erasedAt"`
+}
+
+// erasedEmail function returns a unique email tombstone
+// made of random bytes so that it cannot be traced back to the erased email
+func erasedEmail() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return "erased-" + hex.EncodeToString(buf) + "@erased.invalid", nil
+}
+
+// eraseUser function takes a transaction, a deleted User and an erasure mode
+// deletes the user, or anonymizes it in place while keeping its ID and dropping its personal records,
+// clears its failed logins and records an erasure certificate
+// returns an error if any encountered
+func eraseUser(tx *gorm.DB, user *User, mode string) error {
+	now := time.Now()
+	certificate := &ErasureCertificate{
+		UserID:    user.ID,
+		Mode:      mode,
+		DeletedAt: user.DeletedAt.Time,
+		ErasedAt:  now,
+	}
+
+	// Failed logins are keyed by the email rather than the user
+	if err := tx.Where("attempt_key = ?", "account:"+strings.ToLower(user.Email)).Delete(&LoginAttempt{}).Error; err != nil {
+		return err
+	}
+
+	if mode == ErasureAnonymize {
+		email, err := erasedEmail()
+		if err != nil {
+			return err
+		}
+
+		for _, record := range []interface{}{&DataExport{}, &Address{}, &OneTimeToken{}, &BackupCode{}, &TOTPFactor{}, &AuthorizationCode{}, &Consent{}, &Identity{}, &IdentityState{}, &AuditEvent{}} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"name":              erasedName,
+			"email":             email,
+			"password":          "",
+			"email_verified_at": nil,
+			"phone":             "",
+			"locale":            "",
+			"timezone":          "",
+			"date_of_birth":     nil,
+			"avatar_url":        "",
+			"erased_at":         now,
+		}).Error; err != nil {
+			return err
+		}
+
+		certificate.ErasedFields = strings.Join(anonymizedFields, ",")
+	} else {
+		if err := tx.Unscoped().Delete(&User{}, user.ID).Error; err != nil {
+			return err
+		}
+
+		certificate.Mode = ErasureDelete
+		certificate.ErasedFields = "*"
+	}
+
+	return tx.Create(certificate).Error
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Identity resource consisting of all the attributes defining an account at an external identity provider linked to a user
+// Subject is the stable ID of the account at the provider, Email is the one it had when it was linked
+type Identity struct {
+	ID        int       `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID    int       `json:"userID" gorm:"not null"`
+	Provider  string    `json:"provider" gorm:"not null"`
+	Subject   string    `json:"subject" gorm:"not null"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName overrides the table name of the Identity resource
+func (Identity) TableName() string {
+	return "user_identities"
+}
+
+// IdentityState resource consisting of all the attributes defining a single use sign in at an identity provider
+// Only the hash of the state is stored along with the PKCE code verifier the code is exchanged with,
+// UserID is set when the identity is linked to a signed in user
+type IdentityState struct {
+	ID           int        `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	StateHash    string     `json:"-" gorm:"unique, not null"`
+	Provider     string     `json:"provider" gorm:"not null"`
+	UserID       *int       `json:"userID"`
+	CodeVerifier string     `json:"-" gorm:"not null"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	UsedAt       *time.Time `json:"usedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// ErrIdentityLinked is returned when the account at the identity provider is already linked to a user
+var ErrIdentityLinked = errors.New("identity is already linked to a user")
+
+// mysqlDuplicateEntry is the MySQL error number of a unique key violation
+const mysqlDuplicateEntry = 1062
+
+type identityStore struct {
+	DB *gorm.DB
+}
+
+func NewIdentityStore(db *gorm.DB) Identities {
+	return &identityStore{
+		DB: db,
+	}
+}
+
+// Get method takes a provider and the subject of an account at the provider, fetches the identity
+// from the database and returns Identity object along with an error if any
+func (i *identityStore) Get(provider, subject string) (*Identity, error) {
+	var identity Identity
+	if err := i.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &identity, nil
+}
+
+// ListByUser method takes a user ID, fetches every identity linked to the user
+// from the database and returns them along with an error if any
+func (i *identityStore) ListByUser(userID int) ([]Identity, error) {
+	identities := make([]Identity, 0)
+	if err := i.DB.Where("user_id = ?", userID).Order("created_at, id").Find(&identities); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return identities, nil
+}
+
+// Create method takes an Identity object
+// links the identity to its user in the database
+// and returns ErrIdentityLinked when it is already linked or any other error encountered
+func (i *identityStore) Create(identity *Identity) error {
+	identity.CreatedAt = time.Now()
+	if result := i.DB.Create(identity); result.Error != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(result.Error, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return ErrIdentityLinked
+		}
+
+		return result.Error
+	}
+
+	return nil
+}
+
+// Delete method takes a user ID and an identity ID
+// unlinks the identity of the user in the database
+// and returns an error if any
+func (i *identityStore) Delete(userID int, identityID int) error {
+	result := i.DB.Where("user_id = ?", userID).Delete(&Identity{}, identityID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CreateState method takes an IdentityState object
+// creates the state in the database
+// and returns an error if any
+func (i *identityStore) CreateState(state *IdentityState) error {
+	state.CreatedAt = time.Now()
+	if result := i.DB.Create(state); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// ConsumeState method takes a state hash
+// marks the state as used if it was neither used before nor expired and
+// returns it along with gorm.ErrRecordNotFound when it was not consumed or any other error encountered
+func (i *identityStore) ConsumeState(stateHash string) (*IdentityState, error) {
+	now := time.Now()
+
+	result := i.DB.Model(&IdentityState{}).
+		Where("state_hash = ? AND used_at IS NULL AND expires_at > ?", stateHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var state IdentityState
+	if err := i.DB.Where("state_hash = ?", stateHash).First(&state); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_identityStore_Create runs unit tests on the method Create
+func Test_identityStore_Create(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `user_identities`").
+					WithArgs(1, "google", "108", "jane@example.com", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case due to identity linked to another user",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `user_identities`").
+					WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'google-108' for key 'identity_provider_subject_UNIQUE'"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrIdentityLinked,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `user_identities`").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			iS := NewIdentityStore(gormDB)
+
+			err = iS.Create(&Identity{UserID: 1, Provider: "google", Subject: "108", Email: "jane@example.com"})
+			if err != tt.wantErr {
+				t.Errorf("identityStore.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_identityStore_ConsumeState runs unit tests on the method ConsumeState
+func Test_identityStore_ConsumeState(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name         string
+		mock         func()
+		wantProvider string
+		wantErr      error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `identity_states` SET `used_at`=\\? WHERE state_hash = \\? AND used_at IS NULL AND expires_at > \\?").
+					WithArgs(sqlmock.AnyArg(), "hash", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				rows := sqlmock.NewRows([]string{"id", "state_hash", "provider", "code_verifier"}).AddRow(1, "hash", "google", "verifier")
+				mock.ExpectQuery("SELECT \\* FROM `identity_states` WHERE state_hash = \\?").
+					WithArgs("hash", 1).
+					WillReturnRows(rows)
+			},
+			wantProvider: "google",
+			wantErr:      nil,
+		},
+		{
+			name: "Already used or expired case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), "hash", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), "hash", sqlmock.AnyArg()).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			iS := NewIdentityStore(gormDB)
+
+			got, err := iS.ConsumeState("hash")
+			if err != tt.wantErr {
+				t.Errorf("identityStore.ConsumeState() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil && got.Provider != tt.wantProvider {
+				t.Errorf("identityStore.ConsumeState() provider = %v, want %v", got.Provider, tt.wantProvider)
+			}
+		})
+	}
+}
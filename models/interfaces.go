@@ -1,11 +1,26 @@
 package models
 
+import (
+	"context"
+	"time"
+)
+
 type Users interface {
 	GetByID(userID int) (*User, error)
 	GetByEmail(email string) (*User, error)
+	List(ctx context.Context, filter UserFilter) (*UserPage, error)
 	Create(user *User) (int, error)
 	Update(user *User) error
+	UpdateColumns(user *User, columns ...string) error
+	UpdatePassword(userID int, password string) error
+	MarkEmailVerified(userID int, email string) error
+	UpdateRole(userID int, role string) error
 	Delete(userID int) error
+	DeleteIfMatch(userID int, version int) error
+	GetDeletedByEmail(email string, deletedAfter time.Time) (*User, error)
+	GetDeletedByID(userID int, deletedAfter time.Time) (*User, error)
+	Restore(userID int, deletedAfter time.Time) error
+	Purge(ctx context.Context, deletedBefore time.Time, mode string) ([]int, error)
 }
 
 type Countries interface {
@@ -15,3 +30,93 @@ type Countries interface {
 	GetByName(name string) (*Country, error)
 	Create(countries []Country) error
 }
+
+type RefreshTokens interface {
+	GetByHash(tokenHash string) (*RefreshToken, error)
+	ListByUser(userID int) ([]RefreshToken, error)
+	Create(token *RefreshToken) error
+	Revoke(tokenID int) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeByUser(userID int) error
+}
+
+type Revocations interface {
+	Revoke(token *RevokedToken) error
+	IsRevoked(tokenIDs ...string) (bool, error)
+	RevokeAll(userID int, before time.Time, keptSessionID string) error
+	RevokedBefore(userID int) (time.Time, string, error)
+}
+
+type OneTimeTokens interface {
+	GetByHash(purpose, tokenHash string) (*OneTimeToken, error)
+	Create(token *OneTimeToken) error
+	Consume(tokenID int) (bool, error)
+	InvalidateByUser(userID int, purpose string) error
+}
+
+type LoginAttempts interface {
+	Get(key string) (*LoginAttempt, error)
+	RecordFailure(key string, at time.Time, window time.Duration) (*LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(keys ...string) error
+}
+
+type MFAFactors interface {
+	GetTOTP(userID int) (*TOTPFactor, error)
+	SaveTOTP(factor *TOTPFactor) error
+	ConfirmTOTP(userID int, step int64, codeHashes []string) error
+	UseTOTPStep(userID int, step int64) (bool, error)
+	UseBackupCode(userID int, codeHash string) (bool, error)
+	Disable(userID int) error
+}
+
+type DataExports interface {
+	Get(exportID string) (*DataExport, error)
+	GetLatest(userID int, now time.Time) (*DataExport, error)
+	GetArchive(exportID string) ([]byte, error)
+	Create(export *DataExport) error
+	Complete(exportID string, archive []byte) error
+	Fail(exportID string, reason string) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type Addresses interface {
+	ListByUser(userID int) ([]Address, error)
+	Get(userID int, addressID int) (*Address, error)
+	Create(address *Address) error
+	Update(address *Address) error
+	Delete(userID int, addressID int) error
+}
+
+type OAuthClients interface {
+	Get(clientID string) (*OAuthClient, error)
+	List() ([]OAuthClient, error)
+	Create(client *OAuthClient) error
+	Delete(clientID string) error
+}
+
+type AuthorizationCodes interface {
+	GetByHash(codeHash string) (*AuthorizationCode, error)
+	Create(code *AuthorizationCode) error
+	Consume(codeID int) (bool, error)
+}
+
+type Consents interface {
+	Get(userID int, clientID string) (*Consent, error)
+	ListByUser(userID int) ([]Consent, error)
+	Grant(consent *Consent) error
+}
+
+type Identities interface {
+	Get(provider, subject string) (*Identity, error)
+	ListByUser(userID int) ([]Identity, error)
+	Create(identity *Identity) error
+	Delete(userID int, identityID int) error
+	CreateState(state *IdentityState) error
+	ConsumeState(stateHash string) (*IdentityState, error)
+}
+
+type AuditEvents interface {
+	Create(event *AuditEvent) error
+	ListByUser(userID int) ([]AuditEvent, error)
+}
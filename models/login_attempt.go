@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttempt resource counting the consecutive failed logins of an account or a client IP
+// Key is prefixed by what it counts, e.g. "account:user@gmail.com" or "ip:127.0.0.1"
+type LoginAttempt struct {
+	Key          string     `json:"key" gorm:"column:attempt_key;primaryKey"`
+	Failures     int        `json:"failures" gorm:"not null"`
+	LastFailedAt time.Time  `json:"lastFailedAt"`
+	LockedUntil  *time.Time `json:"lockedUntil"`
+}
+
+type loginAttemptStore struct {
+	DB *gorm.DB
+}
+
+func NewLoginAttemptStore(db *gorm.DB) LoginAttempts {
+	return &loginAttemptStore{
+		DB: db,
+	}
+}
+
+// Get method takes a key, fetches the failed login attempts
+// from the database and returns LoginAttempt object along with an error if any
+func (l *loginAttemptStore) Get(key string) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	if err := l.DB.Where("attempt_key = ?", key).First(&attempt); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &attempt, nil
+}
+
+// RecordFailure method takes a key, the instant of the failed login and a window
+// atomically increments the failures, starting over once an earlier lock has expired
+// or the previous failure is older than the window,
+// and returns the updated LoginAttempt object along with an error if any
+func (l *loginAttemptStore) RecordFailure(key string, at time.Time, window time.Duration) (*LoginAttempt, error) {
+	attempt := LoginAttempt{Key: key, Failures: 1, LastFailedAt: at}
+
+	// MySQL assigns from left to right so failures has to be computed before the expired lock is cleared
+	result := l.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("IF(locked_until <= ? OR last_failed_at <= ?, 1, failures + 1)", at, at.Add(-window))},
+			{Column: clause.Column{Name: "locked_until"}, Value: gorm.Expr("IF(locked_until <= ?, NULL, locked_until)", at)},
+			{Column: clause.Column{Name: "last_failed_at"}, Value: at},
+		},
+	}).Create(&attempt)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return l.Get(key)
+}
+
+// Lock method takes a key and an instant
+// blocks logins for the key until that instant
+// and returns an error if any
+func (l *loginAttemptStore) Lock(key string, until time.Time) error {
+	result := l.DB.Model(&LoginAttempt{}).Where("attempt_key = ?", key).Update("locked_until", until)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Reset method takes keys
+// clears their failed login attempts along with any lock
+// and returns an error if any
+func (l *loginAttemptStore) Reset(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if result := l.DB.Where("attempt_key IN ?", keys).Delete(&LoginAttempt{}); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
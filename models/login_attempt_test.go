@@ -0,0 +1,208 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_loginAttemptStore_RecordFailure runs unit tests on the method RecordFailure
+func Test_loginAttemptStore_RecordFailure(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	at := time.Now()
+
+	tests := []struct {
+		name         string
+		mock         func()
+		wantFailures int
+		wantErr      error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `login_attempts` .* ON DUPLICATE KEY UPDATE `failures`=IF\\(locked_until <= \\? OR last_failed_at <= \\?, 1, failures \\+ 1\\),`locked_until`=IF\\(locked_until <= \\?, NULL, locked_until\\),`last_failed_at`=\\?").
+					WithArgs("account:test@gmail.com", 1, at, nil, at, at.Add(-15*time.Minute), at, at).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+				rows := sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at"}).
+					AddRow("account:test@gmail.com", 3, at)
+				mock.ExpectQuery("SELECT").WithArgs("account:test@gmail.com", 1).WillReturnRows(rows)
+			},
+			wantFailures: 3,
+			wantErr:      nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			lS := NewLoginAttemptStore(gormDB)
+
+			got, err := lS.RecordFailure("account:test@gmail.com", at, 15*time.Minute)
+			if err != tt.wantErr {
+				t.Errorf("loginAttemptStore.RecordFailure() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && got.Failures != tt.wantFailures {
+				t.Errorf("loginAttemptStore.RecordFailure() failures = %v, want %v", got.Failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+// Test_loginAttemptStore_Lock runs unit tests on the method Lock
+func Test_loginAttemptStore_Lock(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	until := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `login_attempts` SET `locked_until`=\\? WHERE attempt_key = \\?").
+					WithArgs(until, "ip:127.0.0.1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			lS := NewLoginAttemptStore(gormDB)
+
+			if err := lS.Lock("ip:127.0.0.1", until); err != tt.wantErr {
+				t.Errorf("loginAttemptStore.Lock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_loginAttemptStore_Reset runs unit tests on the method Reset
+func Test_loginAttemptStore_Reset(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		keys    []string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			keys: []string{"account:test@gmail.com", "ip:127.0.0.1"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `login_attempts` WHERE attempt_key IN \\(\\?,\\?\\)").
+					WithArgs("account:test@gmail.com", "ip:127.0.0.1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			keys: []string{"account:test@gmail.com"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			lS := NewLoginAttemptStore(gormDB)
+
+			if err := lS.Reset(tt.keys...); err != tt.wantErr {
+				t.Errorf("loginAttemptStore.Reset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
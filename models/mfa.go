@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TOTPFactor resource consisting of all the attributes defining a user's authenticator app
+// Secret is sealed by the controller and the factor is only enforced once ConfirmedAt is set
+// LastUsedStep is the last accepted time step so that a code cannot be replayed
+type TOTPFactor struct {
+	UserID       int        `json:"userID" gorm:"primaryKey, not null"`
+	Secret       string     `json:"-" gorm:"not null"`
+	ConfirmedAt  *time.Time `json:"confirmedAt"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// BackupCode resource consisting of all the attributes defining a single use MFA backup code
+// Only the hash of the code is stored
+type BackupCode struct {
+	ID        int        `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID    int        `json:"userID" gorm:"not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type mfaStore struct {
+	DB *gorm.DB
+}
+
+func NewMFAStore(db *gorm.DB) MFAFactors {
+	return &mfaStore{
+		DB: db,
+	}
+}
+
+// GetTOTP method takes a user ID, fetches the TOTP factor
+// from the database and returns TOTPFactor object along with an error if any
+func (m *mfaStore) GetTOTP(userID int) (*TOTPFactor, error) {
+	var factor TOTPFactor
+	if err := m.DB.Where("user_id = ?", userID).First(&factor); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &factor, nil
+}
+
+// SaveTOTP method takes a TOTPFactor object
+// creates the pending factor in the database, replacing a previous pending one,
+// and returns an error if any
+func (m *mfaStore) SaveTOTP(factor *TOTPFactor) error {
+	factor.CreatedAt = time.Now()
+	factor.ConfirmedAt = nil
+	factor.LastUsedStep = 0
+
+	result := m.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(factor)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// ConfirmTOTP method takes a user ID, the time step of the confirming code and the hashes of new backup codes
+// enables the pending factor and replaces the backup codes of the user in a single transaction
+// and returns an error if any
+func (m *mfaStore) ConfirmTOTP(userID int, step int64, codeHashes []string) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&TOTPFactor{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{"confirmed_at": now, "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&BackupCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]BackupCode, 0, len(codeHashes))
+		for _, codeHash := range codeHashes {
+			codes = append(codes, BackupCode{UserID: userID, CodeHash: codeHash, CreatedAt: now})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseTOTPStep method takes a user ID and the time step of a valid code
+// records the step only if it is later than the last accepted one and
+// returns whether the code was accepted along with an error if any
+func (m *mfaStore) UseTOTPStep(userID int, step int64) (bool, error) {
+	result := m.DB.Model(&TOTPFactor{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UseBackupCode method takes a user ID and the hash of a backup code
+// marks the code as used only if it was not used before and
+// returns whether the code was accepted along with an error if any
+func (m *mfaStore) UseBackupCode(userID int, codeHash string) (bool, error) {
+	result := m.DB.Model(&BackupCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Disable method takes a user ID
+// deletes the TOTP factor and the backup codes of the user in a single transaction
+// and returns an error if any
+func (m *mfaStore) Disable(userID int) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&BackupCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&TOTPFactor{}).Error
+	})
+}
@@ -0,0 +1,294 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_mfaStore_ConfirmTOTP runs unit tests on the method ConfirmTOTP
+func Test_mfaStore_ConfirmTOTP(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `totp_factors` SET `confirmed_at`=\\?,`last_used_step`=\\? WHERE user_id = \\? AND confirmed_at IS NULL").
+					WithArgs(sqlmock.AnyArg(), 55, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `backup_codes` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectExec("INSERT INTO `backup_codes`").
+					WithArgs(1, "hash1", nil, sqlmock.AnyArg(), 1, "hash2", nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not pending case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			mS := NewMFAStore(gormDB)
+
+			if err := mS.ConfirmTOTP(1, 55, []string{"hash1", "hash2"}); err != tt.wantErr {
+				t.Errorf("mfaStore.ConfirmTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_mfaStore_UseTOTPStep runs unit tests on the method UseTOTPStep
+func Test_mfaStore_UseTOTPStep(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "Accepted case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `totp_factors` SET `last_used_step`=\\? WHERE user_id = \\? AND confirmed_at IS NOT NULL AND last_used_step < \\?").
+					WithArgs(56, 1, 56).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name: "Replayed case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			mS := NewMFAStore(gormDB)
+
+			got, err := mS.UseTOTPStep(1, 56)
+			if err != tt.wantErr {
+				t.Errorf("mfaStore.UseTOTPStep() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("mfaStore.UseTOTPStep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_mfaStore_UseBackupCode runs unit tests on the method UseBackupCode
+func Test_mfaStore_UseBackupCode(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "Accepted case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `backup_codes` SET `used_at`=\\? WHERE user_id = \\? AND code_hash = \\? AND used_at IS NULL").
+					WithArgs(sqlmock.AnyArg(), 1, "hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name: "Already used case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want:    false,
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			mS := NewMFAStore(gormDB)
+
+			got, err := mS.UseBackupCode(1, "hash")
+			if err != tt.wantErr {
+				t.Errorf("mfaStore.UseBackupCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("mfaStore.UseBackupCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_mfaStore_Disable runs unit tests on the method Disable
+func Test_mfaStore_Disable(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `backup_codes` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectExec("DELETE FROM `totp_factors` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			mS := NewMFAStore(gormDB)
+
+			if err := mS.Disable(1); err != tt.wantErr {
+				t.Errorf("mfaStore.Disable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@
 package models
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -62,6 +64,20 @@ func (mr *MockUsersMockRecorder) Delete(userID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUsers)(nil).Delete), userID)
 }
 
+// DeleteIfMatch mocks base method.
+func (m *MockUsers) DeleteIfMatch(userID, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIfMatch", userID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIfMatch indicates an expected call of DeleteIfMatch.
+func (mr *MockUsersMockRecorder) DeleteIfMatch(userID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIfMatch", reflect.TypeOf((*MockUsers)(nil).DeleteIfMatch), userID, version)
+}
+
 // GetByEmail mocks base method.
 func (m *MockUsers) GetByEmail(email string) (*User, error) {
 	m.ctrl.T.Helper()
@@ -92,6 +108,94 @@ func (mr *MockUsersMockRecorder) GetByID(userID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUsers)(nil).GetByID), userID)
 }
 
+// GetDeletedByEmail mocks base method.
+func (m *MockUsers) GetDeletedByEmail(email string, deletedAfter time.Time) (*User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByEmail", email, deletedAfter)
+	ret0, _ := ret[0].(*User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByEmail indicates an expected call of GetDeletedByEmail.
+func (mr *MockUsersMockRecorder) GetDeletedByEmail(email, deletedAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByEmail", reflect.TypeOf((*MockUsers)(nil).GetDeletedByEmail), email, deletedAfter)
+}
+
+// GetDeletedByID mocks base method.
+func (m *MockUsers) GetDeletedByID(userID int, deletedAfter time.Time) (*User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByID", userID, deletedAfter)
+	ret0, _ := ret[0].(*User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByID indicates an expected call of GetDeletedByID.
+func (mr *MockUsersMockRecorder) GetDeletedByID(userID, deletedAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByID", reflect.TypeOf((*MockUsers)(nil).GetDeletedByID), userID, deletedAfter)
+}
+
+// List mocks base method.
+func (m *MockUsers) List(ctx context.Context, filter UserFilter) (*UserPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].(*UserPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockUsersMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUsers)(nil).List), ctx, filter)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUsers) MarkEmailVerified(userID int, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUsersMockRecorder) MarkEmailVerified(userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUsers)(nil).MarkEmailVerified), userID, email)
+}
+
+// Purge mocks base method.
+func (m *MockUsers) Purge(ctx context.Context, deletedBefore time.Time, mode string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, deletedBefore, mode)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUsersMockRecorder) Purge(ctx, deletedBefore, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUsers)(nil).Purge), ctx, deletedBefore, mode)
+}
+
+// Restore mocks base method.
+func (m *MockUsers) Restore(userID int, deletedAfter time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", userID, deletedAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUsersMockRecorder) Restore(userID, deletedAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUsers)(nil).Restore), userID, deletedAfter)
+}
+
 // Update mocks base method.
 func (m *MockUsers) Update(user *User) error {
 	m.ctrl.T.Helper()
@@ -106,6 +210,53 @@ func (mr *MockUsersMockRecorder) Update(user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUsers)(nil).Update), user)
 }
 
+// UpdateColumns mocks base method.
+func (m *MockUsers) UpdateColumns(user *User, columns ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{user}
+	for _, a := range columns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateColumns", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateColumns indicates an expected call of UpdateColumns.
+func (mr *MockUsersMockRecorder) UpdateColumns(user interface{}, columns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{user}, columns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateColumns", reflect.TypeOf((*MockUsers)(nil).UpdateColumns), varargs...)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUsers) UpdatePassword(userID int, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUsersMockRecorder) UpdatePassword(userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUsers)(nil).UpdatePassword), userID, password)
+}
+
+// UpdateRole mocks base method.
+func (m *MockUsers) UpdateRole(userID int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockUsersMockRecorder) UpdateRole(userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockUsers)(nil).UpdateRole), userID, role)
+}
+
 // MockCountries is a mock of Countries interface.
 type MockCountries struct {
 	ctrl     *gomock.Controller
@@ -202,3 +353,1072 @@ func (mr *MockCountriesMockRecorder) GetByName(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockCountries)(nil).GetByName), name)
 }
+
+// MockRefreshTokens is a mock of RefreshTokens interface.
+type MockRefreshTokens struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokensMockRecorder
+}
+
+// MockRefreshTokensMockRecorder is the mock recorder for MockRefreshTokens.
+type MockRefreshTokensMockRecorder struct {
+	mock *MockRefreshTokens
+}
+
+// NewMockRefreshTokens creates a new mock instance.
+func NewMockRefreshTokens(ctrl *gomock.Controller) *MockRefreshTokens {
+	mock := &MockRefreshTokens{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokensMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshTokens) EXPECT() *MockRefreshTokensMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefreshTokens) Create(token *RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefreshTokensMockRecorder) Create(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefreshTokens)(nil).Create), token)
+}
+
+// GetByHash mocks base method.
+func (m *MockRefreshTokens) GetByHash(tokenHash string) (*RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", tokenHash)
+	ret0, _ := ret[0].(*RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockRefreshTokensMockRecorder) GetByHash(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockRefreshTokens)(nil).GetByHash), tokenHash)
+}
+
+// ListByUser mocks base method.
+func (m *MockRefreshTokens) ListByUser(userID int) ([]RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", userID)
+	ret0, _ := ret[0].([]RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockRefreshTokensMockRecorder) ListByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockRefreshTokens)(nil).ListByUser), userID)
+}
+
+// Revoke mocks base method.
+func (m *MockRefreshTokens) Revoke(tokenID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", tokenID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRefreshTokensMockRecorder) Revoke(tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRefreshTokens)(nil).Revoke), tokenID)
+}
+
+// RevokeByUser mocks base method.
+func (m *MockRefreshTokens) RevokeByUser(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeByUser", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeByUser indicates an expected call of RevokeByUser.
+func (mr *MockRefreshTokensMockRecorder) RevokeByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeByUser", reflect.TypeOf((*MockRefreshTokens)(nil).RevokeByUser), userID)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockRefreshTokens) RevokeFamily(familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockRefreshTokensMockRecorder) RevokeFamily(familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockRefreshTokens)(nil).RevokeFamily), familyID)
+}
+
+// MockRevocations is a mock of Revocations interface.
+type MockRevocations struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevocationsMockRecorder
+}
+
+// MockRevocationsMockRecorder is the mock recorder for MockRevocations.
+type MockRevocationsMockRecorder struct {
+	mock *MockRevocations
+}
+
+// NewMockRevocations creates a new mock instance.
+func NewMockRevocations(ctrl *gomock.Controller) *MockRevocations {
+	mock := &MockRevocations{ctrl: ctrl}
+	mock.recorder = &MockRevocationsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevocations) EXPECT() *MockRevocationsMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockRevocations) IsRevoked(tokenIDs ...string) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range tokenIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "IsRevoked", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockRevocationsMockRecorder) IsRevoked(tokenIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockRevocations)(nil).IsRevoked), tokenIDs...)
+}
+
+// Revoke mocks base method.
+func (m *MockRevocations) Revoke(token *RevokedToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRevocationsMockRecorder) Revoke(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRevocations)(nil).Revoke), token)
+}
+
+// RevokeAll mocks base method.
+func (m *MockRevocations) RevokeAll(userID int, before time.Time, keptSessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAll", userID, before, keptSessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAll indicates an expected call of RevokeAll.
+func (mr *MockRevocationsMockRecorder) RevokeAll(userID, before, keptSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockRevocations)(nil).RevokeAll), userID, before, keptSessionID)
+}
+
+// RevokedBefore mocks base method.
+func (m *MockRevocations) RevokedBefore(userID int) (time.Time, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokedBefore", userID)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RevokedBefore indicates an expected call of RevokedBefore.
+func (mr *MockRevocationsMockRecorder) RevokedBefore(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokedBefore", reflect.TypeOf((*MockRevocations)(nil).RevokedBefore), userID)
+}
+
+// MockOneTimeTokens is a mock of OneTimeTokens interface.
+type MockOneTimeTokens struct {
+	ctrl     *gomock.Controller
+	recorder *MockOneTimeTokensMockRecorder
+}
+
+// MockOneTimeTokensMockRecorder is the mock recorder for MockOneTimeTokens.
+type MockOneTimeTokensMockRecorder struct {
+	mock *MockOneTimeTokens
+}
+
+// NewMockOneTimeTokens creates a new mock instance.
+func NewMockOneTimeTokens(ctrl *gomock.Controller) *MockOneTimeTokens {
+	mock := &MockOneTimeTokens{ctrl: ctrl}
+	mock.recorder = &MockOneTimeTokensMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOneTimeTokens) EXPECT() *MockOneTimeTokensMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockOneTimeTokens) Consume(tokenID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", tokenID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockOneTimeTokensMockRecorder) Consume(tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockOneTimeTokens)(nil).Consume), tokenID)
+}
+
+// Create mocks base method.
+func (m *MockOneTimeTokens) Create(token *OneTimeToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOneTimeTokensMockRecorder) Create(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOneTimeTokens)(nil).Create), token)
+}
+
+// GetByHash mocks base method.
+func (m *MockOneTimeTokens) GetByHash(purpose, tokenHash string) (*OneTimeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", purpose, tokenHash)
+	ret0, _ := ret[0].(*OneTimeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockOneTimeTokensMockRecorder) GetByHash(purpose, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockOneTimeTokens)(nil).GetByHash), purpose, tokenHash)
+}
+
+// InvalidateByUser mocks base method.
+func (m *MockOneTimeTokens) InvalidateByUser(userID int, purpose string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateByUser", userID, purpose)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateByUser indicates an expected call of InvalidateByUser.
+func (mr *MockOneTimeTokensMockRecorder) InvalidateByUser(userID, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateByUser", reflect.TypeOf((*MockOneTimeTokens)(nil).InvalidateByUser), userID, purpose)
+}
+
+// MockLoginAttempts is a mock of LoginAttempts interface.
+type MockLoginAttempts struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptsMockRecorder
+}
+
+// MockLoginAttemptsMockRecorder is the mock recorder for MockLoginAttempts.
+type MockLoginAttemptsMockRecorder struct {
+	mock *MockLoginAttempts
+}
+
+// NewMockLoginAttempts creates a new mock instance.
+func NewMockLoginAttempts(ctrl *gomock.Controller) *MockLoginAttempts {
+	mock := &MockLoginAttempts{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttempts) EXPECT() *MockLoginAttemptsMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockLoginAttempts) Get(key string) (*LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].(*LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLoginAttemptsMockRecorder) Get(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLoginAttempts)(nil).Get), key)
+}
+
+// Lock mocks base method.
+func (m *MockLoginAttempts) Lock(key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginAttemptsMockRecorder) Lock(key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginAttempts)(nil).Lock), key, until)
+}
+
+// RecordFailure mocks base method.
+func (m *MockLoginAttempts) RecordFailure(key string, at time.Time, window time.Duration) (*LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", key, at, window)
+	ret0, _ := ret[0].(*LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockLoginAttemptsMockRecorder) RecordFailure(key, at, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockLoginAttempts)(nil).RecordFailure), key, at, window)
+}
+
+// Reset mocks base method.
+func (m *MockLoginAttempts) Reset(keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Reset", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginAttemptsMockRecorder) Reset(keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginAttempts)(nil).Reset), keys...)
+}
+
+// MockMFAFactors is a mock of MFAFactors interface.
+type MockMFAFactors struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAFactorsMockRecorder
+}
+
+// MockMFAFactorsMockRecorder is the mock recorder for MockMFAFactors.
+type MockMFAFactorsMockRecorder struct {
+	mock *MockMFAFactors
+}
+
+// NewMockMFAFactors creates a new mock instance.
+func NewMockMFAFactors(ctrl *gomock.Controller) *MockMFAFactors {
+	mock := &MockMFAFactors{ctrl: ctrl}
+	mock.recorder = &MockMFAFactorsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAFactors) EXPECT() *MockMFAFactorsMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockMFAFactors) ConfirmTOTP(userID int, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockMFAFactorsMockRecorder) ConfirmTOTP(userID, step, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockMFAFactors)(nil).ConfirmTOTP), userID, step, codeHashes)
+}
+
+// Disable mocks base method.
+func (m *MockMFAFactors) Disable(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockMFAFactorsMockRecorder) Disable(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockMFAFactors)(nil).Disable), userID)
+}
+
+// GetTOTP mocks base method.
+func (m *MockMFAFactors) GetTOTP(userID int) (*TOTPFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", userID)
+	ret0, _ := ret[0].(*TOTPFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockMFAFactorsMockRecorder) GetTOTP(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockMFAFactors)(nil).GetTOTP), userID)
+}
+
+// SaveTOTP mocks base method.
+func (m *MockMFAFactors) SaveTOTP(factor *TOTPFactor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTP", factor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTOTP indicates an expected call of SaveTOTP.
+func (mr *MockMFAFactorsMockRecorder) SaveTOTP(factor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTP", reflect.TypeOf((*MockMFAFactors)(nil).SaveTOTP), factor)
+}
+
+// UseBackupCode mocks base method.
+func (m *MockMFAFactors) UseBackupCode(userID int, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseBackupCode", userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseBackupCode indicates an expected call of UseBackupCode.
+func (mr *MockMFAFactorsMockRecorder) UseBackupCode(userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseBackupCode", reflect.TypeOf((*MockMFAFactors)(nil).UseBackupCode), userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockMFAFactors) UseTOTPStep(userID int, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockMFAFactorsMockRecorder) UseTOTPStep(userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockMFAFactors)(nil).UseTOTPStep), userID, step)
+}
+
+// MockDataExports is a mock of DataExports interface.
+type MockDataExports struct {
+	ctrl     *gomock.Controller
+	recorder *MockDataExportsMockRecorder
+}
+
+// MockDataExportsMockRecorder is the mock recorder for MockDataExports.
+type MockDataExportsMockRecorder struct {
+	mock *MockDataExports
+}
+
+// NewMockDataExports creates a new mock instance.
+func NewMockDataExports(ctrl *gomock.Controller) *MockDataExports {
+	mock := &MockDataExports{ctrl: ctrl}
+	mock.recorder = &MockDataExportsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDataExports) EXPECT() *MockDataExportsMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockDataExports) Complete(exportID string, archive []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", exportID, archive)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockDataExportsMockRecorder) Complete(exportID, archive interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockDataExports)(nil).Complete), exportID, archive)
+}
+
+// Create mocks base method.
+func (m *MockDataExports) Create(export *DataExport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", export)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDataExportsMockRecorder) Create(export interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDataExports)(nil).Create), export)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockDataExports) DeleteExpired(now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockDataExportsMockRecorder) DeleteExpired(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockDataExports)(nil).DeleteExpired), now)
+}
+
+// Fail mocks base method.
+func (m *MockDataExports) Fail(exportID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", exportID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockDataExportsMockRecorder) Fail(exportID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockDataExports)(nil).Fail), exportID, reason)
+}
+
+// Get mocks base method.
+func (m *MockDataExports) Get(exportID string) (*DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", exportID)
+	ret0, _ := ret[0].(*DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDataExportsMockRecorder) Get(exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDataExports)(nil).Get), exportID)
+}
+
+// GetArchive mocks base method.
+func (m *MockDataExports) GetArchive(exportID string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchive", exportID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchive indicates an expected call of GetArchive.
+func (mr *MockDataExportsMockRecorder) GetArchive(exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchive", reflect.TypeOf((*MockDataExports)(nil).GetArchive), exportID)
+}
+
+// GetLatest mocks base method.
+func (m *MockDataExports) GetLatest(userID int, now time.Time) (*DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatest", userID, now)
+	ret0, _ := ret[0].(*DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatest indicates an expected call of GetLatest.
+func (mr *MockDataExportsMockRecorder) GetLatest(userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatest", reflect.TypeOf((*MockDataExports)(nil).GetLatest), userID, now)
+}
+
+// MockAddresses is a mock of Addresses interface.
+type MockAddresses struct {
+	ctrl     *gomock.Controller
+	recorder *MockAddressesMockRecorder
+}
+
+// MockAddressesMockRecorder is the mock recorder for MockAddresses.
+type MockAddressesMockRecorder struct {
+	mock *MockAddresses
+}
+
+// NewMockAddresses creates a new mock instance.
+func NewMockAddresses(ctrl *gomock.Controller) *MockAddresses {
+	mock := &MockAddresses{ctrl: ctrl}
+	mock.recorder = &MockAddressesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAddresses) EXPECT() *MockAddressesMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAddresses) Create(address *Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAddressesMockRecorder) Create(address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAddresses)(nil).Create), address)
+}
+
+// Delete mocks base method.
+func (m *MockAddresses) Delete(userID, addressID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userID, addressID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAddressesMockRecorder) Delete(userID, addressID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAddresses)(nil).Delete), userID, addressID)
+}
+
+// Get mocks base method.
+func (m *MockAddresses) Get(userID, addressID int) (*Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userID, addressID)
+	ret0, _ := ret[0].(*Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAddressesMockRecorder) Get(userID, addressID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAddresses)(nil).Get), userID, addressID)
+}
+
+// ListByUser mocks base method.
+func (m *MockAddresses) ListByUser(userID int) ([]Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", userID)
+	ret0, _ := ret[0].([]Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockAddressesMockRecorder) ListByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockAddresses)(nil).ListByUser), userID)
+}
+
+// Update mocks base method.
+func (m *MockAddresses) Update(address *Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAddressesMockRecorder) Update(address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAddresses)(nil).Update), address)
+}
+
+// MockOAuthClients is a mock of OAuthClients interface.
+type MockOAuthClients struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthClientsMockRecorder
+}
+
+// MockOAuthClientsMockRecorder is the mock recorder for MockOAuthClients.
+type MockOAuthClientsMockRecorder struct {
+	mock *MockOAuthClients
+}
+
+// NewMockOAuthClients creates a new mock instance.
+func NewMockOAuthClients(ctrl *gomock.Controller) *MockOAuthClients {
+	mock := &MockOAuthClients{ctrl: ctrl}
+	mock.recorder = &MockOAuthClientsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthClients) EXPECT() *MockOAuthClientsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOAuthClients) Create(client *OAuthClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOAuthClientsMockRecorder) Create(client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOAuthClients)(nil).Create), client)
+}
+
+// Delete mocks base method.
+func (m *MockOAuthClients) Delete(clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOAuthClientsMockRecorder) Delete(clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOAuthClients)(nil).Delete), clientID)
+}
+
+// Get mocks base method.
+func (m *MockOAuthClients) Get(clientID string) (*OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", clientID)
+	ret0, _ := ret[0].(*OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockOAuthClientsMockRecorder) Get(clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOAuthClients)(nil).Get), clientID)
+}
+
+// List mocks base method.
+func (m *MockOAuthClients) List() ([]OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOAuthClientsMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOAuthClients)(nil).List))
+}
+
+// MockAuthorizationCodes is a mock of AuthorizationCodes interface.
+type MockAuthorizationCodes struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationCodesMockRecorder
+}
+
+// MockAuthorizationCodesMockRecorder is the mock recorder for MockAuthorizationCodes.
+type MockAuthorizationCodesMockRecorder struct {
+	mock *MockAuthorizationCodes
+}
+
+// NewMockAuthorizationCodes creates a new mock instance.
+func NewMockAuthorizationCodes(ctrl *gomock.Controller) *MockAuthorizationCodes {
+	mock := &MockAuthorizationCodes{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationCodesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorizationCodes) EXPECT() *MockAuthorizationCodesMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockAuthorizationCodes) Consume(codeID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", codeID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockAuthorizationCodesMockRecorder) Consume(codeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockAuthorizationCodes)(nil).Consume), codeID)
+}
+
+// Create mocks base method.
+func (m *MockAuthorizationCodes) Create(code *AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuthorizationCodesMockRecorder) Create(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuthorizationCodes)(nil).Create), code)
+}
+
+// GetByHash mocks base method.
+func (m *MockAuthorizationCodes) GetByHash(codeHash string) (*AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", codeHash)
+	ret0, _ := ret[0].(*AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockAuthorizationCodesMockRecorder) GetByHash(codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockAuthorizationCodes)(nil).GetByHash), codeHash)
+}
+
+// MockConsents is a mock of Consents interface.
+type MockConsents struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsentsMockRecorder
+}
+
+// MockConsentsMockRecorder is the mock recorder for MockConsents.
+type MockConsentsMockRecorder struct {
+	mock *MockConsents
+}
+
+// NewMockConsents creates a new mock instance.
+func NewMockConsents(ctrl *gomock.Controller) *MockConsents {
+	mock := &MockConsents{ctrl: ctrl}
+	mock.recorder = &MockConsentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsents) EXPECT() *MockConsentsMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockConsents) Get(userID int, clientID string) (*Consent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userID, clientID)
+	ret0, _ := ret[0].(*Consent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockConsentsMockRecorder) Get(userID, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockConsents)(nil).Get), userID, clientID)
+}
+
+// Grant mocks base method.
+func (m *MockConsents) Grant(consent *Consent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Grant", consent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Grant indicates an expected call of Grant.
+func (mr *MockConsentsMockRecorder) Grant(consent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Grant", reflect.TypeOf((*MockConsents)(nil).Grant), consent)
+}
+
+// ListByUser mocks base method.
+func (m *MockConsents) ListByUser(userID int) ([]Consent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", userID)
+	ret0, _ := ret[0].([]Consent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockConsentsMockRecorder) ListByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockConsents)(nil).ListByUser), userID)
+}
+
+// MockIdentities is a mock of Identities interface.
+type MockIdentities struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentitiesMockRecorder
+}
+
+// MockIdentitiesMockRecorder is the mock recorder for MockIdentities.
+type MockIdentitiesMockRecorder struct {
+	mock *MockIdentities
+}
+
+// NewMockIdentities creates a new mock instance.
+func NewMockIdentities(ctrl *gomock.Controller) *MockIdentities {
+	mock := &MockIdentities{ctrl: ctrl}
+	mock.recorder = &MockIdentitiesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentities) EXPECT() *MockIdentitiesMockRecorder {
+	return m.recorder
+}
+
+// ConsumeState mocks base method.
+func (m *MockIdentities) ConsumeState(stateHash string) (*IdentityState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeState", stateHash)
+	ret0, _ := ret[0].(*IdentityState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeState indicates an expected call of ConsumeState.
+func (mr *MockIdentitiesMockRecorder) ConsumeState(stateHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeState", reflect.TypeOf((*MockIdentities)(nil).ConsumeState), stateHash)
+}
+
+// Create mocks base method.
+func (m *MockIdentities) Create(identity *Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIdentitiesMockRecorder) Create(identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIdentities)(nil).Create), identity)
+}
+
+// CreateState mocks base method.
+func (m *MockIdentities) CreateState(state *IdentityState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateState", state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateState indicates an expected call of CreateState.
+func (mr *MockIdentitiesMockRecorder) CreateState(state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateState", reflect.TypeOf((*MockIdentities)(nil).CreateState), state)
+}
+
+// Delete mocks base method.
+func (m *MockIdentities) Delete(userID, identityID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userID, identityID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIdentitiesMockRecorder) Delete(userID, identityID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIdentities)(nil).Delete), userID, identityID)
+}
+
+// Get mocks base method.
+func (m *MockIdentities) Get(provider, subject string) (*Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", provider, subject)
+	ret0, _ := ret[0].(*Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIdentitiesMockRecorder) Get(provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdentities)(nil).Get), provider, subject)
+}
+
+// ListByUser mocks base method.
+func (m *MockIdentities) ListByUser(userID int) ([]Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", userID)
+	ret0, _ := ret[0].([]Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockIdentitiesMockRecorder) ListByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockIdentities)(nil).ListByUser), userID)
+}
+
+// MockAuditEvents is a mock of AuditEvents interface.
+type MockAuditEvents struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditEventsMockRecorder
+}
+
+// MockAuditEventsMockRecorder is the mock recorder for MockAuditEvents.
+type MockAuditEventsMockRecorder struct {
+	mock *MockAuditEvents
+}
+
+// NewMockAuditEvents creates a new mock instance.
+func NewMockAuditEvents(ctrl *gomock.Controller) *MockAuditEvents {
+	mock := &MockAuditEvents{ctrl: ctrl}
+	mock.recorder = &MockAuditEventsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditEvents) EXPECT() *MockAuditEventsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuditEvents) Create(event *AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuditEventsMockRecorder) Create(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuditEvents)(nil).Create), event)
+}
+
+// ListByUser mocks base method.
+func (m *MockAuditEvents) ListByUser(userID int) ([]AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", userID)
+	ret0, _ := ret[0].([]AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockAuditEventsMockRecorder) ListByUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockAuditEvents)(nil).ListByUser), userID)
+}
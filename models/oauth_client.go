@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClient resource consisting of all the attributes defining an app signing users in with OpenID Connect
+// Only the hash of the secret of a confidential client is stored, public clients have none and rely on PKCE alone
+type OAuthClient struct {
+	ClientID     string   `json:"clientID" gorm:"primaryKey, not null"`
+	Name         string   `json:"name" gorm:"not null"`
+	RedirectURIs []string `json:"redirectURIs" gorm:"serializer:json"`
+	Confidential bool     `json:"confidential" gorm:"not null"`
+	// ClientSecret is only set in the response registering a confidential client
+	ClientSecret string    `json:"clientSecret,omitempty" gorm:"-"`
+	SecretHash   string    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// TableName overrides the table name of the OAuthClient resource
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// AllowsRedirect method takes a redirect URI and
+// returns whether it is exactly one of the registered redirect URIs of the client
+func (c *OAuthClient) AllowsRedirect(redirectURI string) bool {
+	for _, uri := range c.RedirectURIs {
+		if uri == redirectURI {
+			return true
+		}
+	}
+
+	return false
+}
+
+type oauthClientStore struct {
+	DB *gorm.DB
+}
+
+func NewOAuthClientStore(db *gorm.DB) OAuthClients {
+	return &oauthClientStore{
+		DB: db,
+	}
+}
+
+// Get method takes a client ID, fetches the client information
+// from the database and returns OAuthClient object along with an error if any
+func (o *oauthClientStore) Get(clientID string) (*OAuthClient, error) {
+	var client OAuthClient
+	if err := o.DB.Where("client_id = ?", clientID).First(&client); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &client, nil
+}
+
+// List method fetches every registered client
+// from the database and returns them along with an error if any
+func (o *oauthClientStore) List() ([]OAuthClient, error) {
+	clients := make([]OAuthClient, 0)
+	if err := o.DB.Order("created_at").Find(&clients); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return clients, nil
+}
+
+// Create method takes an OAuthClient object
+// creates the client information in the database
+// and returns an error if any
+func (o *oauthClientStore) Create(client *OAuthClient) error {
+	client.CreatedAt = time.Now()
+	if result := o.DB.Create(client); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Delete method takes a client ID
+// deletes the client along with its consents and authorization codes from the database
+// and returns an error if any
+func (o *oauthClientStore) Delete(clientID string) error {
+	result := o.DB.Where("client_id = ?", clientID).Delete(&OAuthClient{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
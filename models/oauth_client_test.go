@@ -0,0 +1,161 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_oauthClientStore_Get runs unit tests on the method Get
+func Test_oauthClientStore_Get(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	createdAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		clientID string
+		mock     func()
+		want     *OAuthClient
+		wantErr  error
+	}{
+		{
+			name:     "Success case",
+			clientID: "client",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"client_id", "name", "redirect_uris", "confidential", "secret_hash", "created_at"}).
+					AddRow("client", "Billing", `["https://billing.example.com/callback"]`, true, "abc123", createdAt)
+				mock.ExpectQuery("SELECT \\* FROM `oauth_clients` WHERE client_id = \\?").
+					WithArgs("client", 1).
+					WillReturnRows(rows)
+			},
+			want: &OAuthClient{
+				ClientID:     "client",
+				Name:         "Billing",
+				RedirectURIs: []string{"https://billing.example.com/callback"},
+				Confidential: true,
+				SecretHash:   "abc123",
+				CreatedAt:    createdAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "Failure case",
+			clientID: "unknown",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"client_id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			oS := NewOAuthClientStore(gormDB)
+
+			got, err := oS.Get(tt.clientID)
+			if err != tt.wantErr {
+				t.Errorf("oauthClientStore.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("oauthClientStore.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_oauthClientStore_Delete runs unit tests on the method Delete
+func Test_oauthClientStore_Delete(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name     string
+		clientID string
+		mock     func()
+		wantErr  error
+	}{
+		{
+			name:     "Success case",
+			clientID: "client",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `oauth_clients` WHERE client_id = \\?").WithArgs("client").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "Not found case",
+			clientID: "unknown",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE").WithArgs("unknown").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:     "Failure case",
+			clientID: "client",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			oS := NewOAuthClientStore(gormDB)
+
+			if err := oS.Delete(tt.clientID); err != tt.wantErr {
+				t.Errorf("oauthClientStore.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purposes a one time token can be issued for
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+	PurposeMFAChallenge      = "mfa_challenge"
+)
+
+// OneTimeToken resource consisting of all the attributes defining a single use, expiring token
+// Only the hash of the token is stored, Email is the address an email verification token was sent to
+type OneTimeToken struct {
+	ID        int        `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID    int        `json:"userID" gorm:"not null"`
+	Purpose   string     `json:"purpose" gorm:"not null"`
+	Email     string     `json:"email"`
+	TokenHash string     `json:"-" gorm:"unique, not null"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type oneTimeTokenStore struct {
+	DB *gorm.DB
+}
+
+func NewOneTimeTokenStore(db *gorm.DB) OneTimeTokens {
+	return &oneTimeTokenStore{
+		DB: db,
+	}
+}
+
+// GetByHash method takes a purpose and a token hash, fetches the token information
+// from the database and returns OneTimeToken object along with an error if any
+func (o *oneTimeTokenStore) GetByHash(purpose, tokenHash string) (*OneTimeToken, error) {
+	var token OneTimeToken
+	if err := o.DB.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &token, nil
+}
+
+// Create method takes a OneTimeToken object
+// creates the token information in the database
+// and returns an error if any
+func (o *oneTimeTokenStore) Create(token *OneTimeToken) error {
+	token.CreatedAt = time.Now()
+	if result := o.DB.Create(token); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Consume method takes a token ID
+// marks the token as used if it was not used before and
+// returns whether this call consumed it along with an error if any
+func (o *oneTimeTokenStore) Consume(tokenID int) (bool, error) {
+	result := o.DB.Model(&OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateByUser method takes a user ID and a purpose
+// marks every unused token of the user for that purpose as used
+// and returns an error if any
+func (o *oneTimeTokenStore) InvalidateByUser(userID int, purpose string) error {
+	result := o.DB.Model(&OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
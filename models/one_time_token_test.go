@@ -0,0 +1,170 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_oneTimeTokenStore_GetByHash runs unit tests on the method GetByHash
+func Test_oneTimeTokenStore_GetByHash(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	expiresAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		purpose   string
+		tokenHash string
+		mock      func()
+		want      *OneTimeToken
+		wantErr   error
+	}{
+		{
+			name:      "Success case",
+			purpose:   PurposePasswordReset,
+			tokenHash: "abc123",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "purpose", "token_hash", "expires_at"}).
+					AddRow(1, 1, PurposePasswordReset, "abc123", expiresAt)
+				mock.ExpectQuery("SELECT").WithArgs(PurposePasswordReset, "abc123", 1).WillReturnRows(rows)
+			},
+			want: &OneTimeToken{
+				ID:        1,
+				UserID:    1,
+				Purpose:   PurposePasswordReset,
+				TokenHash: "abc123",
+				ExpiresAt: expiresAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name:      "Failure case",
+			purpose:   PurposePasswordReset,
+			tokenHash: "abc123",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			oS := NewOneTimeTokenStore(gormDB)
+
+			got, err := oS.GetByHash(tt.purpose, tt.tokenHash)
+			if err != tt.wantErr {
+				t.Errorf("oneTimeTokenStore.GetByHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("oneTimeTokenStore.GetByHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_oneTimeTokenStore_Consume runs unit tests on the method Consume
+func Test_oneTimeTokenStore_Consume(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		tokenID int
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name:    "Success case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:    "Already used case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name:    "Failure case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			oS := NewOneTimeTokenStore(gormDB)
+
+			got, err := oS.Consume(tt.tokenID)
+			if err != tt.wantErr {
+				t.Errorf("oneTimeTokenStore.Consume() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("oneTimeTokenStore.Consume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
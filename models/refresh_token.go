@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken resource consisting of all the attributes defining a persisted refresh token
+// Only the hash of the token is stored and every rotation stays within the same family
+type RefreshToken struct {
+	ID        int        `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	UserID    int        `json:"userID" gorm:"not null"`
+	FamilyID  string     `json:"familyID" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"unique, not null"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type refreshTokenStore struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenStore(db *gorm.DB) RefreshTokens {
+	return &refreshTokenStore{
+		DB: db,
+	}
+}
+
+// GetByHash method takes a token hash, fetches the refresh token information
+// from the database and returns RefreshToken object along with an error if any
+func (r *refreshTokenStore) GetByHash(tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&token); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &token, nil
+}
+
+// ListByUser method takes a user ID, fetches every refresh token
+// issued to the user from the database, oldest first
+// and returns them along with an error if any
+func (r *refreshTokenStore) ListByUser(userID int) ([]RefreshToken, error) {
+	tokens := make([]RefreshToken, 0)
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at, id").Find(&tokens); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return tokens, nil
+}
+
+// Create method takes a RefreshToken object
+// creates the refresh token information in the database
+// and returns an error if any
+func (r *refreshTokenStore) Create(token *RefreshToken) error {
+	token.CreatedAt = time.Now()
+	if result := r.DB.Create(token); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// Revoke method takes a refresh token ID
+// marks the token as revoked if it is still active and
+// returns whether this call revoked it along with an error if any
+func (r *refreshTokenStore) Revoke(tokenID int) (bool, error) {
+	result := r.DB.Model(&RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily method takes a family ID
+// revokes every active refresh token issued within the family and
+// returns an error if any encountered
+func (r *refreshTokenStore) RevokeFamily(familyID string) error {
+	result := r.DB.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// RevokeByUser method takes a user ID
+// revokes every active refresh token issued to the user and
+// returns an error if any encountered
+func (r *refreshTokenStore) RevokeByUser(userID int) error {
+	result := r.DB.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
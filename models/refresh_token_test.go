@@ -0,0 +1,301 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_refreshTokenStore_GetByHash runs unit tests on the method GetByHash
+func Test_refreshTokenStore_GetByHash(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	expiresAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		tokenHash string
+		mock      func()
+		want      *RefreshToken
+		wantErr   error
+	}{
+		{
+			name:      "Success case",
+			tokenHash: "abc123",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "expires_at"}).
+					AddRow(1, 1, "family", "abc123", expiresAt)
+				mock.ExpectQuery("SELECT").WithArgs("abc123", 1).WillReturnRows(rows)
+			},
+			want: &RefreshToken{
+				ID:        1,
+				UserID:    1,
+				FamilyID:  "family",
+				TokenHash: "abc123",
+				ExpiresAt: expiresAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name:      "Failure case",
+			tokenHash: "abc123",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRefreshTokenStore(gormDB)
+
+			got, err := rS.GetByHash(tt.tokenHash)
+			if err != tt.wantErr {
+				t.Errorf("refreshTokenStore.GetByHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refreshTokenStore.GetByHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_refreshTokenStore_ListByUser runs unit tests on the method ListByUser
+func Test_refreshTokenStore_ListByUser(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	createdAt := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []RefreshToken
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "family_id", "created_at"}).
+					AddRow(1, 1, "family", createdAt).
+					AddRow(2, 1, "family", createdAt)
+				mock.ExpectQuery("SELECT \\* FROM `refresh_tokens` WHERE user_id = \\? ORDER BY created_at, id").
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+			want: []RefreshToken{
+				{ID: 1, UserID: 1, FamilyID: "family", CreatedAt: createdAt},
+				{ID: 2, UserID: 1, FamilyID: "family", CreatedAt: createdAt},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			want:    nil,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRefreshTokenStore(gormDB)
+
+			got, err := rS.ListByUser(1)
+			if err != tt.wantErr {
+				t.Errorf("refreshTokenStore.ListByUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refreshTokenStore.ListByUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_refreshTokenStore_Revoke runs unit tests on the method Revoke
+func Test_refreshTokenStore_Revoke(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		tokenID int
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name:    "Success case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:    "Already revoked case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name:    "Failure case",
+			tokenID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRefreshTokenStore(gormDB)
+
+			got, err := rS.Revoke(tt.tokenID)
+			if err != tt.wantErr {
+				t.Errorf("refreshTokenStore.Revoke() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("refreshTokenStore.Revoke() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_refreshTokenStore_RevokeFamily runs unit tests on the method RevokeFamily
+func Test_refreshTokenStore_RevokeFamily(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name     string
+		familyID string
+		mock     func()
+		wantErr  error
+	}{
+		{
+			name:     "Success case",
+			familyID: "family",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), "family").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "Failure case",
+			familyID: "family",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), "family").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRefreshTokenStore(gormDB)
+
+			if err := rS.RevokeFamily(tt.familyID); err != tt.wantErr {
+				t.Errorf("refreshTokenStore.RevokeFamily() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedToken resource consisting of all the attributes defining a revoked token
+// TokenID is either the jti of a single access token or the session ID shared by a token family
+type RevokedToken struct {
+	TokenID   string    `json:"tokenID" gorm:"primaryKey, not null"`
+	UserID    int       `json:"userID" gorm:"not null"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserRevocation resource holding the instant up to which every token issued to a user is revoked
+// KeptSessionID is the session started along with the revocation, whose tokens issued within its second stay valid
+type UserRevocation struct {
+	UserID        int       `json:"userID" gorm:"primaryKey, not null"`
+	RevokedBefore time.Time `json:"revokedBefore"`
+	KeptSessionID string    `json:"keptSessionID" gorm:"not null"`
+}
+
+// cachedLookup is an entry of the in-memory revocation cache
+// negative lookups and cut-offs are only trusted until validUntil
+type cachedLookup struct {
+	revoked       bool
+	revokedBefore time.Time
+	keptSessionID string
+	validUntil    time.Time
+}
+
+type revocationStore struct {
+	DB       *gorm.DB
+	cacheTTL time.Duration
+
+	mu         sync.RWMutex
+	tokens     map[string]cachedLookup
+	users      map[int]cachedLookup
+	lastPruned time.Time
+}
+
+// NewRevocationStore takes a database connection and a cache TTL
+// Revocations written through this store are cached until the token expires while
+// lookups that are not revoked are re-read from the database after cacheTTL
+func NewRevocationStore(db *gorm.DB, cacheTTL time.Duration) Revocations {
+	return &revocationStore{
+		DB:         db,
+		cacheTTL:   cacheTTL,
+		tokens:     make(map[string]cachedLookup),
+		users:      make(map[int]cachedLookup),
+		lastPruned: time.Now(),
+	}
+}
+
+// Revoke method takes a RevokedToken object
+// persists the revocation in the database and the cache
+// and returns an error if any
+func (r *revocationStore) Revoke(token *RevokedToken) error {
+	token.CreatedAt = time.Now()
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	r.mu.Lock()
+	r.tokens[token.TokenID] = cachedLookup{revoked: true, validUntil: token.ExpiresAt}
+	r.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked method takes token IDs (jti or session ID), checks the cache
+// followed by the database and returns whether any of them is revoked along with an error if any
+func (r *revocationStore) IsRevoked(tokenIDs ...string) (bool, error) {
+	now := time.Now()
+	missing := make([]string, 0, len(tokenIDs))
+
+	r.mu.RLock()
+	for _, tokenID := range tokenIDs {
+		if tokenID == "" {
+			continue
+		}
+
+		entry, ok := r.tokens[tokenID]
+		if ok && entry.revoked {
+			r.mu.RUnlock()
+			return true, nil
+		}
+
+		if !ok || entry.validUntil.Before(now) {
+			missing = append(missing, tokenID)
+		}
+	}
+	r.mu.RUnlock()
+
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	var revoked []RevokedToken
+	if err := r.DB.Where("token_id IN ?", missing).Find(&revoked); err.Error != nil {
+		return false, err.Error
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(now)
+
+	for _, tokenID := range missing {
+		r.tokens[tokenID] = cachedLookup{validUntil: now.Add(r.cacheTTL)}
+	}
+
+	for _, token := range revoked {
+		r.tokens[token.TokenID] = cachedLookup{revoked: true, validUntil: token.ExpiresAt}
+	}
+
+	return len(revoked) > 0, nil
+}
+
+// RevokeAll method takes a user ID, an instant and the ID of a session started along with the revocation, if any
+// revokes every token issued to the user up to the second of that instant, except those of the kept session
+// and returns an error if any
+func (r *revocationStore) RevokeAll(userID int, before time.Time, keptSessionID string) error {
+	// Tokens carry their iat in whole seconds, so the cut-off is kept in whole seconds too
+	// which the DATETIME column would otherwise round up past the tokens issued in the following second
+	before = before.Truncate(time.Second)
+
+	revocation := UserRevocation{UserID: userID, RevokedBefore: before, KeptSessionID: keptSessionID}
+	result := r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&revocation)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	r.mu.Lock()
+	r.users[userID] = cachedLookup{revokedBefore: before, keptSessionID: keptSessionID, validUntil: time.Now().Add(r.cacheTTL)}
+	r.mu.Unlock()
+
+	return nil
+}
+
+// RevokedBefore method takes a user ID and
+// returns the instant up to which the user's tokens are revoked along with the session kept by that revocation
+// zero time is returned when the user never revoked all tokens
+func (r *revocationStore) RevokedBefore(userID int) (time.Time, string, error) {
+	now := time.Now()
+
+	r.mu.RLock()
+	entry, ok := r.users[userID]
+	r.mu.RUnlock()
+
+	if ok && entry.validUntil.After(now) {
+		return entry.revokedBefore, entry.keptSessionID, nil
+	}
+
+	var revocation UserRevocation
+	err := r.DB.First(&revocation, userID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, "", err
+	}
+
+	r.mu.Lock()
+	r.users[userID] = cachedLookup{revokedBefore: revocation.RevokedBefore, keptSessionID: revocation.KeptSessionID, validUntil: now.Add(r.cacheTTL)}
+	r.mu.Unlock()
+
+	return revocation.RevokedBefore, revocation.KeptSessionID, nil
+}
+
+// prune method drops expired cache entries at most once per cache TTL
+// callers must hold the write lock
+func (r *revocationStore) prune(now time.Time) {
+	if now.Sub(r.lastPruned) < r.cacheTTL {
+		return
+	}
+
+	for tokenID, entry := range r.tokens {
+		if entry.validUntil.Before(now) {
+			delete(r.tokens, tokenID)
+		}
+	}
+
+	for userID, entry := range r.users {
+		if entry.validUntil.Before(now) {
+			delete(r.users, userID)
+		}
+	}
+
+	r.lastPruned = now
+}
@@ -0,0 +1,322 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_revocationStore_IsRevoked runs unit tests on the method IsRevoked
+func Test_revocationStore_IsRevoked(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name     string
+		tokenIDs []string
+		mock     func()
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "Revoked case",
+			tokenIDs: []string{"jti", "session"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"token_id", "user_id", "expires_at"}).
+					AddRow("session", 1, time.Now().Add(time.Hour))
+				mock.ExpectQuery("SELECT").WithArgs("jti", "session").WillReturnRows(rows)
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:     "Not revoked case",
+			tokenIDs: []string{"jti", ""},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"token_id", "user_id", "expires_at"})
+				mock.ExpectQuery("SELECT").WithArgs("jti").WillReturnRows(rows)
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name:     "Failure case",
+			tokenIDs: []string{"jti"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRevocationStore(gormDB, time.Minute)
+
+			got, err := rS.IsRevoked(tt.tokenIDs...)
+			if err != tt.wantErr {
+				t.Errorf("revocationStore.IsRevoked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("revocationStore.IsRevoked() = %v, want %v", got, tt.want)
+			}
+
+			// A second lookup within the cache TTL must not reach the database
+			if got, err := rS.IsRevoked(tt.tokenIDs...); err != nil || got != tt.want {
+				t.Errorf("revocationStore.IsRevoked() from cache = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Test_revocationStore_Revoke runs unit tests on the method Revoke
+func Test_revocationStore_Revoke(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		token   *RevokedToken
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name:  "Success case",
+			token: &RevokedToken{TokenID: "jti", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:  "Failure case",
+			token: &RevokedToken{TokenID: "jti", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    false,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRevocationStore(gormDB, time.Minute)
+
+			if err := rS.Revoke(tt.token); err != tt.wantErr {
+				t.Errorf("revocationStore.Revoke() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			// Successful revocations are served from the cache without reaching the database
+			if !tt.want {
+				return
+			}
+			if got, err := rS.IsRevoked(tt.token.TokenID); err != nil || got != tt.want {
+				t.Errorf("revocationStore.IsRevoked() after Revoke = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Test_revocationStore_RevokeAll runs unit tests on the method RevokeAll
+func Test_revocationStore_RevokeAll(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	before := time.Date(2024, 7, 1, 12, 0, 0, 700000000, time.UTC)
+	truncated := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case storing the cut-off in whole seconds along with the kept session",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `user_revocations`").
+					WithArgs(1, truncated, "sid").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRevocationStore(gormDB, time.Minute)
+
+			if err := rS.RevokeAll(1, before, "sid"); err != tt.wantErr {
+				t.Errorf("revocationStore.RevokeAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			// Successful revocations are served from the cache without reaching the database
+			if tt.wantErr != nil {
+				return
+			}
+			if got, kept, err := rS.RevokedBefore(1); err != nil || !got.Equal(truncated) || kept != "sid" {
+				t.Errorf("revocationStore.RevokedBefore() after RevokeAll = %v, %v, %v, want %v, sid", got, kept, err, truncated)
+			}
+		})
+	}
+}
+
+// Test_revocationStore_RevokedBefore runs unit tests on the method RevokedBefore
+func Test_revocationStore_RevokedBefore(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	revokedBefore := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		userID   int
+		mock     func()
+		want     time.Time
+		wantKept string
+		wantErr  error
+	}{
+		{
+			name:   "Success case",
+			userID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"user_id", "revoked_before", "kept_session_id"}).AddRow(1, revokedBefore, "sid")
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+			},
+			want:     revokedBefore,
+			wantKept: "sid",
+			wantErr:  nil,
+		},
+		{
+			name:   "Never revoked case",
+			userID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(gorm.ErrRecordNotFound)
+			},
+			want:    time.Time{},
+			wantErr: nil,
+		},
+		{
+			name:   "Failure case",
+			userID: 1,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			want:    time.Time{},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			rS := NewRevocationStore(gormDB, time.Minute)
+
+			got, kept, err := rS.RevokedBefore(tt.userID)
+			if err != tt.wantErr {
+				t.Errorf("revocationStore.RevokedBefore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) || kept != tt.wantKept {
+				t.Errorf("revocationStore.RevokedBefore() = %v, %v, want %v, %v", got, kept, tt.want, tt.wantKept)
+			}
+		})
+	}
+}
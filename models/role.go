@@ -0,0 +1,49 @@
+package models
+
+import "sort"
+
+// Roles a user can be granted, every user starts with RoleUser
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+// Permissions granted on top of the ownership of a user's own resources
+const (
+	PermissionReadUsers   = "users:read"
+	PermissionManageUsers = "users:manage"
+)
+
+// rolePermissions holds the permissions of every role on other users' resources
+var rolePermissions = map[string]map[string]bool{
+	RoleUser:    {},
+	RoleSupport: {PermissionReadUsers: true},
+	RoleAdmin:   {PermissionReadUsers: true, PermissionManageUsers: true},
+}
+
+// ValidRole function takes a role and
+// returns whether it is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission function takes a role and a permission and
+// returns whether the role is granted the permission
+func HasPermission(role, permission string) bool {
+	return rolePermissions[role][permission]
+}
+
+// Permissions function takes a role and
+// returns the permissions the role is granted in lexical order
+func Permissions(role string) []string {
+	permissions := make([]string, 0, len(rolePermissions[role]))
+	for permission := range rolePermissions[role] {
+		permissions = append(permissions, permission)
+	}
+
+	sort.Strings(permissions)
+
+	return permissions
+}
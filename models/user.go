@@ -1,22 +1,66 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
 // User resource consisting of all the attributes defining a user
 type User struct {
-	ID        int       `json:"id" gorm:"primaryKey, not null, autoIncrement"`
-	Name      string    `json:"name" gorm:"not null"`
-	CountryID int       `json:"countryID" gorm:"unique, not null"`
-	Email     string    `json:"email" gorm:"not null"`
-	Password  string    `json:"password,omitempty" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        int    `json:"id" gorm:"primaryKey, not null, autoIncrement"`
+	Name      string `json:"name" gorm:"not null"`
+	CountryID int    `json:"countryID" gorm:"unique, not null"`
+	// Country is embedded in responses and, on input, may identify the country by code or name instead of countryID
+	Country         *Country       `json:"country,omitempty" gorm:"-"`
+	Email           string         `json:"email" gorm:"not null"`
+	Password        string         `json:"password,omitempty" gorm:"not null"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+	EmailVerifiedAt *time.Time     `json:"emailVerifiedAt"`
+	Phone           string         `json:"phone"`
+	Locale          string         `json:"locale"`
+	Timezone        string         `json:"timezone"`
+	DateOfBirth     *Date          `json:"dateOfBirth"`
+	AvatarURL       string         `json:"avatarURL"`
+	Version         int            `json:"-" gorm:"not null, default:1"`
+	Role            string         `json:"role" gorm:"not null, default:user"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	ErasedAt        *time.Time     `json:"-"`
 }
 
+// profileColumns are the columns replaced by a full update of the user profile
+var profileColumns = []string{"name", "country_id", "email", "email_verified_at", "phone", "locale", "timezone", "date_of_birth", "avatar_url"}
+
+// VersionConflictError is returned by conditional updates when the user
+// was modified after the version the caller read
+type VersionConflictError struct {
+	UserID          int
+	ExpectedVersion int
+	CurrentVersion  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("user %d was modified: expected version %d but found version %d", e.UserID, e.ExpectedVersion, e.CurrentVersion)
+}
+
+// ErrUnknownCountry is returned when a user refers to a country that does not exist
+var ErrUnknownCountry = errors.New("country does not exist")
+
+// ErrEmailTaken is returned when the email of a user is already used by another user
+// and ErrEmailOfDeletedUser when that user is deleted, as deleted users keep their email until they are erased
+var (
+	ErrEmailTaken         = errors.New("email is already used by another user")
+	ErrEmailOfDeletedUser = errors.New("email belongs to a deleted user")
+)
+
+// mysqlNoReferencedRow is the MySQL error number of a foreign key pointing to a missing row
+const mysqlNoReferencedRow = 1452
+
 type userStore struct {
 	DB *gorm.DB
 }
@@ -27,6 +71,36 @@ func NewUserStore(db *gorm.DB) Users {
 	}
 }
 
+// saveError method takes an error returned while saving a user with the given email and
+// returns ErrUnknownCountry when it is a violation of the country foreign key,
+// ErrEmailOfDeletedUser or ErrEmailTaken when it is a violation of the unique email, or the error itself otherwise
+func (u *userStore) saveError(err error, email string) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	switch mysqlErr.Number {
+	case mysqlNoReferencedRow:
+		// The country is the only row users refer to
+		return ErrUnknownCountry
+	case mysqlDuplicateEntry:
+		// The email is the only unique column besides the ID
+		var deleted int64
+		if err := u.DB.Unscoped().Model(&User{}).Where("email = ? AND deleted_at IS NOT NULL", email).Count(&deleted).Error; err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			return ErrEmailOfDeletedUser
+		}
+
+		return ErrEmailTaken
+	}
+
+	return err
+}
+
 // GetByID method takes a userID, fetches the user information
 // from the database and returns User object along with an error if any
 func (u *userStore) GetByID(userID int) (*User, error) {
@@ -51,22 +125,23 @@ func (u *userStore) GetByEmail(email string) (*User, error) {
 
 // Create method takes a User object
 // creates the user information in the database
-// and returns the user ID along with an error if any
+// and returns the user ID along with ErrUnknownCountry, ErrEmailTaken, ErrEmailOfDeletedUser or any other error encountered
 func (u *userStore) Create(user *User) (int, error) {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 	result := u.DB.Create(user)
 
 	if result.Error != nil {
-		return 0, result.Error
+		return 0, u.saveError(result.Error, user.Email)
 	}
 
 	return user.ID, nil
 }
 
-// Update method takes a User object
-// updates the existing user information in the database
-// and returns an error if any encountered
+// Update method takes a User object holding the version it was read at
+// updates the existing user information except the password in the database
+// only if the stored version still matches and
+// returns a VersionConflictError, ErrUnknownCountry, ErrEmailTaken, ErrEmailOfDeletedUser or any other error encountered
 func (u *userStore) Update(user *User) error {
 	existingUser, err := u.GetByID(user.ID)
 	if err != nil {
@@ -74,16 +149,206 @@ func (u *userStore) Update(user *User) error {
 	}
 
 	user.CreatedAt = existingUser.CreatedAt
+
+	return u.updateIfMatch(user, profileColumns)
+}
+
+// UpdateColumns method takes a User object holding the version it was read at and the columns that changed
+// updates only those columns of the existing user in the database
+// only if the stored version still matches and
+// returns a VersionConflictError, ErrUnknownCountry, ErrEmailTaken, ErrEmailOfDeletedUser or any other error encountered
+func (u *userStore) UpdateColumns(user *User, columns ...string) error {
+	return u.updateIfMatch(user, columns)
+}
+
+// updateIfMatch method takes a User object and the columns to update
+// updates the columns while bumping the version only if the stored version is user.Version
+// and returns a VersionConflictError when another update happened in between
+func (u *userStore) updateIfMatch(user *User, columns []string) error {
+	version := user.Version
+	updatedAt := user.UpdatedAt
+
+	user.Version = version + 1
 	user.UpdatedAt = time.Now()
-	if result := u.DB.Save(user); result.Error != nil {
+
+	result := u.DB.Model(&User{ID: user.ID}).
+		Where("version = ?", version).
+		Select(append(append([]string{}, columns...), "updated_at", "version")).
+		Updates(user)
+	if result.Error == nil && result.RowsAffected == 1 {
+		return nil
+	}
+
+	user.Version = version
+	user.UpdatedAt = updatedAt
+
+	if result.Error != nil {
+		return u.saveError(result.Error, user.Email)
+	}
+
+	current, err := u.GetByID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return &VersionConflictError{UserID: user.ID, ExpectedVersion: version, CurrentVersion: current.Version}
+}
+
+// UpdatePassword method takes a user ID and a password hash
+// updates only the password of the existing user in the database
+// and returns an error if any encountered
+func (u *userStore) UpdatePassword(userID int, password string) error {
+	result := u.DB.Model(&User{ID: userID}).Updates(map[string]interface{}{
+		"password":   password,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UpdateRole method takes a user ID and a role
+// updates only the role of the existing user in the database
+// and returns an error if any encountered
+func (u *userStore) UpdateRole(userID int, role string) error {
+	result := u.DB.Model(&User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"role":    role,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified method takes a user ID and the email that was verified
+// marks the email as verified only if it is still the user's email
+// and returns an error if any encountered
+func (u *userStore) MarkEmailVerified(userID int, email string) error {
+	result := u.DB.Model(&User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Updates(map[string]interface{}{
+			"email_verified_at": time.Now(),
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
 		return result.Error
 	}
 
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
 	return nil
 }
 
+// GetDeletedByEmail method takes an email and a time
+// fetches the user deleted after that time from the database
+// and returns User object along with an error if any
+func (u *userStore) GetDeletedByEmail(email string, deletedAfter time.Time) (*User, error) {
+	var user User
+	if err := u.DB.Unscoped().Where("email = ? AND deleted_at > ?", email, deletedAfter).First(&user); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &user, nil
+}
+
+// GetDeletedByID method takes a user ID and a time
+// fetches the user deleted after that time from the database
+// and returns User object along with an error if any
+func (u *userStore) GetDeletedByID(userID int, deletedAfter time.Time) (*User, error) {
+	var user User
+	if err := u.DB.Unscoped().Where("id = ? AND deleted_at > ?", userID, deletedAfter).First(&user); err.Error != nil {
+		return nil, err.Error
+	}
+
+	return &user, nil
+}
+
+// Restore method takes a user ID and a time
+// restores the user only if it was deleted after that time
+// and returns an error if any encountered
+func (u *userStore) Restore(userID int, deletedAfter time.Time) error {
+	result := u.DB.Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at > ?", userID, deletedAfter).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Purge method takes a context, a time and an erasure mode
+// erases every user deleted before that time and not erased yet, each in its own transaction,
+// either deleting it along with its dependent records or anonymizing it in place
+// and returns the IDs of the users erased along with an error if any
+func (u *userStore) Purge(ctx context.Context, deletedBefore time.Time, mode string) ([]int, error) {
+	var users []User
+	if err := u.DB.WithContext(ctx).Unscoped().
+		Where("deleted_at <= ? AND erased_at IS NULL", deletedBefore).
+		Find(&users); err.Error != nil {
+		return nil, err.Error
+	}
+
+	var erased []int
+	for i := range users {
+		if err := u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return eraseUser(tx, &users[i], mode)
+		}); err != nil {
+			return erased, err
+		}
+
+		erased = append(erased, users[i].ID)
+	}
+
+	return erased, nil
+}
+
+// DeleteIfMatch method takes a user ID and the version it was read at
+// soft deletes the user information only if the stored version still matches and
+// returns a VersionConflictError or any other error encountered
+func (u *userStore) DeleteIfMatch(userID int, version int) error {
+	result := u.DB.Where("version = ?", version).Delete(&User{}, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	current, err := u.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return &VersionConflictError{UserID: userID, ExpectedVersion: version, CurrentVersion: current.Version}
+}
+
 // Delete method takes a user ID
-// deletes the user information and
+// soft deletes the user information so that it can be restored within the grace period and
 // return an error if encountered
 func (u *userStore) Delete(userID int) error {
 	if result := u.DB.Delete(&User{}, userID); result.Error != nil {
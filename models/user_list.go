@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	ErrInvalidSort   = errors.New("sort must be one of id, createdAt, name or email, optionally prefixed with - for descending order")
+)
+
+// userSortColumns maps every sort option of the user listing to its column
+var userSortColumns = map[string]string{
+	"id":        "id",
+	"createdAt": "created_at",
+	"name":      "name",
+	"email":     "email",
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// UserFilter holds the criteria, the order and the page of a user listing
+// Zero values leave the corresponding criterion out
+type UserFilter struct {
+	CountryID     int
+	EmailDomain   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Verified      *bool
+	Sort          string
+	Cursor        string
+	Limit         int
+}
+
+// UserPage is a single page of a user listing
+// NextCursor is empty on the last page
+type UserPage struct {
+	Users      []User
+	Total      int64
+	NextCursor string
+}
+
+// userCursor is the position after the last user of a page
+// It is bound to the sort it was created with
+type userCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// sortColumn function takes a sort option and
+// returns its column and whether the order is descending along with an error if any
+func sortColumn(sort string) (string, bool, error) {
+	if sort == "" {
+		return "id", false, nil
+	}
+
+	descending := strings.HasPrefix(sort, "-")
+	column, ok := userSortColumns[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return "", false, ErrInvalidSort
+	}
+
+	return column, descending, nil
+}
+
+// sortValue function takes a User and a sort column and
+// returns the value of the column as a cursor value
+func sortValue(user *User, column string) string {
+	switch column {
+	case "created_at":
+		return user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	}
+
+	return ""
+}
+
+// encodeCursor function takes the last User of a page, the sort option and its column and
+// returns an opaque cursor pointing after the user
+func encodeCursor(user *User, sort string, column string) string {
+	data, _ := json.Marshal(userCursor{Sort: sort, Value: sortValue(user, column), ID: user.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor function takes an opaque cursor and the sort option of the listing and
+// returns the decoded cursor along with ErrInvalidCursor if it is malformed or of another sort
+func decodeCursor(cursor string, sort string) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var decoded userCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	return &decoded, nil
+}
+
+// filteredUsers method takes a context and a UserFilter and
+// returns a query over the users matching the criteria of the filter
+func (u *userStore) filteredUsers(ctx context.Context, filter UserFilter) *gorm.DB {
+	query := u.DB.WithContext(ctx).Model(&User{})
+
+	if filter.CountryID != 0 {
+		query = query.Where("country_id = ?", filter.CountryID)
+	}
+
+	if filter.EmailDomain != "" {
+		query = query.Where("email LIKE ?", "%@"+likeEscaper.Replace(filter.EmailDomain))
+	}
+
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	if filter.Verified != nil {
+		if *filter.Verified {
+			query = query.Where("email_verified_at IS NOT NULL")
+		} else {
+			query = query.Where("email_verified_at IS NULL")
+		}
+	}
+
+	return query
+}
+
+// List method takes a context and a UserFilter
+// counts the users matching the criteria and fetches the page after the cursor
+// ordered by the sort column with the user ID breaking ties and
+// returns the UserPage along with an error if any
+func (u *userStore) List(ctx context.Context, filter UserFilter) (*UserPage, error) {
+	column, descending, err := sortColumn(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *userCursor
+	if filter.Cursor != "" {
+		if cursor, err = decodeCursor(filter.Cursor, filter.Sort); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	} else if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+
+	var total int64
+	if err := u.filteredUsers(ctx, filter).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := u.filteredUsers(ctx, filter)
+
+	if cursor != nil {
+		if column == "id" {
+			query = query.Where("id "+comparison+" ?", cursor.ID)
+		} else {
+			var value interface{} = cursor.Value
+			if column == "created_at" {
+				createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+				if err != nil {
+					return nil, ErrInvalidCursor
+				}
+				value = createdAt
+			}
+
+			query = query.Where(
+				"("+column+" "+comparison+" ? OR ("+column+" = ? AND id "+comparison+" ?))",
+				value, value, cursor.ID,
+			)
+		}
+	}
+
+	if column != "id" {
+		query = query.Order(column + " " + direction)
+	}
+
+	var users []User
+	if err := query.Order("id " + direction).Limit(limit + 1).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: users, Total: total}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = encodeCursor(&page.Users[limit-1], filter.Sort, column)
+	}
+
+	return page, nil
+}
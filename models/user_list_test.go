@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Test_userStore_List runs unit tests on the method List
+func Test_userStore_List(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	verified := true
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	userColumns := []string{"id", "name", "country_id", "email", "password", "created_at", "updated_at", "email_verified_at", "version", "role"}
+	nameCursor := encodeCursor(&User{ID: 2, Name: "Bob"}, "-name", "name")
+
+	tests := []struct {
+		name           string
+		filter         UserFilter
+		mock           func()
+		wantIDs        []int
+		wantTotal      int64
+		wantNextCursor bool
+		wantErr        error
+	}{
+		{
+			name:   "Success case with filters and a next page",
+			filter: UserFilter{CountryID: 1, EmailDomain: "gmail.com", Verified: &verified, Limit: 2},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE country_id = \\? AND email LIKE \\? AND email_verified_at IS NOT NULL AND `users`.`deleted_at` IS NULL").
+					WithArgs(1, "%@gmail.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+				rows := sqlmock.NewRows(userColumns).
+					AddRow(1, "Alice", 1, "alice@gmail.com", "hash", createdAt, createdAt, createdAt, 1, "user").
+					AddRow(2, "Bob", 1, "bob@gmail.com", "hash", createdAt, createdAt, createdAt, 1, "user").
+					AddRow(3, "Carol", 1, "carol@gmail.com", "hash", createdAt, createdAt, createdAt, 1, "user")
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE country_id = \\? AND email LIKE \\? AND email_verified_at IS NOT NULL AND `users`.`deleted_at` IS NULL ORDER BY id ASC LIMIT \\?").
+					WithArgs(1, "%@gmail.com", 3).
+					WillReturnRows(rows)
+			},
+			wantIDs:        []int{1, 2},
+			wantTotal:      5,
+			wantNextCursor: true,
+			wantErr:        nil,
+		},
+		{
+			name:   "Success case after a cursor in descending order",
+			filter: UserFilter{Sort: "-name", Cursor: nameCursor},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users`").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				rows := sqlmock.NewRows(userColumns).
+					AddRow(1, "Alice", 1, "alice@gmail.com", "hash", createdAt, createdAt, nil, 1, "user")
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE \\(\\(name < \\? OR \\(name = \\? AND id < \\?\\)\\)\\) AND `users`.`deleted_at` IS NULL ORDER BY name DESC,id DESC LIMIT \\?").
+					WithArgs("Bob", "Bob", 2, DefaultUserPageSize+1).
+					WillReturnRows(rows)
+			},
+			wantIDs:        []int{1},
+			wantTotal:      3,
+			wantNextCursor: false,
+			wantErr:        nil,
+		},
+		{
+			name:   "Failure case due to cursor of another sort",
+			filter: UserFilter{Sort: "email", Cursor: nameCursor},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+			},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:   "Failure case due to unknown sort",
+			filter: UserFilter{Sort: "password"},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+			},
+			wantErr: ErrInvalidSort,
+		},
+		{
+			name:   "Failure case",
+			filter: UserFilter{},
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT count").WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			got, err := uS.List(context.Background(), tt.filter)
+			if err != tt.wantErr {
+				t.Errorf("userStore.List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.Total != tt.wantTotal || (got.NextCursor != "") != tt.wantNextCursor {
+				t.Errorf("userStore.List() total = %v, nextCursor = %q", got.Total, got.NextCursor)
+			}
+
+			var ids []int
+			for _, user := range got.Users {
+				ids = append(ids, user.ID)
+			}
+
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("userStore.List() ids = %v, want %v", ids, tt.wantIDs)
+			}
+
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("userStore.List() ids = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
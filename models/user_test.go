@@ -1,11 +1,13 @@
 package models
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -270,6 +272,60 @@ func Test_userStore_Update(t *testing.T) {
 			},
 			wantErr: sqlmock.ErrCancelled,
 		},
+		{
+			name: "Success case without updating the password",
+			user: &User{
+				ID:        1,
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Password:  "xasf2415g46",
+				Phone:     "+14155552671",
+				Locale:    "en-US",
+				Timezone:  "America/Los_Angeles",
+				AvatarURL: "https://cdn.example.com/avatar.png",
+				Version:   1,
+			},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "name", "country_id", "email", "password", "version"}).
+					AddRow(1, "Test User", 1, "test@gmail.com", "xasf2415g46", 1)
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET `name`=\\?,`country_id`=\\?,`email`=\\?,`updated_at`=\\?,`email_verified_at`=\\?,`phone`=\\?,`locale`=\\?,`timezone`=\\?,`date_of_birth`=\\?,`avatar_url`=\\?,`version`=\\? WHERE version = \\? AND `users`.`deleted_at` IS NULL AND `id` = \\?").
+					WithArgs("Test User", 1, "test@gmail.com", sqlmock.AnyArg(), nil, "+14155552671", "en-US", "America/Los_Angeles", nil, "https://cdn.example.com/avatar.png", 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Version conflict case",
+			user: &User{
+				ID:        1,
+				Name:      "Test User",
+				CountryID: 1,
+				Email:     "test@gmail.com",
+				Version:   1,
+			},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "name", "country_id", "email", "version"}).
+					AddRow(1, "Test User", 1, "test@gmail.com", 2)
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET").
+					WithArgs("Test User", 1, "test@gmail.com", sqlmock.AnyArg(), nil, "", "", "", nil, "", 2, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				currentRows := sqlmock.NewRows([]string{"id", "name", "country_id", "email", "version"}).
+					AddRow(1, "Test User", 1, "test@gmail.com", 2)
+				mock.ExpectQuery("SELECT").WillReturnRows(currentRows)
+			},
+			wantErr: &VersionConflictError{UserID: 1, ExpectedVersion: 1, CurrentVersion: 2},
+		},
 		// Unable to test these cases due to time related attributes which is expected real time
 		// {
 		// 	name: "Success case",
@@ -332,7 +388,7 @@ func Test_userStore_Update(t *testing.T) {
 			uS := NewUserStore(gormDB)
 
 			err = uS.Update(tt.user)
-			if err != tt.wantErr {
+			if !reflect.DeepEqual(err, tt.wantErr) {
 				t.Errorf("userStore.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
@@ -361,7 +417,7 @@ func Test_userStore_Delete(t *testing.T) {
 				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
 				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
 				mock.ExpectBegin()
-				mock.ExpectExec("DELETE").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE `users` SET `deleted_at`").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
 			wantErr: nil,
@@ -373,7 +429,7 @@ func Test_userStore_Delete(t *testing.T) {
 				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
 				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
 				mock.ExpectBegin()
-				mock.ExpectExec("DELETE").WithArgs(1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectExec("UPDATE `users` SET `deleted_at`").WithArgs(sqlmock.AnyArg(), 1).WillReturnError(sqlmock.ErrCancelled)
 				mock.ExpectRollback()
 			},
 			wantErr: sqlmock.ErrCancelled,
@@ -400,3 +456,760 @@ func Test_userStore_Delete(t *testing.T) {
 		})
 	}
 }
+
+// Test_userStore_UpdateRole runs unit tests on the method UpdateRole
+func Test_userStore_UpdateRole(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET `role`=\\?,`version`=version \\+ 1,`updated_at`=\\? WHERE id = \\?").
+					WithArgs("admin", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.UpdateRole(1, "admin"); err != tt.wantErr {
+				t.Errorf("userStore.UpdateRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_DeleteIfMatch runs unit tests on the method DeleteIfMatch
+func Test_userStore_DeleteIfMatch(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		userID  int
+		version int
+		mock    func()
+		wantErr error
+	}{
+		{
+			name:    "Success case",
+			userID:  1,
+			version: 2,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET `deleted_at`=\\? WHERE version = \\? AND `users`.`id` = \\? AND `users`.`deleted_at` IS NULL").WithArgs(sqlmock.AnyArg(), 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "Version conflict case",
+			userID:  1,
+			version: 2,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				rows := sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 3)
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+			},
+			wantErr: &VersionConflictError{UserID: 1, ExpectedVersion: 2, CurrentVersion: 3},
+		},
+		{
+			name:    "Not found case",
+			userID:  1,
+			version: 2,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:    "Failure case",
+			userID:  1,
+			version: 2,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), 2, 1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.DeleteIfMatch(tt.userID, tt.version); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userStore.DeleteIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_UpdatePassword runs unit tests on the method UpdatePassword
+func Test_userStore_UpdatePassword(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name     string
+		userID   int
+		password string
+		mock     func()
+		wantErr  error
+	}{
+		{
+			name:     "Success case",
+			userID:   1,
+			password: "hash",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs("hash", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "Not found case",
+			userID:   1,
+			password: "hash",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs("hash", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:     "Failure case",
+			userID:   1,
+			password: "hash",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs("hash", sqlmock.AnyArg(), 1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.UpdatePassword(tt.userID, tt.password); err != tt.wantErr {
+				t.Errorf("userStore.UpdatePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_MarkEmailVerified runs unit tests on the method MarkEmailVerified
+func Test_userStore_MarkEmailVerified(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		userID  int
+		email   string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name:   "Success case",
+			userID: 1,
+			email:  "test@gmail.com",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, "test@gmail.com").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:   "Changed email case",
+			userID: 1,
+			email:  "test@gmail.com",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, "test@gmail.com").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.MarkEmailVerified(tt.userID, tt.email); err != tt.wantErr {
+				t.Errorf("userStore.MarkEmailVerified() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_UpdateColumns runs unit tests on the method UpdateColumns
+func Test_userStore_UpdateColumns(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	tests := []struct {
+		name    string
+		user    *User
+		columns []string
+		mockExp func()
+		wantErr error
+	}{
+		{
+			name:    "Success case",
+			user:    &User{ID: 1, Name: "New Name", CountryID: 1, Email: "test@gmail.com", Version: 3},
+			columns: []string{"name"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET `name`=\\?,`updated_at`=\\?,`version`=\\? WHERE version = \\? AND `users`.`deleted_at` IS NULL AND `id` = \\?").
+					WithArgs("New Name", sqlmock.AnyArg(), 4, 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "Not found case",
+			user:    &User{ID: 1, Name: "New Name"},
+			columns: []string{"name"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:    "Version conflict case",
+			user:    &User{ID: 1, Name: "New Name", Version: 3},
+			columns: []string{"name"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				rows := sqlmock.NewRows([]string{"id", "name", "version"}).AddRow(1, "Other Name", 5)
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+			},
+			wantErr: &VersionConflictError{UserID: 1, ExpectedVersion: 3, CurrentVersion: 5},
+		},
+		{
+			name:    "Unknown country case",
+			user:    &User{ID: 1, CountryID: 999, Version: 3},
+			columns: []string{"country_id"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(&mysqldriver.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUnknownCountry,
+		},
+		{
+			name:    "Email of another user case",
+			user:    &User{ID: 1, Email: "taken@gmail.com", Version: 3},
+			columns: []string{"email"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'taken@gmail.com' for key 'email_UNIQUE'"})
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = \\? AND deleted_at IS NOT NULL").
+					WithArgs("taken@gmail.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			wantErr: ErrEmailTaken,
+		},
+		{
+			name:    "Email of a deleted user case",
+			user:    &User{ID: 1, Email: "deleted@gmail.com", Version: 3},
+			columns: []string{"email"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'deleted@gmail.com' for key 'email_UNIQUE'"})
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = \\? AND deleted_at IS NOT NULL").
+					WithArgs("deleted@gmail.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			wantErr: ErrEmailOfDeletedUser,
+		},
+		{
+			name:    "Failure case",
+			user:    &User{ID: 1, Name: "New Name"},
+			columns: []string{"name"},
+			mockExp: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockExp()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.UpdateColumns(tt.user, tt.columns...); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userStore.UpdateColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_GetDeletedByEmail runs unit tests on the method GetDeletedByEmail
+func Test_userStore_GetDeletedByEmail(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	deletedAfter := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "email", "deleted_at"}).AddRow(1, "test@gmail.com", time.Now())
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE email = \\? AND deleted_at > \\? ORDER BY").
+					WithArgs("test@gmail.com", deletedAfter, 1).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			got, err := uS.GetDeletedByEmail("test@gmail.com", deletedAfter)
+			if err != tt.wantErr {
+				t.Errorf("userStore.GetDeletedByEmail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && !got.DeletedAt.Valid {
+				t.Errorf("userStore.GetDeletedByEmail() = %v, want a deleted user", got)
+			}
+		})
+	}
+}
+
+// Test_userStore_GetDeletedByID runs unit tests on the method GetDeletedByID
+func Test_userStore_GetDeletedByID(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	deletedAfter := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				rows := sqlmock.NewRows([]string{"id", "email", "deleted_at"}).AddRow(1, "test@gmail.com", time.Now())
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE id = \\? AND deleted_at > \\? ORDER BY").
+					WithArgs(1, deletedAfter, 1).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			got, err := uS.GetDeletedByID(1, deletedAfter)
+			if err != tt.wantErr {
+				t.Errorf("userStore.GetDeletedByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && !got.DeletedAt.Valid {
+				t.Errorf("userStore.GetDeletedByID() = %v, want a deleted user", got)
+			}
+		})
+	}
+}
+
+// Test_userStore_Restore runs unit tests on the method Restore
+func Test_userStore_Restore(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	deletedAfter := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `users` SET `deleted_at`=\\?,`version`=version \\+ 1,`updated_at`=\\? WHERE id = \\? AND deleted_at > \\?").
+					WithArgs(nil, sqlmock.AnyArg(), 1, deletedAfter).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Not found case",
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			if err := uS.Restore(1, deletedAfter); err != tt.wantErr {
+				t.Errorf("userStore.Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test_userStore_Purge runs unit tests on the method Purge
+func Test_userStore_Purge(t *testing.T) {
+	fDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening a mock database connection", err)
+	}
+	defer fDB.Close()
+
+	deletedBefore := time.Now().Add(-30 * 24 * time.Hour)
+	deletedAt := deletedBefore.Add(-time.Hour)
+
+	deletedUsers := func() {
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "password", "deleted_at"}).
+			AddRow(1, "Test User", "Test@gmail.com", "hash", deletedAt)
+		mock.ExpectQuery("SELECT \\* FROM `users` WHERE deleted_at <= \\? AND erased_at IS NULL").
+			WithArgs(deletedBefore).
+			WillReturnRows(rows)
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		mock    func()
+		want    []int
+		wantErr error
+	}{
+		{
+			name: "Success case deleting users",
+			mode: ErasureDelete,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				deletedUsers()
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `login_attempts` WHERE attempt_key = \\?").
+					WithArgs("account:test@gmail.com").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `users` WHERE `users`.`id` = \\?").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `erasure_certificates`").
+					WithArgs(1, ErasureDelete, "*", deletedAt, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want:    []int{1},
+			wantErr: nil,
+		},
+		{
+			name: "Success case anonymizing users",
+			mode: ErasureAnonymize,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				deletedUsers()
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `login_attempts`").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM `data_exports` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `user_addresses` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `one_time_tokens` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM `backup_codes` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM `totp_factors` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM `oauth_authorization_codes` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `oauth_consents` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `user_identities` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM `identity_states` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM `audit_events` WHERE user_id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE `users` SET `avatar_url`=\\?,`date_of_birth`=\\?,`email`=\\?,`email_verified_at`=\\?,`erased_at`=\\?,`locale`=\\?,`name`=\\?,`password`=\\?,`phone`=\\?,`timezone`=\\?,`updated_at`=\\? WHERE id = \\?").
+					WithArgs("", nil, sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "", "Erased User", "", "", "", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `erasure_certificates`").
+					WithArgs(1, ErasureAnonymize, "name,email,password,email_verified_at,phone,locale,timezone,date_of_birth,avatar_url", deletedAt, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want:    []int{1},
+			wantErr: nil,
+		},
+		{
+			name: "Failure case while erasing",
+			mode: ErasureDelete,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				deletedUsers()
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `login_attempts`").WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectRollback()
+			},
+			want:    nil,
+			wantErr: sqlmock.ErrCancelled,
+		},
+		{
+			name: "Failure case",
+			mode: ErasureDelete,
+			mock: func() {
+				versionRows := sqlmock.NewRows([]string{"version"}).AddRow("1")
+				mock.ExpectQuery("SELECT VERSION").WillReturnRows(versionRows)
+				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
+			},
+			want:    nil,
+			wantErr: sqlmock.ErrCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			dialector := mysql.New(mysql.Config{
+				Conn:       fDB,
+				DriverName: "mysql",
+			})
+			gormDB, err := gorm.Open(dialector, &gorm.Config{})
+			if err != nil {
+				t.Fatalf("Error initializing gormDB: %v", err)
+			}
+
+			uS := NewUserStore(gormDB)
+
+			got, err := uS.Purge(context.Background(), deletedBefore, tt.mode)
+			if err != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userStore.Purge() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("userStore.Purge() %v", err)
+			}
+		})
+	}
+}
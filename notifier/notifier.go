@@ -0,0 +1,66 @@
+// Package notifier delivers messages such as password reset links to the users
+package notifier
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message consisting of all the attributes needed to notify a user
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sentAt"`
+}
+
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileNotifier takes a file path and returns a Notifier for local development
+// which appends every message as a JSON line to the file
+// Messages are written to the standard logger when the path is empty
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{
+		path: path,
+	}
+}
+
+// Notify method takes a Message, stamps the time it was sent
+// writes it to the configured file or the logger
+// and returns an error if any
+func (f *fileNotifier) Notify(msg Message) error {
+	msg.SentAt = time.Now()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if f.path == "" {
+		log.Printf("notification: %s", data)
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
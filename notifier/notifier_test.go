@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test_fileNotifier_Notify runs unit tests on the method Notify
+func Test_fileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{
+			name: "First message",
+			msg:  Message{To: "test@gmail.com", Subject: "Reset your password", Body: "token-1"},
+		},
+		{
+			name: "Second message is appended",
+			msg:  Message{To: "test@gmail.com", Subject: "Reset your password", Body: "token-2"},
+		},
+	}
+
+	n := NewFileNotifier(path)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := n.Notify(tt.msg); err != nil {
+				t.Errorf("fileNotifier.Notify() error = %v", err)
+			}
+		})
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' when opening the notification file", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i := 0; scanner.Scan(); i++ {
+		var got Message
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("Unexpected error '%v' when decoding line %d", err, i)
+		}
+
+		if got.To != tests[i].msg.To || got.Body != tests[i].msg.Body || got.SentAt.IsZero() {
+			t.Errorf("fileNotifier.Notify() wrote %v, want %v", got, tests[i].msg)
+		}
+	}
+}
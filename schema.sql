@@ -5,11 +5,14 @@ CREATE TABLE IF NOT EXISTS `countries`(
   `common_name` varchar(50) NOT NULL,
   `official_name` varchar(100) DEFAULT NULL,
   `country_code` varchar(30) NOT NULL,
+  `alpha3_code` varchar(3) DEFAULT NULL,
   `capital` varchar(50) DEFAULT NULL,
   `region` varchar(50) DEFAULT NULL,
   `sub_region` varchar(50) DEFAULT NULL,
+  `calling_codes` json DEFAULT NULL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `country_code_UNIQUE` (`country_code`)
+  UNIQUE KEY `country_code_UNIQUE` (`country_code`),
+  UNIQUE KEY `alpha3_code_UNIQUE` (`alpha3_code`)
 );
 
 CREATE TABLE IF NOT EXISTS `users`(
@@ -20,7 +23,216 @@ CREATE TABLE IF NOT EXISTS `users`(
   `password` varchar(100) NOT NULL,
   `created_at` datetime NOT NULL,
   `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `email_verified_at` datetime DEFAULT NULL,
+  `version` int NOT NULL DEFAULT 1,
+  `role` varchar(20) NOT NULL DEFAULT 'user',
+  `deleted_at` datetime DEFAULT NULL,
+  `erased_at` datetime DEFAULT NULL,
+  `phone` varchar(16) NOT NULL DEFAULT '',
+  `locale` varchar(35) NOT NULL DEFAULT '',
+  `timezone` varchar(64) NOT NULL DEFAULT '',
+  `date_of_birth` date DEFAULT NULL,
+  `avatar_url` varchar(255) NOT NULL DEFAULT '',
   PRIMARY KEY (`id`),
   UNIQUE KEY `email_UNIQUE` (`email`),
+  KEY `created_at_idx` (`created_at`, `id`),
+  KEY `name_idx` (`name`, `id`),
+  KEY `deleted_at_idx` (`deleted_at`),
   CONSTRAINT `country_fk` FOREIGN KEY (`country_id`) REFERENCES `countries` (`id`)
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `family_id` varchar(64) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `token_hash_UNIQUE` (`token_hash`),
+  KEY `family_id_INDEX` (`family_id`),
+  CONSTRAINT `refresh_token_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens`(
+  `token_id` varchar(64) NOT NULL,
+  `user_id` int NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`token_id`),
+  KEY `revoked_token_user_INDEX` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_revocations`(
+  `user_id` int NOT NULL,
+  `revoked_before` datetime NOT NULL,
+  `kept_session_id` varchar(64) NOT NULL DEFAULT '',
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `user_revocation_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `one_time_tokens`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `purpose` varchar(30) NOT NULL,
+  `email` varchar(50) DEFAULT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `one_time_token_hash_UNIQUE` (`token_hash`),
+  KEY `one_time_token_user_INDEX` (`user_id`, `purpose`),
+  CONSTRAINT `one_time_token_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `login_attempts`(
+  `attempt_key` varchar(100) NOT NULL,
+  `failures` int NOT NULL DEFAULT 0,
+  `last_failed_at` datetime NOT NULL,
+  `locked_until` datetime DEFAULT NULL,
+  PRIMARY KEY (`attempt_key`)
+);
+
+CREATE TABLE IF NOT EXISTS `totp_factors`(
+  `user_id` int NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `confirmed_at` datetime DEFAULT NULL,
+  `last_used_step` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `totp_factor_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `backup_codes`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `backup_code_UNIQUE` (`user_id`, `code_hash`),
+  CONSTRAINT `backup_code_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `data_exports`(
+  `id` varchar(32) NOT NULL,
+  `user_id` int NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `error` varchar(255) DEFAULT NULL,
+  `archive` longblob DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  `completed_at` datetime DEFAULT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `user_id_INDEX` (`user_id`),
+  CONSTRAINT `data_export_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `user_addresses`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `recipient` varchar(100) NOT NULL DEFAULT '',
+  `line1` varchar(100) NOT NULL,
+  `line2` varchar(100) NOT NULL DEFAULT '',
+  `city` varchar(50) NOT NULL,
+  `subdivision` varchar(50) NOT NULL DEFAULT '',
+  `postal_code` varchar(20) NOT NULL DEFAULT '',
+  `country_id` int NOT NULL,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `user_id_INDEX` (`user_id`),
+  CONSTRAINT `user_address_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `user_address_country_fk` FOREIGN KEY (`country_id`) REFERENCES `countries` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `erasure_certificates`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `mode` varchar(20) NOT NULL,
+  `erased_fields` varchar(255) NOT NULL,
+  `deleted_at` datetime NOT NULL,
+  `erased_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `user_id_INDEX` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `oauth_clients`(
+  `client_id` varchar(64) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `redirect_uris` text NOT NULL,
+  `confidential` tinyint(1) NOT NULL DEFAULT 0,
+  `secret_hash` char(64) DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`client_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `oauth_authorization_codes`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `code_hash` char(64) NOT NULL,
+  `client_id` varchar(64) NOT NULL,
+  `user_id` int NOT NULL,
+  `redirect_uri` varchar(2048) NOT NULL,
+  `scope` varchar(255) NOT NULL,
+  `nonce` varchar(255) DEFAULT NULL,
+  `code_challenge` varchar(128) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `oauth_code_hash_UNIQUE` (`code_hash`),
+  CONSTRAINT `oauth_code_client_fk` FOREIGN KEY (`client_id`) REFERENCES `oauth_clients` (`client_id`) ON DELETE CASCADE,
+  CONSTRAINT `oauth_code_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `oauth_consents`(
+  `user_id` int NOT NULL,
+  `client_id` varchar(64) NOT NULL,
+  `scope` varchar(255) NOT NULL,
+  `granted_at` datetime NOT NULL,
+  PRIMARY KEY (`user_id`, `client_id`),
+  CONSTRAINT `oauth_consent_client_fk` FOREIGN KEY (`client_id`) REFERENCES `oauth_clients` (`client_id`) ON DELETE CASCADE,
+  CONSTRAINT `oauth_consent_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `user_identities`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `provider` varchar(20) NOT NULL,
+  `subject` varchar(255) NOT NULL,
+  `email` varchar(255) DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `identity_provider_subject_UNIQUE` (`provider`, `subject`),
+  KEY `identity_user_idx` (`user_id`),
+  CONSTRAINT `identity_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `identity_states`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `state_hash` char(64) NOT NULL,
+  `provider` varchar(20) NOT NULL,
+  `user_id` int DEFAULT NULL,
+  `code_verifier` varchar(128) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `identity_state_hash_UNIQUE` (`state_hash`),
+  CONSTRAINT `identity_state_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `audit_events`(
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `type` varchar(32) NOT NULL,
+  `detail` varchar(255) NOT NULL,
+  `ip` varchar(45) NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `audit_event_user_idx` (`user_id`, `created_at`),
+  CONSTRAINT `audit_event_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,85 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the RFC 7517 JSON Web Key of a public key
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// N and E are the modulus and exponent of RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are the curve and public key of Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is the RFC 7517 JSON Web Key Set downstream services verify tokens with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// errSecretKey is returned when a shared secret would be published
+var errSecretKey = errors.New("a shared secret has no public key")
+
+// JWK method returns the JSON Web Key of the public part of the key along with an error if any
+func (k *Key) JWK() (JWK, error) {
+	jwk := JWK{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Method.Alg(),
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	case nil:
+		return JWK{}, errSecretKey
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	return jwk, nil
+}
+
+// thumbprint function takes a JSON Web Key and
+// returns its RFC 7638 SHA-256 thumbprint, which hashes the required members in lexicographic order
+func thumbprint(jwk JWK) string {
+	var members string
+	if jwk.KeyType == "RSA" {
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, jwk.E, jwk.KeyType, jwk.N)
+	} else {
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, jwk.Curve, jwk.KeyType, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS method returns the public keys of the set, leaving out the shared secret
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(ks.ordered))}
+	for _, key := range ks.ordered {
+		if jwk, err := key.JWK(); err == nil {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
@@ -0,0 +1,306 @@
+// Package signing holds the keys JSON web tokens are signed and verified with
+// and publishes the public ones as a JSON Web Key Set
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verifying tokens
+const minRSABits = 2048
+
+// ErrUnknownKey is returned when a token is signed with a key that is not in the key set
+var ErrUnknownKey = errors.New("jwt token is signed with an unknown key")
+
+// errKeyRetired is returned when a token is signed with a key whose verification deadline has passed
+var errKeyRetired = errors.New("jwt token is signed with a retired key")
+
+// errSigningMethod is returned when a token names another algorithm than the one of its key
+var errSigningMethod = errors.New("jwt token is signed with an unexpected method")
+
+// Key is a key tokens are signed or verified with, identified in their kid header by its ID
+type Key struct {
+	// ID is the RFC 7638 thumbprint of an asymmetric key and empty for the shared secret,
+	// since the tokens signed with the secret before key IDs were introduced carry no kid
+	ID     string
+	Method jwt.SigningMethod
+
+	// signKey is nil for keys that only verify tokens
+	signKey   interface{}
+	verifyKey interface{}
+	// public is nil for the shared secret which must never be published
+	public crypto.PublicKey
+	// verifyUntil is when the key stops verifying tokens, zero when it does not
+	verifyUntil time.Time
+}
+
+// NewSecretKey takes a shared secret and
+// returns the HS256 key signing and verifying tokens with it
+func NewSecretKey(secret []byte) *Key {
+	return &Key{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// NewPrivateKey takes an RSA or Ed25519 private key and
+// returns the RS256 or EdDSA key signing tokens with it along with an error if any
+func NewPrivateKey(private crypto.Signer) (*Key, error) {
+	key, err := NewPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	key.signKey = private
+
+	return key, nil
+}
+
+// NewPublicKey takes an RSA or Ed25519 public key and
+// returns the RS256 or EdDSA key only verifying tokens with it along with an error if any
+func NewPublicKey(public crypto.PublicKey) (*Key, error) {
+	var method jwt.SigningMethod
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("rsa keys must have at least %d bits", minRSABits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys are supported", public)
+	}
+
+	key := &Key{
+		Method:    method,
+		verifyKey: public,
+		public:    public,
+	}
+
+	jwk, err := key.JWK()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = thumbprint(jwk)
+
+	return key, nil
+}
+
+// ParsePEM function takes a PEM encoded PKCS #8 or PKCS #1 private key or PKIX public key and
+// returns the key along with an error if any
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys are supported", private)
+		}
+
+		return NewPrivateKey(signer)
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPrivateKey(private)
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPublicKey(public)
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are still verified with,
+// such as the previous key while the tokens it signed expire or the next key published ahead of a rotation
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	// ordered lists the keys in the order they are published, the signing key first
+	ordered []*Key
+}
+
+// NewKeySet takes the key to sign with, which must hold its private part, and the keys to verify with as well and
+// returns the key set along with an error if any
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing == nil || signing.signKey == nil {
+		return nil, errors.New("the signing key must be a secret or a private key")
+	}
+
+	ks := &KeySet{
+		signing: signing,
+		keys:    make(map[string]*Key),
+	}
+
+	for _, key := range append([]*Key{signing}, verification...) {
+		if _, ok := ks.keys[key.ID]; ok {
+			// Rotating to a key still listed for verification is harmless
+			continue
+		}
+
+		ks.keys[key.ID] = key
+		ks.ordered = append(ks.ordered, key)
+	}
+
+	return ks, nil
+}
+
+// LoadKeySet function takes the PEM file of the private key to sign with, the PEM files of the keys to verify with as well,
+// the shared secret and the time until which it verifies tokens, then returns the key set along with an error if any
+// Tokens are signed with the secret when no key file is given, otherwise the secret only verifies
+// the tokens signed with it before the switch to asymmetric keys, until secretUntil has passed
+// as anyone holding the secret could sign tokens otherwise
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string, secret string, secretUntil time.Time) (*KeySet, error) {
+	var secretKey *Key
+	if secret != "" {
+		secretKey = NewSecretKey([]byte(secret))
+	}
+
+	if signingKeyFile == "" {
+		if secretKey == nil {
+			return nil, errors.New("either a signing key file or a secret is required")
+		}
+
+		return NewKeySet(secretKey)
+	}
+
+	signing, err := loadKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := make([]*Key, 0, len(verificationKeyFiles)+1)
+	for _, file := range verificationKeyFiles {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, err
+		}
+
+		verification = append(verification, key)
+	}
+
+	if secretKey != nil && time.Now().Before(secretUntil) {
+		secretKey.verifyUntil = secretUntil
+		verification = append(verification, secretKey)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+// loadKey function takes a file name and
+// returns the PEM encoded key it holds along with an error if any
+func loadKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return key, nil
+}
+
+// Asymmetric method reports whether the key is an RSA or Ed25519 key
+// whose public part others can verify tokens with, unlike the shared secret
+func (k *Key) Asymmetric() bool {
+	return k.public != nil
+}
+
+// SigningKey method returns the key new tokens are signed with
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// Sign method takes the claims of a token
+// signs them with the signing key, naming it in the kid header, and
+// returns the token along with an error if any
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	if ks.signing.ID != "" {
+		token.Header["kid"] = ks.signing.ID
+	}
+
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Keyfunc method takes a parsed token and
+// returns the key named by its kid header to verify it with
+// Only the algorithm of that key is accepted so a public key can never be used as an HMAC secret
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errSigningMethod
+	}
+
+	if !key.verifyUntil.IsZero() && !time.Now().Before(key.verifyUntil) {
+		return nil, errKeyRetired
+	}
+
+	return key.verifyKey, nil
+}
+
+// Methods method returns the algorithms of the keys in the set
+func (ks *KeySet) Methods() []string {
+	methods := make([]string, 0, len(ks.ordered))
+	for _, key := range ks.ordered {
+		alg := key.Method.Alg()
+		if !contains(methods, alg) {
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+// contains function takes a slice of strings and a value and
+// reports whether the value is in the slice
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,312 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// pemFile function writes the PEM block of the given type and bytes to a file in dir and
+// returns the file name
+func pemFile(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+// Test_thumbprint runs unit tests on the function thumbprint
+func Test_thumbprint(t *testing.T) {
+	// Example of RFC 8037 appendix A.3
+	jwk := JWK{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+
+	if got, want := thumbprint(jwk), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Errorf("thumbprint() = %v, want %v", got, want)
+	}
+}
+
+// TestParsePEM runs unit tests on the function ParsePEM
+func TestParsePEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weakRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8 := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	pkix := func(key interface{}) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	tests := []struct {
+		name       string
+		blockType  string
+		der        []byte
+		wantAlg    string
+		wantSigner bool
+		wantErr    bool
+	}{
+		{name: "Success case with a PKCS #8 RSA key", blockType: "PRIVATE KEY", der: pkcs8(rsaKey), wantAlg: "RS256", wantSigner: true},
+		{name: "Success case with a PKCS #1 RSA key", blockType: "RSA PRIVATE KEY", der: x509.MarshalPKCS1PrivateKey(rsaKey), wantAlg: "RS256", wantSigner: true},
+		{name: "Success case with a PKCS #8 Ed25519 key", blockType: "PRIVATE KEY", der: pkcs8(edPrivate), wantAlg: "EdDSA", wantSigner: true},
+		{name: "Success case with an RSA public key", blockType: "PUBLIC KEY", der: pkix(&rsaKey.PublicKey), wantAlg: "RS256"},
+		{name: "Success case with an Ed25519 public key", blockType: "PUBLIC KEY", der: pkix(edPublic), wantAlg: "EdDSA"},
+		{name: "Failure case due to a weak RSA key", blockType: "RSA PRIVATE KEY", der: x509.MarshalPKCS1PrivateKey(weakRSAKey), wantErr: true},
+		{name: "Failure case due to an unsupported block", blockType: "CERTIFICATE", der: []byte("certificate"), wantErr: true},
+		{name: "Failure case due to invalid bytes", blockType: "PRIVATE KEY", der: []byte("invalid"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePEM(pem.EncodeToMemory(&pem.Block{Type: tt.blockType, Bytes: tt.der}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePEM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if key.Method.Alg() != tt.wantAlg {
+				t.Errorf("ParsePEM() alg = %v, want %v", key.Method.Alg(), tt.wantAlg)
+			}
+
+			if (key.signKey != nil) != tt.wantSigner {
+				t.Errorf("ParsePEM() signer = %v, want %v", key.signKey != nil, tt.wantSigner)
+			}
+
+			if key.ID == "" {
+				t.Errorf("ParsePEM() key ID is empty")
+			}
+		})
+	}
+
+	if _, err := ParsePEM([]byte("not a pem")); err == nil {
+		t.Errorf("ParsePEM() error = nil for data without a PEM block")
+	}
+}
+
+// TestKeySet_Keyfunc runs unit tests on the methods Sign and Keyfunc
+func TestKeySet_Keyfunc(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := NewPrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := NewPrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewPrivateKey(otherPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := NewSecretKey([]byte("secret"))
+
+	ks, err := NewKeySet(current, previous, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(signer *KeySet) string {
+		token, err := signer.Sign(jwt.MapClaims{"id": 1, "iat": time.Now().Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	previousSigner, _ := NewKeySet(previous)
+	otherSigner, _ := NewKeySet(other)
+	secretSigner, _ := NewKeySet(secret)
+
+	// Signs the public RSA key as an HMAC secret under the kid of the previous key
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1})
+	confused.Header["kid"] = previous.ID
+	confusedToken, err := confused.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The secret of a set whose migration deadline has passed while the service is running
+	retired := NewSecretKey([]byte("secret"))
+	retired.verifyUntil = time.Now().Add(-time.Second)
+	retiredSet, err := NewKeySet(current, retired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    *KeySet
+		token   string
+		wantErr error
+	}{
+		{name: "Success case with the signing key", token: sign(ks)},
+		{name: "Success case with the previous key", token: sign(previousSigner)},
+		{name: "Success case with a token signed by the secret without kid", token: sign(secretSigner)},
+		{name: "Failure case due to an unknown key", token: sign(otherSigner), wantErr: ErrUnknownKey},
+		{name: "Failure case due to an algorithm other than the key's", token: confusedToken, wantErr: jwt.ErrTokenUnverifiable},
+		{name: "Failure case due to a token signed by the secret after its deadline", keys: retiredSet, token: sign(secretSigner), wantErr: errKeyRetired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := ks
+			if tt.keys != nil {
+				keys = tt.keys
+			}
+
+			_, err := jwt.Parse(tt.token, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("KeySet.Keyfunc() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("KeySet.Keyfunc() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLoadKeySet runs unit tests on the function LoadKeySet
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nextPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nextDER, err := x509.MarshalPKIXPublicKey(nextPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateFile := pemFile(t, dir, "current.pem", "PRIVATE KEY", privateDER)
+	publicFile := pemFile(t, dir, "current.pub.pem", "PUBLIC KEY", publicDER)
+	nextFile := pemFile(t, dir, "next.pub.pem", "PUBLIC KEY", nextDER)
+
+	secretSigner, err := NewKeySet(NewSecretKey([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secretToken, err := secretSigner.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name               string
+		signingKeyFile     string
+		verificationKeys   []string
+		secret             string
+		secretUntil        time.Time
+		wantAlg            string
+		wantPublished      int
+		wantSecretVerified bool
+		wantErr            bool
+	}{
+		{name: "Success case with the secret only", secret: "secret", wantAlg: "HS256", wantPublished: 0, wantSecretVerified: true},
+		{name: "Success case with a signing key file", signingKeyFile: privateFile, wantAlg: "EdDSA", wantPublished: 1},
+		{name: "Success case with a next key published ahead and the secret for older tokens", signingKeyFile: privateFile, verificationKeys: []string{" " + nextFile, ""}, secret: "secret", secretUntil: time.Now().Add(time.Hour), wantAlg: "EdDSA", wantPublished: 2, wantSecretVerified: true},
+		{name: "Success case with the secret no longer verifying once its deadline has passed", signingKeyFile: privateFile, secret: "secret", secretUntil: time.Now().Add(-time.Second), wantAlg: "EdDSA", wantPublished: 1},
+		{name: "Success case with the secret not verifying without a deadline", signingKeyFile: privateFile, secret: "secret", wantAlg: "EdDSA", wantPublished: 1},
+		{name: "Success case with the signing key listed for verification too", signingKeyFile: privateFile, verificationKeys: []string{publicFile}, wantAlg: "EdDSA", wantPublished: 1},
+		{name: "Failure case due to neither a key file nor a secret", wantErr: true},
+		{name: "Failure case due to a public signing key", signingKeyFile: publicFile, wantErr: true},
+		{name: "Failure case due to a missing verification key file", signingKeyFile: privateFile, verificationKeys: []string{filepath.Join(dir, "missing.pem")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := LoadKeySet(tt.signingKeyFile, tt.verificationKeys, tt.secret, tt.secretUntil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if alg := ks.SigningKey().Method.Alg(); alg != tt.wantAlg {
+				t.Errorf("LoadKeySet() signing alg = %v, want %v", alg, tt.wantAlg)
+			}
+
+			if published := len(ks.JWKS().Keys); published != tt.wantPublished {
+				t.Errorf("LoadKeySet() published keys = %v, want %v", published, tt.wantPublished)
+			}
+
+			_, err = jwt.Parse(secretToken, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+			if verified := err == nil; verified != tt.wantSecretVerified {
+				t.Errorf("LoadKeySet() HS256 token verified = %v, want %v, error %v", verified, tt.wantSecretVerified, err)
+			}
+		})
+	}
+}
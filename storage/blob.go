@@ -0,0 +1,135 @@
+// Package storage keeps binary objects such as avatar images outside of the database
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under a key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// errInvalidKey is returned for keys that would escape the storage root
+var errInvalidKey = errors.New("invalid blob key")
+
+// Blob consisting of the content of a stored object and its attributes
+type Blob struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore keeps blobs under slash separated keys such as avatars/1/256
+// so that a local directory or an S3-compatible bucket can back it
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type localBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore takes a directory and returns a BlobStore
+// keeping every blob as a file under that directory
+func NewLocalBlobStore(dir string) BlobStore {
+	return &localBlobStore{
+		dir: dir,
+	}
+}
+
+// path method takes a key and returns the file the blob is kept in
+func (l *localBlobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", errInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", errInvalidKey
+		}
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put method takes a context, a key, the data and its content type
+// writes the data to a temporary file renamed over the blob so readers never see a partial blob
+// and returns an error if any
+// The file system keeps no content type so it is sniffed from the data when read
+func (l *localBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Get method takes a context and a key, reads the blob from its file and
+// returns the Blob along with ErrBlobNotFound or any other error
+func (l *localBlobStore) Get(ctx context.Context, key string) (*Blob, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Blob{
+		Data:        data,
+		ContentType: http.DetectContentType(data),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// Delete method takes a context and a key, removes the blob if it exists
+// and returns an error if any
+func (l *localBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
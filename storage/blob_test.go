@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Test_localBlobStore runs unit tests on the methods Put, Get and Delete
+func Test_localBlobStore(t *testing.T) {
+	ctx := context.Background()
+	png := []byte("\x89PNG\r\n\x1a\nrest of the image")
+
+	tests := []struct {
+		name    string
+		key     string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "Success case", key: "avatars/1/256", data: png, wantErr: false},
+		{name: "Success case overwriting a blob", key: "avatars/1/256", data: append([]byte{}, png...), wantErr: false},
+		{name: "Failure case due to parent directory in key", key: "avatars/../../etc/passwd", data: png, wantErr: true},
+		{name: "Failure case due to absolute key", key: "/etc/passwd", data: png, wantErr: true},
+	}
+
+	bs := NewLocalBlobStore(t.TempDir())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bs.Put(ctx, tt.key, tt.data, "image/png"); (err != nil) != tt.wantErr {
+				t.Fatalf("localBlobStore.Put() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got, err := bs.Get(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("localBlobStore.Get() error = %v", err)
+			}
+
+			if !bytes.Equal(got.Data, tt.data) || got.ContentType != "image/png" || got.ModTime.IsZero() {
+				t.Errorf("localBlobStore.Get() = %+v, want %s", got, tt.data)
+			}
+		})
+	}
+
+	if err := bs.Delete(ctx, "avatars/1/256"); err != nil {
+		t.Errorf("localBlobStore.Delete() error = %v", err)
+	}
+
+	if _, err := bs.Get(ctx, "avatars/1/256"); err != ErrBlobNotFound {
+		t.Errorf("localBlobStore.Get() after Delete() error = %v, want %v", err, ErrBlobNotFound)
+	}
+
+	if err := bs.Delete(ctx, "avatars/1/256"); err != nil {
+		t.Errorf("localBlobStore.Delete() of a missing blob error = %v", err)
+	}
+}